@@ -2,21 +2,29 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/goblinsan/gh-project-helper/pkg/engine"
-	"github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/state"
 	"github.com/goblinsan/gh-project-helper/pkg/types"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
 func init() {
 	rootCmd.AddCommand(applyCmd)
 	applyCmd.Flags().StringP("file", "f", "", "The plan file to apply")
-	applyCmd.MarkFlagRequired("file")
+	applyCmd.Flags().String("plan-file", "", "Apply a change set previously saved by the plan command")
 	applyCmd.Flags().Bool("dry-run", false, "Preview what would be created without making changes")
+	applyCmd.Flags().Bool("reconcile", false, "Update body, labels, assignees, milestone and status of existing issues to match the plan")
+	applyCmd.Flags().String("state", state.DefaultPath, "State file recording the GitHub objects managed by the plan (empty to disable)")
+	applyCmd.Flags().IntP("concurrency", "j", 1, "Number of epics and issues to apply at once")
+	applyCmd.Flags().Bool("resume", false, "Continue an apply that stopped part way, skipping the steps its journal records")
+	applyCmd.Flags().String("record", "", "Debug: record every GitHub API request and response, with tokens scrubbed, to this cassette file")
+	addPruneFlags(applyCmd)
 }
 
 var applyCmd = &cobra.Command{
@@ -25,30 +33,90 @@ var applyCmd = &cobra.Command{
 	Long:  `Apply a project plan from a YAML file to create GitHub projects, epics, and issues.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		filePath, _ := cmd.Flags().GetString("file")
-
-		// Read the YAML file
-		yamlFile, err := os.ReadFile(filePath)
+		changeSetPath, _ := cmd.Flags().GetString("plan-file")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		reconcile, _ := cmd.Flags().GetBool("reconcile")
+		statePath, _ := cmd.Flags().GetString("state")
+		resume, _ := cmd.Flags().GetBool("resume")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		prune, err := prunePolicy(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to read file: %w", err)
+			return err
+		}
+		if resume && (statePath == "" || dryRun) {
+			return fmt.Errorf("--resume needs a state file and cannot be combined with --dry-run")
+		}
+		opts := engine.Options{
+			DryRun:      dryRun,
+			Reconcile:   reconcile,
+			Prune:       prune,
+			Concurrency: concurrency,
 		}
 
-		// Unmarshal the YAML file into a Plan struct
+		// Read either a saved change set or the YAML plan file
+		var cs *engine.ChangeSet
 		var plan types.Plan
-		err = yaml.Unmarshal(yamlFile, &plan)
+		switch {
+		case changeSetPath != "":
+			cs, err = readChangeSet(changeSetPath)
+		case filePath != "":
+			plan, err = readPlanFile(filePath)
+		default:
+			err = fmt.Errorf("either --file or --plan-file is required")
+		}
 		if err != nil {
-			return fmt.Errorf("failed to unmarshal YAML: %w", err)
+			return err
 		}
 
-		// Create a new GitHub client
-		client, err := github.NewClient()
+		// Create a new GitHub client for the host the plan targets
+		target := plan
+		if cs != nil {
+			target = cs.Plan
+		}
+		client, saveCache, err := newClient(cmd, target)
 		if err != nil {
-			return fmt.Errorf("failed to create github client: %w", err)
+			return err
 		}
+		defer saveCache()
 
-		dryRun, _ := cmd.Flags().GetBool("dry-run")
-		report, err := engine.ApplyPlan(context.Background(), client, plan, engine.Options{
-			DryRun: dryRun,
-		})
+		if statePath != "" {
+			st, release, err := openState(statePath, dryRun)
+			if err != nil {
+				return err
+			}
+			defer release()
+			opts.State = st
+		}
+
+		// Journal every mutation next to the state file so a failed apply can be resumed
+		if opts.State != nil && !dryRun {
+			opts.Journal, err = state.OpenJournal(state.JournalPath(statePath), resume)
+			if err != nil {
+				return err
+			}
+		}
+
+		var report *engine.Report
+		if cs != nil {
+			report, err = engine.ApplyChangeSet(context.Background(), client, cs, opts)
+		} else {
+			report, err = engine.ApplyPlan(context.Background(), client, plan, opts)
+		}
+
+		// Save whatever was resolved or created, even if the apply failed part way
+		if opts.State != nil && !dryRun {
+			if saveErr := opts.State.Save(statePath); saveErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save state: %v\n", saveErr)
+			}
+		}
+		if opts.Journal != nil {
+			if err != nil {
+				opts.Journal.Close()
+				fmt.Fprintf(os.Stderr, "Run apply again with --resume to continue from where it stopped\n")
+			} else if rmErr := opts.Journal.Remove(); rmErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to remove journal: %v\n", rmErr)
+			}
+		}
 		if err != nil {
 			return err
 		}
@@ -58,3 +126,81 @@ var applyCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// readPlanFile reads and unmarshals a YAML plan file.
+func readPlanFile(path string) (types.Plan, error) {
+	var plan types.Plan
+
+	yamlFile, err := os.ReadFile(path)
+	if err != nil {
+		return plan, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(yamlFile, &plan); err != nil {
+		return plan, fmt.Errorf("failed to unmarshal YAML: %w", err)
+	}
+	return plan, nil
+}
+
+// readChangeSet reads a change set saved by the plan command.
+func readChangeSet(path string) (*engine.ChangeSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change set: %w", err)
+	}
+
+	var cs engine.ChangeSet
+	if err := json.Unmarshal(data, &cs); err != nil {
+		return nil, fmt.Errorf("failed to parse change set: %w", err)
+	}
+	return &cs, nil
+}
+
+// openState loads the state file at path. Unless readOnly is set, the state is
+// locked first; the returned release function unlocks it.
+func openState(path string, readOnly bool) (*state.State, func(), error) {
+	release := func() {}
+	if !readOnly {
+		lock, err := state.AcquireLock(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		release = func() {
+			if err := lock.Release(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to release state lock: %v\n", err)
+			}
+		}
+	}
+
+	st, err := state.Load(path)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return st, release, nil
+}
+
+func addPruneFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("prune", false, "Handle managed issues that are no longer in the plan according to --prune-policy")
+	cmd.Flags().String("prune-policy", string(engine.PruneReport), "What --prune does with dropped issues: report, close (as not planned) or remove (from the project)")
+}
+
+// prunePolicy returns the prune policy selected by --prune. The policy comes from
+// --prune-policy, falling back to prune_policy in the config file.
+func prunePolicy(cmd *cobra.Command) (engine.PrunePolicy, error) {
+	if enabled, _ := cmd.Flags().GetBool("prune"); !enabled {
+		return engine.PruneNone, nil
+	}
+	policy, _ := cmd.Flags().GetString("prune-policy")
+	if !cmd.Flags().Changed("prune-policy") && viper.IsSet("prune_policy") {
+		policy = viper.GetString("prune_policy")
+	}
+	p, err := engine.ParsePrunePolicy(policy)
+	if err != nil {
+		return engine.PruneNone, err
+	}
+	if p == engine.PruneNone {
+		return engine.PruneReport, nil
+	}
+	return p, nil
+}
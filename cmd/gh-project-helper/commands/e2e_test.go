@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goblinsan/gh-project-helper/pkg/engine"
+	"github.com/goblinsan/gh-project-helper/pkg/github/fakegithub"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+const e2ePlan = `project: "Roadmap"
+repository: "acme/roadmap"
+linking: "sub_issues"
+labels:
+  - name: "backend"
+    color: "1d76db"
+milestones:
+  - title: "Phase 1"
+    due_on: "2026-11-30"
+    description: "First phase"
+epics:
+  - title: "User Schema Refactor"
+    body: "Multi-tenancy"
+    milestone: "Phase 1"
+    status: "In Progress"
+    labels: ["backend"]
+    assignees: ["octocat"]
+    fields:
+      Priority: "P1"
+    children:
+      - title: "Create migration script"
+        body: "Alter the users table"
+      - title: "Update ORM models"
+        body: "Update the structs"
+        status: "Todo"
+        depends_on: ["Create migration script"]
+`
+
+// newFakeGitHub starts a fake GitHub with an acme/roadmap repository and a
+// Roadmap board that has a Priority field, and points the commands at it.
+func newFakeGitHub(t *testing.T) *fakegithub.Server {
+	t.Helper()
+	s := fakegithub.New()
+	t.Cleanup(s.Close)
+	s.AddRepository("acme", "roadmap")
+	s.AddProject("acme", "Roadmap").AddField("Priority", "SINGLE_SELECT", "P0", "P1", "P2")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GH_PROJECT_HELPER_TOKEN", "test-token")
+	return s
+}
+
+// execute runs the CLI with args and resets every flag afterwards, since the
+// commands are package globals shared between tests.
+func execute(t *testing.T, args ...string) error {
+	t.Helper()
+	rootCmd.SetArgs(args)
+	defer rootCmd.SetArgs(nil)
+	defer resetFlags(rootCmd)
+	return rootCmd.Execute()
+}
+
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		f.Value.Set(f.DefValue)
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+func TestEndToEnd_ApplyAndExport(t *testing.T) {
+	s := newFakeGitHub(t)
+	dir := t.TempDir()
+	planPath := filepath.Join(dir, "plan.yaml")
+	if err := os.WriteFile(planPath, []byte(e2ePlan), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execute(t, "apply", "-f", planPath, "--host", s.URL, "--state", ""); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	assertBoard(t, s)
+
+	// A second apply finds everything and creates nothing
+	if err := execute(t, "apply", "-f", planPath, "--host", s.URL, "--state", ""); err != nil {
+		t.Fatalf("second apply failed: %v", err)
+	}
+	if n := len(s.Repository("acme", "roadmap").Issues); n != 3 {
+		t.Errorf("expected the second apply to create no issues, have %d", n)
+	}
+
+	// A closed issue on the board is left out, since apply would create it again
+	closed := s.Repository("acme", "roadmap").AddIssue("Retired task", "")
+	closed.State = "CLOSED"
+	s.Project("acme", "Roadmap").AddItem(closed, nil)
+
+	outPath := filepath.Join(dir, "exported.yaml")
+	if err := execute(t, "export", "--host", s.URL, "-r", "acme/roadmap", "-p", "Roadmap", "-o", outPath); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported types.Plan
+	if err := yaml.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("failed to parse the exported plan: %v", err)
+	}
+	if len(exported.Epics) != 1 {
+		t.Fatalf("expected one exported epic, got %+v", exported.Epics)
+	}
+	epic := exported.Epics[0]
+	got := fmt.Sprintf("%s %s %s %v %v %s %d", epic.Title, epic.Status, epic.Milestone, epic.Labels, epic.Assignees, epic.Fields["Priority"], len(epic.Children))
+	if want := "User Schema Refactor In Progress Phase 1 [backend] [octocat] P1 2"; got != want {
+		t.Errorf("unexpected exported epic:\n got %s\nwant %s", got, want)
+	}
+	if deps := epic.Children[1].DependsOn; fmt.Sprint(deps) != "[Create migration script]" {
+		t.Errorf("expected the dependency to be exported, got %v", deps)
+	}
+	if exported.Host != s.URL {
+		t.Errorf("expected the exported plan to target %s, got %q", s.URL, exported.Host)
+	}
+
+	// Planning the exported plan against the same board changes nothing
+	csPath := filepath.Join(dir, "changes.json")
+	if err := execute(t, "plan", "-f", outPath, "--host", s.URL, "--state", "", "--reconcile", "-o", csPath); err != nil {
+		t.Fatalf("plan of the exported plan failed: %v", err)
+	}
+	data, err = os.ReadFile(csPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cs engine.ChangeSet
+	if err := json.Unmarshal(data, &cs); err != nil {
+		t.Fatalf("failed to parse the change set: %v", err)
+	}
+	if cs.HasChanges() || cs.Count(engine.ActionDrift) > 0 {
+		t.Errorf("expected the exported plan to be unchanged, got %s", cs.String())
+	}
+}
+
+func TestEndToEnd_Serve(t *testing.T) {
+	s := newFakeGitHub(t)
+	var plan types.Plan
+	if err := yaml.Unmarshal([]byte(e2ePlan), &plan); err != nil {
+		t.Fatal(err)
+	}
+	plan.Host = s.URL
+	t.Setenv("GH_HOST", s.URL)
+	args, _ := json.Marshal(plan)
+	params, _ := json.Marshal(mcpToolCallParams{Name: "apply_project_plan", Arguments: args})
+
+	resp := handleToolCall(jsonRPCRequest{JSONRPC: "2.0", ID: json.RawMessage(`1`), Method: "tools/call", Params: params})
+	result, ok := resp.Result.(mcpToolCallResult)
+	if !ok || result.IsError {
+		t.Fatalf("expected a successful tool call, got %+v", resp)
+	}
+	assertBoard(t, s)
+
+	// A plan cannot send the token to a host the user did not configure
+	plan.Host = "https://evil.example"
+	args, _ = json.Marshal(plan)
+	params, _ = json.Marshal(mcpToolCallParams{Name: "apply_project_plan", Arguments: args})
+	resp = handleToolCall(jsonRPCRequest{JSONRPC: "2.0", ID: json.RawMessage(`2`), Method: "tools/call", Params: params})
+	if result, ok := resp.Result.(mcpToolCallResult); !ok || !result.IsError || !strings.Contains(result.Content[0].Text, "configured host") {
+		t.Errorf("expected the foreign plan host to be rejected, got %+v", resp)
+	}
+}
+
+// assertBoard checks the fake GitHub holds the board e2ePlan describes.
+func assertBoard(t *testing.T, s *fakegithub.Server) {
+	t.Helper()
+	repo := s.Repository("acme", "roadmap")
+	project := s.Project("acme", "Roadmap")
+
+	milestone := repo.Milestone("Phase 1")
+	if milestone == nil || milestone.Description != "First phase" || milestone.DueOn == nil {
+		t.Errorf("unexpected milestone %+v", milestone)
+	}
+	if label := repo.Label("backend"); label == nil || label.Color != "1d76db" {
+		t.Errorf("unexpected label %+v", label)
+	}
+
+	epic := repo.Issue("User Schema Refactor")
+	if epic == nil {
+		t.Fatalf("epic was not created, issues: %d", len(repo.Issues))
+	}
+	got := fmt.Sprintf("%s %s %v %v", epic.Body, epic.Milestone.Title, epic.LabelNames(), epic.AssigneeLogins())
+	if want := "Multi-tenancy Phase 1 [backend] [octocat]"; got != want {
+		t.Errorf("unexpected epic:\n got %s\nwant %s", got, want)
+	}
+	item := project.Item("User Schema Refactor")
+	if item == nil || item.Values["Status"] != "In Progress" || item.Values["Priority"] != "P1" {
+		t.Errorf("unexpected epic item %+v", item)
+	}
+
+	migration, orm := repo.Issue("Create migration script"), repo.Issue("Update ORM models")
+	if migration == nil || orm == nil {
+		t.Fatal("children were not created")
+	}
+	if migration.Parent != epic || orm.Parent != epic {
+		t.Error("expected the children to be sub-issues of the epic")
+	}
+	if len(orm.BlockedBy) != 1 || orm.BlockedBy[0] != migration {
+		t.Errorf("expected %q to be blocked by %q", orm.Title, migration.Title)
+	}
+	if item := project.Item("Update ORM models"); item == nil || item.Values["Status"] != "Todo" {
+		t.Errorf("unexpected child item %+v", item)
+	}
+	if len(project.Items) != 3 {
+		var titles []string
+		for _, i := range project.Items {
+			titles = append(titles, i.Issue.Title)
+		}
+		t.Errorf("expected three items on the board, got %s", strings.Join(titles, ", "))
+	}
+}
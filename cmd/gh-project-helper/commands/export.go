@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/goblinsan/gh-project-helper/pkg/engine"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringP("repository", "r", "", "Owner/repo whose issues on the board are exported")
+	exportCmd.Flags().StringP("project", "p", "", "The Project V2 board title")
+	exportCmd.MarkFlagRequired("repository")
+	exportCmd.MarkFlagRequired("project")
+	exportCmd.Flags().StringP("out", "o", "", "Write the plan to this file instead of stdout")
+	exportCmd.Flags().String("format", "yaml", "Output format: yaml or json")
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export an existing Project V2 board as a plan",
+	Long: `Read the issues on a Project V2 board, with their status, custom fields,
+milestones, labels, assignees, tasklist or sub-issue hierarchy and dependencies,
+and write them out as a plan that apply leaves unchanged. Anything the plan format
+cannot express exactly is reported as a warning.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repository, _ := cmd.Flags().GetString("repository")
+		project, _ := cmd.Flags().GetString("project")
+		outPath, _ := cmd.Flags().GetString("out")
+		format, _ := cmd.Flags().GetString("format")
+
+		client, saveCache, err := newClient(cmd, types.Plan{Repository: repository})
+		if err != nil {
+			return err
+		}
+		defer saveCache()
+
+		plan, warnings, err := engine.ExportPlan(context.Background(), client, repository, project)
+		if err != nil {
+			return err
+		}
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+
+		data, err := marshalPlan(plan, format)
+		if err != nil {
+			return err
+		}
+		if outPath == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(outPath, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write plan: %w", err)
+		}
+		fmt.Printf("Exported %d epics to %s\n", len(plan.Epics), outPath)
+		return nil
+	},
+}
+
+// marshalPlan encodes a plan as YAML or JSON.
+func marshalPlan(plan types.Plan, format string) ([]byte, error) {
+	switch format {
+	case "yaml":
+		return yaml.Marshal(plan)
+	case "json":
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	return nil, fmt.Errorf("format %q must be yaml or json", format)
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/goblinsan/gh-project-helper/pkg/engine"
+	"github.com/goblinsan/gh-project-helper/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	planCmd.Flags().StringP("file", "f", "", "The plan file to compare against GitHub")
+	planCmd.MarkFlagRequired("file")
+	planCmd.Flags().StringP("out", "o", "", "Save the computed change set to this file for use with apply --plan-file")
+	planCmd.Flags().Bool("reconcile", false, "Plan updates to existing issues instead of reporting them as drift")
+	planCmd.Flags().String("state", state.DefaultPath, "State file written by apply, used to skip lookups (empty to disable)")
+	addPruneFlags(planCmd)
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the changes apply would make without making them",
+	Long: `Read the current state of the repository, milestones, issues and Project V2 items
+and compute a change set (create / update / unchanged / drift) for every milestone,
+epic and child issue in the plan. The change set can be saved with --out and passed
+to apply --plan-file so that exactly what was reviewed gets executed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filePath, _ := cmd.Flags().GetString("file")
+		plan, err := readPlanFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		client, saveCache, err := newClient(cmd, plan)
+		if err != nil {
+			return err
+		}
+		defer saveCache()
+
+		reconcile, _ := cmd.Flags().GetBool("reconcile")
+		prune, err := prunePolicy(cmd)
+		if err != nil {
+			return err
+		}
+		opts := engine.Options{Reconcile: reconcile, Prune: prune}
+
+		statePath, _ := cmd.Flags().GetString("state")
+		if statePath != "" {
+			st, release, err := openState(statePath, true)
+			if err != nil {
+				return err
+			}
+			defer release()
+			opts.State = st
+		}
+
+		cs, err := engine.ComputeChangeSet(context.Background(), client, plan, opts)
+		if err != nil {
+			return err
+		}
+		cs.Render(os.Stdout)
+
+		outPath, _ := cmd.Flags().GetString("out")
+		if outPath != "" {
+			data, err := json.MarshalIndent(cs, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode change set: %w", err)
+			}
+			if err := os.WriteFile(outPath, data, 0o644); err != nil {
+				return fmt.Errorf("failed to write change set: %w", err)
+			}
+			fmt.Printf("Change set saved to %s. Run \"gh-project-helper apply --plan-file %s\" to apply it.\n", outPath, outPath)
+		}
+		return nil
+	},
+}
@@ -3,9 +3,15 @@ package commands
 import (
 	"fmt"
 	"os"
+	"sort"
+	"time"
 
+	"github.com/goblinsan/gh-project-helper/pkg/engine"
+	"github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
 )
 
 var cfgFile string
@@ -36,6 +42,11 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gh-project-helper.yaml)")
+	rootCmd.PersistentFlags().String("token", "", "GitHub token (default is taken from the environment, config file, gh CLI or OS keyring)")
+	rootCmd.PersistentFlags().String("profile", "", "Config file profile whose token to use (default picks one by host and repository owner)")
+	rootCmd.PersistentFlags().String("host", "", "GitHub host, e.g. a GitHub Enterprise Server (default is GH_HOST, host in the config file or github.com)")
+	rootCmd.PersistentFlags().String("cache", "", "File caching repository, project, milestone, label and user IDs between runs")
+	rootCmd.PersistentFlags().Duration("cache-ttl", time.Hour, "How long entries of the --cache file stay valid")
 }
 
 func initConfig() {
@@ -60,3 +71,191 @@ func initConfig() {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
+
+// resolveHost returns the GitHub host to talk to: --host, GH_HOST or host in
+// the config file, defaulting to github.com. A plan may name a host too, but
+// only the configured one, so that a plan file or MCP call cannot redirect the
+// user's credentials to another server.
+func resolveHost(planHost string) (string, error) {
+	host := configuredHost()
+	if planHost != "" && !github.SameHost(planHost, host) {
+		return "", fmt.Errorf("the plan targets host %s but the configured host is %s; pass --host %s to use it", planHost, host, planHost)
+	}
+	return host, nil
+}
+
+// configuredHost returns the host given by --host, GH_HOST or host in the
+// config file, in that order, or github.com.
+func configuredHost() string {
+	flagHost, _ := rootCmd.PersistentFlags().GetString("host")
+	switch {
+	case flagHost != "":
+		return flagHost
+	case os.Getenv("GH_HOST") != "":
+		return os.Getenv("GH_HOST")
+	case viper.GetString("host") != "":
+		return viper.GetString("host")
+	}
+	return github.DefaultHost
+}
+
+// newClient creates the GitHub client of a command for the plan's host (see
+// resolveHost) and repository owner (see githubClient). Lookups are cached for
+// the run; if --cache (or cache in the config file) names a file, the cache is
+// loaded from it and the returned save function writes it back. If the command
+// has a --record flag, the save function also writes the recorded requests.
+func newClient(cmd *cobra.Command, plan types.Plan) (*engine.CachingClient, func(), error) {
+	host, err := resolveHost(plan.Host)
+	if err != nil {
+		return nil, nil, err
+	}
+	owner := splitRepo(plan.Repository)[0]
+	var client *github.Client
+	saveRecording := func() {}
+	if record, _ := cmd.Flags().GetString("record"); record != "" {
+		client, saveRecording, err = recordingClient(host, owner, record)
+	} else {
+		client, err = githubClient(host, owner)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create github client: %w", err)
+	}
+	cached := engine.NewCachingClient(client, host)
+
+	path, _ := cmd.Flags().GetString("cache")
+	if !cmd.Flags().Changed("cache") && viper.IsSet("cache") {
+		path = viper.GetString("cache")
+	}
+	if path == "" {
+		return cached, saveRecording, nil
+	}
+	ttl, _ := cmd.Flags().GetDuration("cache-ttl")
+	if !cmd.Flags().Changed("cache-ttl") && viper.IsSet("cache_ttl") {
+		ttl = viper.GetDuration("cache_ttl")
+	}
+	if err := cached.Load(path, ttl); err != nil {
+		return nil, nil, err
+	}
+	save := func() {
+		if err := cached.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save cache: %v\n", err)
+		}
+		saveRecording()
+	}
+	return cached, save, nil
+}
+
+// recordingClient is githubClient for the --record debug flag: every request
+// and response is also recorded, with tokens scrubbed, and the returned save
+// function writes them to a cassette file at path.
+func recordingClient(host, owner, path string) (*github.Client, func(), error) {
+	ts, _, err := credentials(host, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	recorder := github.NewRecorder(github.TokenTransport(ts))
+	client, err := github.NewClientWithTransport(host, recorder)
+	if err != nil {
+		return nil, nil, err
+	}
+	save := func() {
+		if err := recorder.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save recording: %v\n", err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Recorded GitHub API requests to %s\n", path)
+	}
+	return client, save, nil
+}
+
+// githubClient creates a client for host that authenticates as described by
+// credentials.
+func githubClient(host, owner string) (*github.Client, error) {
+	ts, _, err := credentials(host, owner)
+	if err != nil {
+		return nil, err
+	}
+	return github.NewClientWithTokenSource(host, ts)
+}
+
+// credentials returns the token source for host and owner and where its tokens
+// come from. --token comes first. Next, when app_id is configured, tokens are
+// minted for the GitHub App's installation on owner (or on
+// app_installation_id) with the key from app_private_key or
+// app_private_key_file. The App is only used on app_host, which defaults to
+// host in the config file or github.com. Otherwise the token is found by
+// github.ResolveCredential, with the profiles of the config file.
+func credentials(host, owner string) (oauth2.TokenSource, string, error) {
+	flagToken, _ := rootCmd.PersistentFlags().GetString("token")
+	if flagToken == "" && viper.GetString("app_id") != "" {
+		app := github.AppAuth{
+			AppID:          viper.GetInt64("app_id"),
+			PrivateKey:     []byte(viper.GetString("app_private_key")),
+			InstallationID: viper.GetInt64("app_installation_id"),
+			Owner:          owner,
+			Host:           viper.GetString("app_host"),
+		}
+		if app.Host == "" {
+			app.Host = viper.GetString("host")
+		}
+		if path := viper.GetString("app_private_key_file"); path != "" {
+			key, err := os.ReadFile(path)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read GitHub App private key: %w", err)
+			}
+			app.PrivateKey = key
+		}
+		ts, err := github.AppTokenSource(host, app)
+		if err != nil {
+			return nil, "", err
+		}
+		return ts, fmt.Sprintf("GitHub App %d installation", app.AppID), nil
+	}
+
+	profiles, err := configProfiles()
+	if err != nil {
+		return nil, "", err
+	}
+	profile, _ := rootCmd.PersistentFlags().GetString("profile")
+	if profile == "" {
+		profile = viper.GetString("profile")
+	}
+	credential, err := github.ResolveCredential(github.CredentialOptions{
+		Host:           host,
+		Owner:          owner,
+		ConfiguredHost: configuredHost(),
+		FlagToken:      flagToken,
+		Profile:        profile,
+		Profiles:       profiles,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: credential.Token}), credential.Source, nil
+}
+
+// configProfiles returns the profiles section of the config file, sorted by
+// name, followed by the top-level token as a profile without a name for the
+// configured host.
+func configProfiles() ([]github.Profile, error) {
+	var named map[string]github.Profile
+	if err := viper.UnmarshalKey("profiles", &named); err != nil {
+		return nil, fmt.Errorf("failed to read profiles from config file: %w", err)
+	}
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var profiles []github.Profile
+	for _, name := range names {
+		p := named[name]
+		p.Name = name
+		profiles = append(profiles, p)
+	}
+	if token := viper.GetString("token"); token != "" {
+		profiles = append(profiles, github.Profile{Host: viper.GetString("host"), Token: token})
+	}
+	return profiles, nil
+}
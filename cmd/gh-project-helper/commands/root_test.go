@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestResolveHost(t *testing.T) {
+	t.Setenv("GH_HOST", "")
+	if host, err := resolveHost(""); err != nil || host != "github.com" {
+		t.Errorf("expected github.com by default, got %q, %v", host, err)
+	}
+
+	t.Setenv("GH_HOST", "ghes.example.com")
+	if host, _ := resolveHost(""); host != "ghes.example.com" {
+		t.Errorf("expected GH_HOST, got %q", host)
+	}
+	if host, err := resolveHost("https://ghes.example.com"); err != nil || host != "ghes.example.com" {
+		t.Errorf("expected a plan host matching GH_HOST to be accepted, got %q, %v", host, err)
+	}
+	if _, err := resolveHost("other.example.com"); err == nil {
+		t.Error("expected an error when the plan and GH_HOST disagree")
+	}
+
+	rootCmd.PersistentFlags().Set("host", "flag.example.com")
+	t.Cleanup(func() { rootCmd.PersistentFlags().Set("host", "") })
+	if host, _ := resolveHost(""); host != "flag.example.com" {
+		t.Errorf("expected --host to win over GH_HOST, got %q", host)
+	}
+	if _, err := resolveHost("other.example.com"); err == nil {
+		t.Error("expected an error when the plan and --host disagree")
+	}
+	if host, err := resolveHost("flag.example.com"); err != nil || host != "flag.example.com" {
+		t.Errorf("expected the plan host to be accepted when passed as --host, got %q, %v", host, err)
+	}
+}
+
+func TestConfigProfiles(t *testing.T) {
+	viper.Set("profiles", map[string]interface{}{
+		"work":   map[string]interface{}{"host": "ghes.example.com", "owner": "acme", "token": "work-token"},
+		"public": map[string]interface{}{"token": "public-token"},
+	})
+	viper.Set("token", "default-token")
+	t.Cleanup(viper.Reset)
+
+	profiles, err := configProfiles()
+	if err != nil {
+		t.Fatalf("configProfiles failed: %v", err)
+	}
+	expected := "[{public   public-token} {work ghes.example.com acme work-token} {   default-token}]"
+	if fmt.Sprint(profiles) != expected {
+		t.Errorf("unexpected profiles:\n got %v\nwant %s", profiles, expected)
+	}
+}
+
+func TestScopeWarning(t *testing.T) {
+	if w := scopeWarning([]string{"repo", "project"}); w != "" {
+		t.Errorf("expected no warning with the project scope, got %q", w)
+	}
+	if w := scopeWarning([]string{"repo", "read:project"}); w == "" {
+		t.Error("expected a warning with only read:project")
+	}
+	if w := scopeWarning([]string{"repo"}); w == "" {
+		t.Error("expected a warning without the project scope")
+	}
+}
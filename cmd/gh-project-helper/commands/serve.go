@@ -83,14 +83,35 @@ var applyToolSchema = json.RawMessage(`{
   "properties": {
     "project": {"type": "string", "description": "The GitHub Project V2 board title"},
     "repository": {"type": "string", "description": "Owner/repo (e.g. my-org/my-repo)"},
+    "host": {"type": "string", "description": "GitHub host of the repository; must match the host the server was started with (default github.com)"},
+    "linking": {"type": "string", "enum": ["tasklist", "sub_issues", "both"], "description": "How children are linked to their epic: a tasklist in the epic body (default), native sub-issues, or both"},
+    "max_depth": {"type": "integer", "description": "Maximum nesting depth, counting epics as level 1 (default 8)"},
+    "iteration_field": {"type": "string", "description": "The iteration field set by iteration; only needed when the project has several"},
+    "labels": {
+      "type": "array",
+      "description": "Label definitions; missing labels are created and existing ones recolored or renamed from an alias before issues are created",
+      "items": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "color": {"type": "string", "description": "Six digit hex color, e.g. d73a4a"},
+          "description": {"type": "string"},
+          "aliases": {"type": "array", "items": {"type": "string"}, "description": "Former names to rename from"}
+        },
+        "required": ["name"]
+      }
+    },
+    "label_policy": {"type": "string", "enum": ["declared", "any"], "description": "Whether epics and issues may use labels missing from labels (default declared when labels are given)"},
     "milestones": {
       "type": "array",
       "items": {
         "type": "object",
         "properties": {
+          "id": {"type": "string", "description": "Optional stable ID used to find the milestone after renames"},
           "title": {"type": "string"},
-          "due_on": {"type": "string"},
-          "description": {"type": "string"}
+          "due_on": {"type": "string", "description": "A date such as 2026-04-01 or an RFC 3339 time"},
+          "description": {"type": "string"},
+          "state": {"type": "string", "enum": ["open", "closed"]}
         },
         "required": ["title"]
       }
@@ -100,20 +121,34 @@ var applyToolSchema = json.RawMessage(`{
       "items": {
         "type": "object",
         "properties": {
+          "id": {"type": "string", "description": "Optional stable ID embedded as a hidden marker in the issue body"},
           "title": {"type": "string"},
           "body": {"type": "string"},
           "milestone": {"type": "string"},
           "status": {"type": "string"},
           "labels": {"type": "array", "items": {"type": "string"}},
           "assignees": {"type": "array", "items": {"type": "string"}},
+          "fields": {"type": "object", "description": "Project V2 custom field values by field name (text, number, YYYY-MM-DD date, single-select option or iteration title)"},
+          "iteration": {"type": "string", "description": "Iteration title, or current, next or +N relative to the iteration in progress"},
+          "depends_on": {"type": "array", "items": {"type": "string"}, "description": "IDs or titles of epics or issues that block this one"},
+          "blocks": {"type": "array", "items": {"type": "string"}, "description": "IDs or titles of epics or issues this one blocks"},
           "children": {
             "type": "array",
             "items": {
               "type": "object",
               "properties": {
+                "id": {"type": "string"},
                 "title": {"type": "string"},
                 "body": {"type": "string"},
-                "labels": {"type": "array", "items": {"type": "string"}}
+                "milestone": {"type": "string", "description": "Defaults to the parent's milestone"},
+                "status": {"type": "string", "description": "Defaults to the parent's status"},
+                "labels": {"type": "array", "items": {"type": "string"}},
+                "assignees": {"type": "array", "items": {"type": "string"}, "description": "Defaults to the parent's assignees; an empty list leaves the issue unassigned"},
+                "fields": {"type": "object", "description": "Merged over the parent's fields"},
+                "iteration": {"type": "string", "description": "Defaults to the parent's iteration"},
+                "depends_on": {"type": "array", "items": {"type": "string"}},
+                "blocks": {"type": "array", "items": {"type": "string"}},
+                "children": {"type": "array", "items": {"type": "object"}, "description": "Nested child issues with the same properties"}
               },
               "required": ["title"]
             }
@@ -203,7 +238,11 @@ func handleToolCall(req jsonRPCRequest) jsonRPCResponse {
 		}
 	}
 
-	client, err := github.NewClient()
+	var client *github.Client
+	host, err := resolveHost(plan.Host)
+	if err == nil {
+		client, err = githubClient(host, splitRepo(plan.Repository)[0])
+	}
 	if err != nil {
 		return jsonRPCResponse{
 			JSONRPC: "2.0",
@@ -215,7 +254,7 @@ func handleToolCall(req jsonRPCRequest) jsonRPCResponse {
 		}
 	}
 
-	report, err := engine.ApplyPlan(context.Background(), client, plan, engine.Options{})
+	report, err := engine.ApplyPlan(context.Background(), engine.NewCachingClient(client, host), plan, engine.Options{})
 	if err != nil {
 		return jsonRPCResponse{
 			JSONRPC: "2.0",
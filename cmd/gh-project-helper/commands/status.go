@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/goblinsan/gh-project-helper/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().String("state", state.DefaultPath, "The state file to read")
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the GitHub objects recorded in the state file",
+	Long:  `Show the repository, project, milestones and issues that previous applies recorded in the state file, and whether an apply currently holds the state lock.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		statePath, _ := cmd.Flags().GetString("state")
+		if _, err := os.Stat(statePath); errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("No state file at %s. Run apply to create one.\n", statePath)
+			return nil
+		}
+
+		st, err := state.Load(statePath)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Repository: %s\n", st.Repository)
+		fmt.Printf("Project:    %s\n", st.Project)
+		fmt.Printf("Updated:    %s\n", st.UpdatedAt.Format("2006-01-02 15:04:05 MST"))
+		if _, err := os.Stat(state.LockPath(statePath)); err == nil {
+			fmt.Printf("Locked:     yes (%s)\n", state.LockPath(statePath))
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "KIND\tNUMBER\tKEY\tTITLE")
+		for _, key := range st.MilestoneKeys() {
+			m, _ := st.Milestone(key)
+			fmt.Fprintf(w, "milestone\t%d\t%s\t%s\n", m.Number, key, m.Title)
+		}
+		for _, key := range st.IssueKeys() {
+			issue, _ := st.Issue(key)
+			fmt.Fprintf(w, "%s\t#%d\t%s\t%s\n", issue.Kind, issue.Number, key, issue.Title)
+		}
+		return w.Flush()
+	},
+}
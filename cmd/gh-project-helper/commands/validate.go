@@ -3,7 +3,13 @@ package commands
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/goblinsan/gh-project-helper/pkg/engine"
+	"github.com/goblinsan/gh-project-helper/pkg/github"
 	"github.com/goblinsan/gh-project-helper/pkg/types"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -13,12 +19,13 @@ func init() {
 	rootCmd.AddCommand(validateCmd)
 	validateCmd.Flags().StringP("file", "f", "", "The plan file to validate")
 	validateCmd.MarkFlagRequired("file")
+	validateCmd.Flags().Int("max-depth", 0, fmt.Sprintf("Maximum nesting depth, counting epics as level 1 (overrides max_depth in the plan; default %d)", types.DefaultMaxDepth))
 }
 
 var validateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "Validate a plan file without making any changes",
-	Long:  `Validate a plan YAML file for correctness. Checks structure, required fields, and referential integrity (e.g. epic milestones reference defined milestones).`,
+	Long:  `Validate a plan YAML file for correctness. Checks structure, required fields, unique titles across the whole issue tree, nesting depth, label colors, and referential integrity (e.g. epic milestones reference defined milestones and labels are declared).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		filePath, _ := cmd.Flags().GetString("file")
 
@@ -31,6 +38,9 @@ var validateCmd = &cobra.Command{
 		if err := yaml.Unmarshal(yamlFile, &plan); err != nil {
 			return fmt.Errorf("invalid YAML: %w", err)
 		}
+		if cmd.Flags().Changed("max-depth") {
+			plan.MaxDepth, _ = cmd.Flags().GetInt("max-depth")
+		}
 
 		errs := validatePlan(plan)
 		if len(errs) > 0 {
@@ -46,6 +56,12 @@ var validateCmd = &cobra.Command{
 	},
 }
 
+// labelColorPattern matches a label color, a six digit hex code with an optional leading '#'.
+var labelColorPattern = regexp.MustCompile(`^#?[0-9A-Fa-f]{6}$`)
+
+// planIDPattern restricts plan IDs to characters that are safe inside the hidden body marker.
+var planIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
 func validatePlan(plan types.Plan) []string {
 	var errs []string
 
@@ -62,9 +78,26 @@ func validatePlan(plan types.Plan) []string {
 		errs = append(errs, "project is required")
 	}
 
+	if plan.Host != "" {
+		if _, _, err := github.Endpoints(plan.Host); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	switch plan.Linking {
+	case "", types.LinkTasklist, types.LinkSubIssues, types.LinkBoth:
+	default:
+		errs = append(errs, fmt.Sprintf("linking %q must be one of %s, %s or %s", plan.Linking, types.LinkTasklist, types.LinkSubIssues, types.LinkBoth))
+	}
+
+	labelErrs, checkLabels := validateLabels(plan)
+	errs = append(errs, labelErrs...)
+
 	// Build milestone index for referential integrity checks
 	milestoneSet := make(map[string]bool)
+	milestoneIDs := make(map[string]bool)
 	for i, m := range plan.Milestones {
+		errs = append(errs, validateID(fmt.Sprintf("milestones[%d]", i), m.ID, milestoneIDs)...)
 		if m.Title == "" {
 			errs = append(errs, fmt.Sprintf("milestones[%d]: title is required", i))
 			continue
@@ -73,36 +106,186 @@ func validatePlan(plan types.Plan) []string {
 			errs = append(errs, fmt.Sprintf("milestones[%d]: duplicate title %q", i, m.Title))
 		}
 		milestoneSet[m.Title] = true
+		if m.DueOn != "" {
+			if _, err := engine.ParseDueOn(m.DueOn); err != nil {
+				errs = append(errs, fmt.Sprintf("milestones[%d] %q: %v", i, m.Title, err))
+			}
+		}
+		switch m.State {
+		case "", types.MilestoneOpen, types.MilestoneClosed:
+		default:
+			errs = append(errs, fmt.Sprintf("milestones[%d] %q: state %q must be %s or %s", i, m.Title, m.State, types.MilestoneOpen, types.MilestoneClosed))
+		}
+	}
+
+	maxDepth := plan.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = types.DefaultMaxDepth
+	}
+	if maxDepth < 1 {
+		errs = append(errs, fmt.Sprintf("max_depth %d must be at least 1", plan.MaxDepth))
+	}
+
+	// Issues are found by title, so titles must be unique across the whole tree
+	titles := make(map[string]bool)
+	issueIDs := make(map[string]bool)
+
+	var validateChildren func(path string, children []types.Issue, depth int)
+	validateChildren = func(path string, children []types.Issue, depth int) {
+		for j, child := range children {
+			childPath := fmt.Sprintf("%s.children[%d]", path, j)
+			errs = append(errs, validateID(childPath, child.ID, issueIDs)...)
+			if child.Title == "" {
+				errs = append(errs, fmt.Sprintf("%s: title is required", childPath))
+				continue
+			}
+			if titles[child.Title] {
+				errs = append(errs, fmt.Sprintf("%s: duplicate title %q", childPath, child.Title))
+			}
+			titles[child.Title] = true
+			if child.Milestone != "" && !milestoneSet[child.Milestone] {
+				errs = append(errs, fmt.Sprintf("%s %q: milestone %q is not defined in milestones section", childPath, child.Title, child.Milestone))
+			}
+			errs = append(errs, checkLabels(childPath, child.Title, child.Labels)...)
+			errs = append(errs, validateFields(childPath, child.Fields)...)
+
+			if depth > maxDepth {
+				errs = append(errs, fmt.Sprintf("%s %q: nested %d levels deep, more than max_depth %d", childPath, child.Title, depth, maxDepth))
+				continue
+			}
+			validateChildren(childPath, child.Children, depth+1)
+		}
 	}
 
-	epicTitles := make(map[string]bool)
 	for i, epic := range plan.Epics {
+		errs = append(errs, validateID(fmt.Sprintf("epics[%d]", i), epic.ID, issueIDs)...)
 		if epic.Title == "" {
 			errs = append(errs, fmt.Sprintf("epics[%d]: title is required", i))
 			continue
 		}
-		if epicTitles[epic.Title] {
+		if titles[epic.Title] {
 			errs = append(errs, fmt.Sprintf("epics[%d]: duplicate title %q", i, epic.Title))
 		}
-		epicTitles[epic.Title] = true
+		titles[epic.Title] = true
 
 		if epic.Milestone != "" && !milestoneSet[epic.Milestone] {
 			errs = append(errs, fmt.Sprintf("epics[%d] %q: milestone %q is not defined in milestones section", i, epic.Title, epic.Milestone))
 		}
+		errs = append(errs, checkLabels(fmt.Sprintf("epics[%d]", i), epic.Title, epic.Labels)...)
+		errs = append(errs, validateFields(fmt.Sprintf("epics[%d]", i), epic.Fields)...)
 
-		childTitles := make(map[string]bool)
-		for j, child := range epic.Children {
-			if child.Title == "" {
-				errs = append(errs, fmt.Sprintf("epics[%d].children[%d]: title is required", i, j))
-				continue
+		validateChildren(fmt.Sprintf("epics[%d]", i), epic.Children, 2)
+	}
+
+	// depends_on and blocks must resolve and the issues must have a creation order
+	if err := engine.ValidateDependencies(plan); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	return errs
+}
+
+// validateLabels checks the labels section and label_policy, and returns a
+// function that checks the labels used by an epic or issue against them.
+func validateLabels(plan types.Plan) ([]string, func(path, title string, labels []string) []string) {
+	var errs []string
+
+	policy := plan.LabelPolicy
+	switch policy {
+	case "":
+		policy = types.LabelPolicyAny
+		if len(plan.Labels) > 0 {
+			policy = types.LabelPolicyDeclared
+		}
+	case types.LabelPolicyDeclared, types.LabelPolicyAny:
+	default:
+		errs = append(errs, fmt.Sprintf("label_policy %q must be %s or %s", plan.LabelPolicy, types.LabelPolicyDeclared, types.LabelPolicyAny))
+	}
+
+	// Label names are case-insensitive on GitHub
+	declared := make(map[string]bool)
+	renamed := make(map[string]string)
+	for i, l := range plan.Labels {
+		path := fmt.Sprintf("labels[%d]", i)
+		if l.Name == "" {
+			errs = append(errs, fmt.Sprintf("%s: name is required", path))
+			continue
+		}
+		if declared[strings.ToLower(l.Name)] {
+			errs = append(errs, fmt.Sprintf("%s: duplicate name %q", path, l.Name))
+		}
+		declared[strings.ToLower(l.Name)] = true
+		if l.Color != "" && !labelColorPattern.MatchString(l.Color) {
+			errs = append(errs, fmt.Sprintf("%s %q: color %q must be a six digit hex code such as d73a4a", path, l.Name, l.Color))
+		}
+		for _, alias := range l.Aliases {
+			if _, ok := renamed[strings.ToLower(alias)]; ok {
+				errs = append(errs, fmt.Sprintf("%s %q: alias %q is used by more than one label", path, l.Name, alias))
 			}
-			if childTitles[child.Title] {
-				errs = append(errs, fmt.Sprintf("epics[%d].children[%d]: duplicate title %q", i, j, child.Title))
+			renamed[strings.ToLower(alias)] = l.Name
+		}
+	}
+	var conflicts []string
+	for alias, name := range renamed {
+		if declared[alias] {
+			conflicts = append(conflicts, fmt.Sprintf("label %q is declared and also an alias of %q", alias, name))
+		}
+	}
+	sort.Strings(conflicts)
+	errs = append(errs, conflicts...)
+
+	check := func(path, title string, labels []string) []string {
+		var errs []string
+		for _, label := range labels {
+			switch {
+			case declared[strings.ToLower(label)]:
+			case renamed[strings.ToLower(label)] != "":
+				errs = append(errs, fmt.Sprintf("%s %q: label %q has been renamed to %q", path, title, label, renamed[strings.ToLower(label)]))
+			case policy == types.LabelPolicyDeclared:
+				errs = append(errs, fmt.Sprintf("%s %q: label %q is not declared in labels section (set label_policy: any to allow it)", path, title, label))
 			}
-			childTitles[child.Title] = true
 		}
+		return errs
+	}
+	return errs, check
+}
+
+// validateID checks the format of an optional plan ID and that it is unique within seen.
+func validateID(path, id string, seen map[string]bool) []string {
+	if id == "" {
+		return nil
+	}
+	if !planIDPattern.MatchString(id) {
+		return []string{fmt.Sprintf("%s: id %q may only contain letters, digits, '.', '_' and '-'", path, id)}
+	}
+	if seen[id] {
+		return []string{fmt.Sprintf("%s: duplicate id %q", path, id)}
 	}
+	seen[id] = true
+	return nil
+}
+
+// validateFields checks that custom field values are strings, numbers or dates.
+// Field names and options can only be checked against the project at apply time.
+func validateFields(path string, fields types.Fields) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
+	var errs []string
+	for _, name := range names {
+		if name == "" {
+			errs = append(errs, fmt.Sprintf("%s: field name is required", path))
+			continue
+		}
+		switch fields[name].(type) {
+		case string, int, float64, time.Time:
+		default:
+			errs = append(errs, fmt.Sprintf("%s: field %q must be a string, number or date", path, name))
+		}
+	}
 	return errs
 }
 
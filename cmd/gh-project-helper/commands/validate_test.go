@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/goblinsan/gh-project-helper/pkg/types"
@@ -77,6 +78,29 @@ func TestValidatePlan_UndefinedMilestone(t *testing.T) {
 	}
 }
 
+func TestValidatePlan_UndefinedChildMilestone(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test",
+		Repository: "owner/repo",
+		Milestones: []types.Milestone{{Title: "Phase 1"}},
+		Epics: []types.Epic{
+			{
+				Title:     "Epic 1",
+				Milestone: "Phase 1",
+				Children: []types.Issue{
+					{Title: "Child 1"},
+					{Title: "Child 2", Milestone: "Phase 2"},
+				},
+			},
+		},
+	}
+	errs := validatePlan(plan)
+	expected := `epics[0].children[1] "Child 2": milestone "Phase 2" is not defined in milestones section`
+	if len(errs) != 1 || errs[0] != expected {
+		t.Errorf("expected %q, got %v", expected, errs)
+	}
+}
+
 func TestValidatePlan_DuplicateTitles(t *testing.T) {
 	plan := types.Plan{
 		Project:    "Test",
@@ -120,3 +144,226 @@ func TestValidatePlan_MissingChildTitle(t *testing.T) {
 		t.Errorf("expected child title error, got %v", errs)
 	}
 }
+
+func TestValidatePlan_IDs(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test",
+		Repository: "owner/repo",
+		Milestones: []types.Milestone{
+			{ID: "phase-1", Title: "Phase 1"},
+		},
+		Epics: []types.Epic{
+			{ID: "phase-1", Title: "Epic 1", Children: []types.Issue{
+				{ID: "child-1", Title: "Child 1"},
+				{ID: "child-1", Title: "Child 2"},
+			}},
+			{ID: "bad id -->", Title: "Epic 2"},
+		},
+	}
+	errs := validatePlan(plan)
+	expected := []string{
+		`epics[0].children[1]: duplicate id "child-1"`,
+		`epics[1]: id "bad id -->" may only contain letters, digits, '.', '_' and '-'`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, e := range expected {
+		if errs[i] != e {
+			t.Errorf("expected %q, got %q", e, errs[i])
+		}
+	}
+}
+
+func TestValidatePlan_Linking(t *testing.T) {
+	plan := types.Plan{Project: "Test", Repository: "owner/repo", Linking: types.LinkSubIssues}
+	if errs := validatePlan(plan); len(errs) != 0 {
+		t.Errorf("expected no errors for sub_issues linking, got %v", errs)
+	}
+
+	plan.Linking = "checklist"
+	errs := validatePlan(plan)
+	expected := `linking "checklist" must be one of tasklist, sub_issues or both`
+	if len(errs) != 1 || errs[0] != expected {
+		t.Errorf("expected %q, got %v", expected, errs)
+	}
+}
+
+func TestValidatePlan_Fields(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test",
+		Repository: "owner/repo",
+		Epics: []types.Epic{
+			{Title: "Epic 1", Fields: types.Fields{"Priority": "P1", "Estimate": 3, "Flags": []interface{}{"a"}}, Children: []types.Issue{
+				{Title: "Child 1", Fields: types.Fields{"Notes": nil}},
+			}},
+		},
+	}
+	errs := validatePlan(plan)
+	expected := []string{
+		`epics[0]: field "Flags" must be a string, number or date`,
+		`epics[0].children[0]: field "Notes" must be a string, number or date`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, e := range expected {
+		if errs[i] != e {
+			t.Errorf("expected %q, got %q", e, errs[i])
+		}
+	}
+}
+
+func TestValidatePlan_Milestones(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test",
+		Repository: "owner/repo",
+		Milestones: []types.Milestone{
+			{Title: "Phase 1", DueOn: "2026-04-01", State: types.MilestoneClosed},
+			{Title: "Phase 2", DueOn: "2026-04-01T17:00:00-07:00"},
+			{Title: "Phase 3", DueOn: "April 1st", State: "done"},
+		},
+	}
+	errs := validatePlan(plan)
+	expected := []string{
+		`milestones[2] "Phase 3": due_on "April 1st" must be a date (2006-01-02) or an RFC 3339 time`,
+		`milestones[2] "Phase 3": state "done" must be open or closed`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, e := range expected {
+		if errs[i] != e {
+			t.Errorf("expected %q, got %q", e, errs[i])
+		}
+	}
+}
+
+func TestValidatePlan_Labels(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test",
+		Repository: "owner/repo",
+		Labels: []types.Label{
+			{Name: "bug", Color: "#d73a4a"},
+			{Name: "ui", Color: "blue", Aliases: []string{"frontend"}},
+			{Name: "Bug"},
+		},
+		Epics: []types.Epic{
+			{Title: "Epic 1", Labels: []string{"BUG", "frontend"}, Children: []types.Issue{
+				{Title: "Child 1", Labels: []string{"ui", "misc"}},
+			}},
+		},
+	}
+	errs := validatePlan(plan)
+	expected := []string{
+		`labels[1] "ui": color "blue" must be a six digit hex code such as d73a4a`,
+		`labels[2]: duplicate name "Bug"`,
+		`epics[0] "Epic 1": label "frontend" has been renamed to "ui"`,
+		`epics[0].children[0] "Child 1": label "misc" is not declared in labels section (set label_policy: any to allow it)`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, e := range expected {
+		if errs[i] != e {
+			t.Errorf("expected %q, got %q", e, errs[i])
+		}
+	}
+
+	// Undeclared labels are allowed by policy, or when no labels are declared
+	plan.Labels = plan.Labels[:1]
+	plan.Epics[0].Labels = []string{"bug"}
+	plan.LabelPolicy = types.LabelPolicyAny
+	if errs := validatePlan(plan); len(errs) != 0 {
+		t.Errorf("expected no errors with label_policy any, got %v", errs)
+	}
+	plan.Labels = nil
+	plan.LabelPolicy = ""
+	if errs := validatePlan(plan); len(errs) != 0 {
+		t.Errorf("expected no errors without declared labels, got %v", errs)
+	}
+
+	plan.LabelPolicy = "strict"
+	errs = validatePlan(plan)
+	if len(errs) != 1 || errs[0] != `label_policy "strict" must be declared or any` {
+		t.Errorf("expected a label_policy error, got %v", errs)
+	}
+}
+
+func TestValidatePlan_NestedChildren(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test",
+		Repository: "owner/repo",
+		MaxDepth:   3,
+		Epics: []types.Epic{
+			{Title: "Initiative", Children: []types.Issue{
+				{Title: "Epic A", Children: []types.Issue{
+					{Title: "Story A1", Children: []types.Issue{
+						{Title: "Task A1a"},
+					}},
+					{Title: ""},
+				}},
+				{Title: "Epic B", Children: []types.Issue{
+					{Title: "Story A1"},
+				}},
+			}},
+			{Title: "Other", Children: []types.Issue{
+				{Title: "Epic B"},
+			}},
+		},
+	}
+	errs := validatePlan(plan)
+	expected := []string{
+		`epics[0].children[0].children[0].children[0] "Task A1a": nested 4 levels deep, more than max_depth 3`,
+		"epics[0].children[0].children[1]: title is required",
+		`epics[0].children[1].children[0]: duplicate title "Story A1"`,
+		`epics[1].children[0]: duplicate title "Epic B"`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, e := range expected {
+		if errs[i] != e {
+			t.Errorf("expected %q, got %q", e, errs[i])
+		}
+	}
+
+	plan.MaxDepth = 0
+	for _, e := range validatePlan(plan) {
+		if strings.Contains(e, "max_depth") {
+			t.Errorf("expected the default max depth to allow 4 levels, got %q", e)
+		}
+	}
+}
+
+func TestValidatePlan_Dependencies(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test",
+		Repository: "owner/repo",
+		Epics: []types.Epic{
+			{ID: "api", Title: "API", Children: []types.Issue{
+				{Title: "Schema", Blocks: []string{"Endpoints"}},
+				{Title: "Endpoints"},
+			}},
+			{Title: "UI", DependsOn: []string{"api"}},
+		},
+	}
+	if errs := validatePlan(plan); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+
+	plan.Epics[1].DependsOn = []string{"Backend"}
+	errs := validatePlan(plan)
+	expected := `epic "UI": depends_on "Backend" does not match any id or title in the plan`
+	if len(errs) != 1 || errs[0] != expected {
+		t.Errorf("expected %q, got %v", expected, errs)
+	}
+
+	plan.Epics[1].DependsOn = []string{"api"}
+	plan.Epics[0].Children[1].DependsOn = []string{"UI"}
+	errs = validatePlan(plan)
+	expected = "dependency cycle: API -> Endpoints -> UI -> API"
+	if len(errs) != 1 || errs[0] != expected {
+		t.Errorf("expected %q, got %v", expected, errs)
+	}
+}
@@ -3,6 +3,9 @@ package commands
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/goblinsan/gh-project-helper/pkg/github"
 	"github.com/spf13/cobra"
@@ -10,22 +13,68 @@ import (
 
 func init() {
 	rootCmd.AddCommand(whoamiCmd)
+	whoamiCmd.Flags().String("owner", "", "Organization or user whose profile (or GitHub App installation) to check")
 }
 
 var whoamiCmd = &cobra.Command{
 	Use:   "whoami",
 	Short: "Prints the logged in user's login",
-	Long:  `This command prints the login of the user that is currently logged in.`,
+	Long: `This command prints the login of the user that is currently logged in, followed by
+the host, where the token was found and the scopes it has. It warns when the token
+lacks the project scope that apply needs.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client, err := github.NewClient()
+		owner, _ := cmd.Flags().GetString("owner")
+		host, err := resolveHost("")
 		if err != nil {
 			return err
 		}
-		user, err := client.GetAuthenticatedUser(context.Background())
+		ts, source, err := credentials(host, owner)
+		if err != nil {
+			return err
+		}
+		client, err := github.NewClientWithTokenSource(host, ts)
+		if err != nil {
+			return err
+		}
+
+		// Installation tokens cannot read /user, so minting one is the check
+		if strings.HasPrefix(source, "GitHub App") {
+			if _, err := ts.Token(); err != nil {
+				return err
+			}
+			fmt.Println(source)
+			fmt.Printf("Host: %s\n", host)
+			return nil
+		}
+
+		user, scopes, err := client.GetAuthenticatedUser(context.Background())
 		if err != nil {
 			return err
 		}
 		fmt.Println(*user.Login)
+		fmt.Printf("Host: %s\n", host)
+		fmt.Printf("Token from: %s\n", source)
+		if scopes == nil {
+			fmt.Println("Scopes: not reported (fine-grained token)")
+			return nil
+		}
+		fmt.Printf("Scopes: %s\n", strings.Join(scopes, ", "))
+		if warning := scopeWarning(scopes); warning != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
 		return nil
 	},
 }
+
+// scopeWarning explains what is missing when the OAuth scopes of a classic
+// token do not allow editing Projects V2 boards.
+func scopeWarning(scopes []string) string {
+	switch {
+	case slices.Contains(scopes, "project"):
+		return ""
+	case slices.Contains(scopes, "read:project"):
+		return "the token has the read:project scope but not project, so apply cannot change boards; run gh auth refresh -s project"
+	default:
+		return "the token lacks the project scope, so apply cannot read or change boards; run gh auth refresh -s project"
+	}
+}
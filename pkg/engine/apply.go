@@ -3,9 +3,11 @@ package engine
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	ghclient "github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/state"
 	"github.com/goblinsan/gh-project-helper/pkg/types"
 	gogithub "github.com/google/go-github/v66/github"
 	"github.com/shurcooL/githubv4"
@@ -16,14 +18,38 @@ type GitHubClient interface {
 	GetRepositoryID(ctx context.Context, owner, name string) (string, error)
 	GetProjectV2ID(ctx context.Context, owner, title string) (string, error)
 	GetProjectV2StatusFieldOptions(ctx context.Context, projectID githubv4.ID) (githubv4.ID, map[string]string, error)
-	GetOrCreateMilestone(ctx context.Context, owner, repo, title, description, dueOn string) (*gogithub.Milestone, error)
+	GetProjectV2Fields(ctx context.Context, projectID githubv4.ID) ([]ghclient.ProjectV2Field, error)
+	ListMilestones(ctx context.Context, owner, repo string) ([]*gogithub.Milestone, error)
+	CreateMilestone(ctx context.Context, owner, repo string, milestone *gogithub.Milestone) (*gogithub.Milestone, error)
+	EditMilestone(ctx context.Context, owner, repo string, number int, milestone *gogithub.Milestone) (*gogithub.Milestone, error)
 	GetMilestoneID(ctx context.Context, owner, name string, number int) (string, error)
 	FindIssueByTitle(ctx context.Context, owner, repo, title string) (int, string, error)
+	ListIssues(ctx context.Context, owner, repo string) ([]*gogithub.Issue, error)
+	GetIssue(ctx context.Context, owner, repo string, number int) (*ghclient.IssueDetails, error)
+	CloseIssue(ctx context.Context, issueID githubv4.ID, reason githubv4.IssueClosedStateReason) error
 	GetOrCreateLabel(ctx context.Context, owner, repo, labelName string) (githubv4.ID, error)
+	ListLabels(ctx context.Context, owner, repo string) ([]*gogithub.Label, error)
+	CreateLabel(ctx context.Context, owner, repo string, label *gogithub.Label) (*gogithub.Label, error)
+	EditLabel(ctx context.Context, owner, repo, name string, label *gogithub.Label) (*gogithub.Label, error)
 	GetUserID(ctx context.Context, login string) (githubv4.ID, error)
 	CreateIssue(ctx context.Context, input githubv4.CreateIssueInput) (*ghclient.CreateIssueMutation, error)
+	UpdateIssue(ctx context.Context, input githubv4.UpdateIssueInput) error
 	AddIssueToProjectV2(ctx context.Context, projectID, contentID githubv4.ID) (*ghclient.AddProjectV2ItemMutation, error)
 	UpdateProjectV2ItemStatus(ctx context.Context, projectID, itemID, fieldID githubv4.ID, optionID string) error
+	// The batch methods send many mutations per request; see ghclient.BatchError.
+	CreateIssues(ctx context.Context, inputs []githubv4.CreateIssueInput) ([]*ghclient.CreateIssueMutation, error)
+	AddIssuesToProjectV2(ctx context.Context, projectID githubv4.ID, contentIDs []githubv4.ID) ([]githubv4.ID, error)
+	UpdateProjectV2ItemFieldValues(ctx context.Context, inputs []githubv4.UpdateProjectV2ItemFieldValueInput) error
+	DeleteProjectV2Item(ctx context.Context, projectID, itemID githubv4.ID) error
+	ListProjectV2Items(ctx context.Context, projectID githubv4.ID) ([]ghclient.ProjectV2Item, error)
+	AddSubIssue(ctx context.Context, issueID, subIssueID githubv4.ID, replaceParent bool) error
+	SupportsIssueDependencies(ctx context.Context) (bool, error)
+	GetBlockedBy(ctx context.Context, owner, repo string, number int) ([]int, error)
+	AddBlockedBy(ctx context.Context, issueID, blockingIssueID githubv4.ID) error
+	// Host is the GitHub host the client talks to.
+	Host() string
+	// RateLimit reports the remaining rate limit budget so apply can pace itself.
+	RateLimit() ghclient.RateLimit
 }
 
 // Ensure *github.Client satisfies the interface at compile time.
@@ -31,253 +57,816 @@ var _ GitHubClient = (*ghclient.Client)(nil)
 
 // Options configures the behavior of ApplyPlan.
 type Options struct {
+	// DryRun computes and prints the change set instead of applying it.
 	DryRun bool
+	// Reconcile updates the body, labels, assignees, milestone and status of
+	// existing issues to match the plan instead of skipping them.
+	Reconcile bool
+	// State, when set, is used to skip lookups of objects resolved by earlier
+	// runs and is updated with everything resolved or created by this one.
+	State *state.State
+	// Prune selects what happens to previously managed issues that are no
+	// longer in the plan. The zero value disables pruning.
+	Prune PrunePolicy
+	// Concurrency is the number of epics and issues applied at once. Values
+	// below 2 apply them one at a time.
+	Concurrency int
+	// Journal, when set, records each mutation as it succeeds. Steps it already
+	// holds from an interrupted apply are skipped, reusing the recorded IDs.
+	Journal *state.Journal
 }
 
 // Report summarizes the results of an ApplyPlan execution.
 type Report struct {
-	MilestonesCreated int      `json:"milestones_created"`
-	EpicsCreated      int      `json:"epics_created"`
-	EpicsSkipped      int      `json:"epics_skipped"`
-	IssuesCreated     int      `json:"issues_created"`
-	IssuesSkipped     int      `json:"issues_skipped"`
-	EpicURLs          []string `json:"epic_urls,omitempty"`
+	LabelsCreated     int               `json:"labels_created,omitempty"`
+	LabelsUpdated     int               `json:"labels_updated,omitempty"`
+	MilestonesCreated int               `json:"milestones_created"`
+	MilestonesUpdated int               `json:"milestones_updated,omitempty"`
+	EpicsCreated      int               `json:"epics_created"`
+	EpicsUpdated      int               `json:"epics_updated"`
+	EpicsSkipped      int               `json:"epics_skipped"`
+	IssuesCreated     int               `json:"issues_created"`
+	IssuesUpdated     int               `json:"issues_updated"`
+	IssuesSkipped     int               `json:"issues_skipped"`
+	SubIssuesLinked   int               `json:"sub_issues_linked,omitempty"`
+	DependenciesAdded int               `json:"dependencies_added,omitempty"`
+	EpicURLs          []string          `json:"epic_urls,omitempty"`
+	Updates           []IssueUpdate     `json:"updates,omitempty"`
+	MilestoneUpdates  []MilestoneUpdate `json:"milestone_updates,omitempty"`
+	Pruned            []PrunedIssue     `json:"pruned,omitempty"`
+	Resumed           []string          `json:"resumed,omitempty"`
+}
+
+// IssueUpdate records a single field changed on an existing issue during reconcile.
+type IssueUpdate struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Field  string `json:"field"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// MilestoneUpdate records a single field changed on an existing milestone.
+type MilestoneUpdate struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Field  string `json:"field"`
+	From   string `json:"from"`
+	To     string `json:"to"`
 }
 
 func (r *Report) String() string {
-	return fmt.Sprintf("Summary: %d milestones synced, %d epics created (%d skipped), %d issues created (%d skipped)",
+	s := fmt.Sprintf("Summary: %d milestones created, %d epics created (%d skipped), %d issues created (%d skipped)",
 		r.MilestonesCreated, r.EpicsCreated, r.EpicsSkipped, r.IssuesCreated, r.IssuesSkipped)
+	if r.EpicsUpdated > 0 || r.IssuesUpdated > 0 {
+		s += fmt.Sprintf(", %d epics updated, %d issues updated", r.EpicsUpdated, r.IssuesUpdated)
+	}
+	if r.MilestonesUpdated > 0 {
+		s += fmt.Sprintf(", %d milestones updated", r.MilestonesUpdated)
+	}
+	if r.LabelsCreated > 0 || r.LabelsUpdated > 0 {
+		s += fmt.Sprintf(", %d labels created, %d labels updated", r.LabelsCreated, r.LabelsUpdated)
+	}
+	if r.SubIssuesLinked > 0 {
+		s += fmt.Sprintf(", %d sub-issues linked", r.SubIssuesLinked)
+	}
+	if r.DependenciesAdded > 0 {
+		s += fmt.Sprintf(", %d dependencies added", r.DependenciesAdded)
+	}
+	if len(r.Resumed) > 0 {
+		s += fmt.Sprintf(", %d steps resumed", len(r.Resumed))
+	}
+	if len(r.Pruned) > 0 {
+		s += fmt.Sprintf(", %d issues no longer in plan", len(r.Pruned))
+	}
+	return s
+}
+
+// issueSpec is the desired state of an epic or child issue.
+// Nil Assignees or Milestone mean the field is not managed by the plan.
+// Fields and Iteration only manage the custom fields they name.
+type issueSpec struct {
+	Kind      string
+	ID        string
+	Title     string
+	Body      string
+	Labels    []string
+	Assignees *[]string
+	Milestone *string
+	Status    string
+	Fields    types.Fields
+	Iteration string
+}
+
+// issueResult is the GitHub issue that an epic or child issue resolved to.
+type issueResult struct {
+	Number  int
+	NodeID  string
+	URL     string
+	Created bool
+}
+
+// applier holds the context resolved once per ApplyPlan run.
+type applier struct {
+	client             GitHubClient
+	opts               Options
+	linking            string
+	nativeDependencies bool
+	owner              string
+	repo               string
+	repoID             string
+	projectID          string
+	statusFieldID      githubv4.ID
+	statusOptions      map[string]string
+	fields             *projectFields
+	milestones         map[string]string
+	finder             *issueFinder
+	resolved           *resolvedIssues
+	state              *state.State
+	report             *Report
 }
 
 // ApplyPlan executes a plan against the GitHub API, creating milestones, epics, and child issues.
 func ApplyPlan(ctx context.Context, client GitHubClient, plan types.Plan, opts Options) (*Report, error) {
 	report := &Report{}
-	// Get owner and repo from repository string
-	repoParts := strings.Split(plan.Repository, "/")
-	if len(repoParts) != 2 {
-		return nil, fmt.Errorf("invalid repository format: %s", plan.Repository)
+	owner, repo, err := parseRepository(plan.Repository)
+	if err != nil {
+		return nil, err
 	}
-	owner, repo := repoParts[0], repoParts[1]
 
 	if opts.DryRun {
-		fmt.Println("[dry-run] Validating plan...")
 		fmt.Printf("[dry-run] Repository: %s/%s\n", owner, repo)
 		fmt.Printf("[dry-run] Project: %s\n", plan.Project)
+		cs, err := ComputeChangeSet(ctx, client, plan, opts)
+		if err != nil {
+			return nil, err
+		}
+		cs.Render(os.Stdout)
+		return report, nil
 	}
 
-	// Resolve Context
-	repoID, err := client.GetRepositoryID(ctx, owner, repo)
+	roots, err := buildTree(plan)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get repository id: %w", err)
+		return nil, err
 	}
-
-	projectID, err := client.GetProjectV2ID(ctx, owner, plan.Project)
+	order, err := creationOrder(roots)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get project id: %w", err)
+		return nil, err
+	}
+
+	// Without a state file, record into a throwaway state so lookups are still shared within the run
+	st := opts.State
+	if st == nil {
+		st = state.New(plan.Repository, plan.Project)
+	}
+	if err := st.Bind(plan.Repository, plan.Project); err != nil {
+		return nil, err
+	}
+
+	a := &applier{
+		client:     client,
+		opts:       opts,
+		linking:    plan.Linking,
+		owner:      owner,
+		repo:       repo,
+		milestones: make(map[string]string),
+		resolved:   newResolvedIssues(),
+		state:      st,
+		report:     report,
+	}
+
+	// Resolve Context
+	a.repoID = st.RepositoryID
+	if a.repoID == "" {
+		a.repoID, err = client.GetRepositoryID(ctx, owner, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get repository id: %w", err)
+		}
+		st.RepositoryID = a.repoID
+	}
+
+	a.projectID = st.ProjectID
+	if a.projectID == "" {
+		a.projectID, err = client.GetProjectV2ID(ctx, owner, plan.Project)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get project id: %w", err)
+		}
+		st.ProjectID = a.projectID
 	}
 
 	// Get project status field options
-	statusFieldID, statusOptions, err := client.GetProjectV2StatusFieldOptions(ctx, githubv4.ID(projectID))
+	a.statusFieldID, a.statusOptions, err = client.GetProjectV2StatusFieldOptions(ctx, githubv4.ID(a.projectID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get project status field options: %w", err)
 	}
+	a.fields, err = loadFields(ctx, client, a.projectID, plan.IterationField, order)
+	if err != nil {
+		return nil, err
+	}
+	a.nativeDependencies, err = nativeDependencies(ctx, client, order)
+	if err != nil {
+		return nil, err
+	}
+
+	a.finder, err = newIssueFinder(ctx, client, owner, repo, plan, st)
+	if err != nil {
+		return nil, err
+	}
+
+	// Labels are in place before any issue uses them
+	if err := a.syncLabels(ctx, plan.Labels); err != nil {
+		return nil, err
+	}
 
 	// Milestone Sync
-	milestones := make(map[string]string)
+	var existingMilestones []*gogithub.Milestone
 	for _, m := range plan.Milestones {
-		if opts.DryRun {
-			fmt.Printf("[dry-run] Would create/sync milestone: %s (due: %s)\n", m.Title, m.DueOn)
-			continue
-		}
-		milestone, err := client.GetOrCreateMilestone(ctx, owner, repo, m.Title, m.Description, m.DueOn)
+		key := state.Key(m.ID, m.Title)
+		synced, err := a.step("milestone:"+key, m.Title, "sync", func() (state.Step, error) {
+			if existingMilestones == nil {
+				existingMilestones, err = client.ListMilestones(ctx, owner, repo)
+				if err != nil {
+					return state.Step{}, fmt.Errorf("failed to list milestones: %w", err)
+				}
+			}
+			return a.syncMilestone(ctx, m, existingMilestones)
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to get or create milestone: %w", err)
+			return nil, err
 		}
-		milestoneID, err := client.GetMilestoneID(ctx, owner, repo, milestone.GetNumber())
+		a.milestones[m.Title] = synced.NodeID
+		st.SetMilestone(key, state.Milestone{Title: m.Title, Number: synced.Number, NodeID: synced.NodeID})
+	}
+
+	// Execution Loop (each issue after its children and blockers)
+	if err := a.applyNodes(ctx, order); err != nil {
+		return nil, err
+	}
+
+	if opts.Prune != PruneNone {
+		if err := a.prune(ctx, plan); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// applyNode applies an epic or issue, whose children and blockers have already
+// been applied, and then links it to them.
+func (a *applier) applyNode(ctx context.Context, n *planNode, results *resultMap) error {
+	var children []issueResult
+	var childRefs []string
+	for _, c := range n.children {
+		res := results.get(c)
+		children = append(children, res)
+		childRefs = append(childRefs, fmt.Sprintf("- [ ] #%d", res.Number))
+	}
+	var blockers []issueResult
+	var blockerRefs []string
+	for _, b := range n.blockedBy {
+		res := results.get(b)
+		blockers = append(blockers, res)
+		if !a.nativeDependencies {
+			blockerRefs = append(blockerRefs, fmt.Sprintf("#%d", res.Number))
+		}
+	}
+
+	spec := n.spec(childRefs, blockerRefs, a.linking)
+	res, err := a.applyIssue(ctx, spec)
+	if err != nil {
+		return err
+	}
+	results.set(n, res)
+
+	if usesSubIssues(a.linking) {
+		if err := a.linkSubIssues(ctx, spec, res, children); err != nil {
+			return err
+		}
+	}
+	if a.nativeDependencies {
+		return a.linkBlockers(ctx, spec, res, blockers)
+	}
+	return nil
+}
+
+// linkSubIssues makes each child a native sub-issue of parent. Children that
+// already belong to a different parent are only moved when reconciling.
+func (a *applier) linkSubIssues(ctx context.Context, spec issueSpec, parent issueResult, children []issueResult) error {
+	for _, child := range children {
+		_, err := a.step(state.Key(spec.ID, spec.Title), spec.Title, fmt.Sprintf("sub-issue #%d", child.Number), func() (state.Step, error) {
+			return state.Step{}, a.linkSubIssue(ctx, parent, child)
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to get milestone id: %w", err)
+			return err
 		}
-		milestones[m.Title] = milestoneID
-		report.MilestonesCreated++
 	}
+	return nil
+}
 
-	// Execution Loop (Per Epic)
-	for _, epic := range plan.Epics {
-		if opts.DryRun {
-			fmt.Printf("[dry-run] Would create epic: %s\n", epic.Title)
-			if epic.Milestone != "" {
-				fmt.Printf("[dry-run]   Milestone: %s\n", epic.Milestone)
-			}
-			if epic.Status != "" {
-				if _, ok := statusOptions[epic.Status]; !ok {
-					fmt.Printf("[dry-run]   WARNING: Status %q not found in project\n", epic.Status)
-				} else {
-					fmt.Printf("[dry-run]   Status: %s\n", epic.Status)
-				}
-			}
-			for _, label := range epic.Labels {
-				fmt.Printf("[dry-run]   Label: %s\n", label)
-			}
-			for _, child := range epic.Children {
-				fmt.Printf("[dry-run]   Would create child issue: %s\n", child.Title)
-				for _, label := range child.Labels {
-					fmt.Printf("[dry-run]     Label: %s\n", label)
-				}
+// linkSubIssue makes child a native sub-issue of parent.
+func (a *applier) linkSubIssue(ctx context.Context, parent, child issueResult) error {
+	replaceParent := false
+	if !child.Created {
+		current, err := a.client.GetIssue(ctx, a.owner, a.repo, child.Number)
+		if err != nil {
+			return fmt.Errorf("failed to read issue #%d: %w", child.Number, err)
+		}
+		if current.ParentID == parent.NodeID {
+			return nil
+		}
+		if current.ParentID != "" {
+			if !a.opts.Reconcile {
+				fmt.Printf("  Not linking #%d to #%d: it is already a sub-issue of #%d\n", child.Number, parent.Number, current.ParentNumber)
+				return nil
 			}
+			replaceParent = true
+		}
+	}
+
+	if err := a.client.AddSubIssue(ctx, githubv4.ID(parent.NodeID), githubv4.ID(child.NodeID), replaceParent); err != nil {
+		return fmt.Errorf("failed to link #%d as a sub-issue of #%d: %w", child.Number, parent.Number, err)
+	}
+	fmt.Printf("  Linked #%d as a sub-issue of #%d\n", child.Number, parent.Number)
+	a.report.SubIssuesLinked++
+	return nil
+}
+
+// linkBlockers records native dependencies on the issues blocking issue, skipping
+// those GitHub already has. Dependencies not in the plan are left alone.
+func (a *applier) linkBlockers(ctx context.Context, spec issueSpec, issue issueResult, blockers []issueResult) error {
+	if len(blockers) == 0 {
+		return nil
+	}
+	existing := make(map[int]bool)
+	if !issue.Created {
+		numbers, err := a.client.GetBlockedBy(ctx, a.owner, a.repo, issue.Number)
+		if err != nil {
+			return fmt.Errorf("failed to read dependencies of #%d: %w", issue.Number, err)
+		}
+		for _, number := range numbers {
+			existing[number] = true
+		}
+	}
+
+	for _, blocker := range blockers {
+		if existing[blocker.Number] {
 			continue
 		}
-		// Step A (Children)
-		var childIssues []string
-		for _, child := range epic.Children {
-			// Idempotency: check if child issue already exists
-			existingNum, existingNodeID, err := client.FindIssueByTitle(ctx, owner, repo, child.Title)
-			if err != nil {
-				return nil, fmt.Errorf("failed to check for existing issue %q: %w", child.Title, err)
+		_, err := a.step(state.Key(spec.ID, spec.Title), spec.Title, fmt.Sprintf("blocked by #%d", blocker.Number), func() (state.Step, error) {
+			if err := a.client.AddBlockedBy(ctx, githubv4.ID(issue.NodeID), githubv4.ID(blocker.NodeID)); err != nil {
+				return state.Step{}, fmt.Errorf("failed to mark #%d as blocked by #%d: %w", issue.Number, blocker.Number, err)
 			}
-			if existingNum > 0 {
-				fmt.Printf("  Skipping child issue (already exists): #%d %s\n", existingNum, child.Title)
-				childIssues = append(childIssues, fmt.Sprintf("- [ ] #%d", existingNum))
-				report.IssuesSkipped++
+			fmt.Printf("  Marked #%d as blocked by #%d\n", issue.Number, blocker.Number)
+			a.report.DependenciesAdded++
+			return state.Step{}, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-				// Still ensure it's on the project board
-				projectItem, err := client.AddIssueToProjectV2(ctx, githubv4.ID(projectID), githubv4.ID(existingNodeID))
-				if err != nil {
-					return nil, fmt.Errorf("failed to add existing child issue to project: %w", err)
-				}
-				if epic.Status != "" {
-					if statusID, ok := statusOptions[epic.Status]; ok {
-						_ = client.UpdateProjectV2ItemStatus(ctx, githubv4.ID(projectID), projectItem.AddProjectV2ItemById.Item.ID, statusFieldID, statusID)
-					}
-				}
-				continue
-			}
+// applyIssue creates the issue described by spec, or skips or reconciles it if it
+// already exists.
+func (a *applier) applyIssue(ctx context.Context, spec issueSpec) (issueResult, error) {
+	var labelIDs []githubv4.ID
+	resolved, err := a.step(state.Key(spec.ID, spec.Title), spec.Title, "resolve", func() (state.Step, error) {
+		step, found, err := a.findIssue(ctx, spec)
+		if err != nil || found {
+			return step, err
+		}
+		input, ids, err := a.createInput(ctx, spec)
+		if err != nil {
+			return state.Step{}, err
+		}
+		issue, err := a.client.CreateIssue(ctx, input)
+		if err != nil {
+			return state.Step{}, fmt.Errorf("failed to create %s: %w", noun(spec), err)
+		}
+		labelIDs = ids
+		return a.created(spec, issue), nil
+	})
+	if err != nil {
+		return issueResult{}, err
+	}
+	return a.finishIssue(ctx, spec, resolved, labelIDs)
+}
 
-			// Resolve label IDs
-			var labelIDs []githubv4.ID
-			for _, labelName := range child.Labels {
-				labelID, err := client.GetOrCreateLabel(ctx, owner, repo, labelName)
-				if err != nil {
-					return nil, fmt.Errorf("failed to get or create label %s: %w", labelName, err)
-				}
-				labelIDs = append(labelIDs, labelID)
-			}
+// finishIssue brings a resolved issue in line with spec: it reconciles an
+// existing issue, or puts the issue on the project board with its status and
+// fields, and records it in the state.
+func (a *applier) finishIssue(ctx context.Context, spec issueSpec, resolved state.Step, labelIDs []githubv4.ID) (issueResult, error) {
+	key := state.Key(spec.ID, spec.Title)
+	res := issueResult{Number: resolved.Number, NodeID: resolved.NodeID, URL: resolved.URL, Created: resolved.Created}
+	if !res.Created && a.opts.Reconcile {
+		_, err := a.step(key, spec.Title, "reconcile", func() (state.Step, error) {
+			return state.Step{}, a.reconcileIssue(ctx, res.Number, spec)
+		})
+		return res, err
+	}
 
-			childBody := githubv4.String(child.Body)
-			issue, err := client.CreateIssue(ctx, githubv4.CreateIssueInput{
-				RepositoryID: githubv4.ID(repoID),
-				Title:        githubv4.String(child.Title),
-				Body:         &childBody,
-				LabelIDs:     &labelIDs,
-			})
-			if err != nil {
-				return nil, fmt.Errorf("failed to create child issue: %w", err)
+	// Ensure it's on the project board, with its status and fields
+	added, err := a.step(key, spec.Title, "project", func() (state.Step, error) {
+		projectItem, err := a.client.AddIssueToProjectV2(ctx, githubv4.ID(a.projectID), githubv4.ID(res.NodeID))
+		if err != nil && res.Created {
+			return state.Step{}, fmt.Errorf("failed to add %s to project: %w", noun(spec), err)
+		}
+		if err != nil {
+			return state.Step{}, fmt.Errorf("failed to add existing %s to project: %w", noun(spec), err)
+		}
+		return state.Step{ItemID: fmt.Sprint(projectItem.AddProjectV2ItemById.Item.ID)}, nil
+	})
+	if err != nil {
+		return issueResult{}, err
+	}
+	itemID := githubv4.ID(added.ItemID)
+	if statusID, ok := a.statusOptions[spec.Status]; ok && spec.Status != "" {
+		_, err := a.step(key, spec.Title, "status", func() (state.Step, error) {
+			err := a.client.UpdateProjectV2ItemStatus(ctx, githubv4.ID(a.projectID), itemID, a.statusFieldID, statusID)
+			if err != nil && res.Created {
+				return state.Step{}, fmt.Errorf("failed to update status for %s: %w", noun(spec), err)
 			}
-			childIssues = append(childIssues, fmt.Sprintf("- [ ] #%d", issue.CreateIssue.Issue.Number))
-			report.IssuesCreated++
+			return state.Step{}, nil
+		})
+		if err != nil {
+			return issueResult{}, err
+		}
+	}
+	_, err = a.step(key, spec.Title, "fields", func() (state.Step, error) {
+		if _, err := a.updateFields(ctx, itemID, spec, nil); err != nil {
+			return state.Step{}, fmt.Errorf("failed to update fields for %s: %w", noun(spec), err)
+		}
+		return state.Step{}, nil
+	})
+	if err != nil {
+		return issueResult{}, err
+	}
 
-			// Add child issue to project
-			projectItem, err := client.AddIssueToProjectV2(ctx, githubv4.ID(projectID), issue.CreateIssue.Issue.ID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to add child issue to project: %w", err)
-			}
+	a.record(spec, res.Number, res.NodeID, itemID, labelIDs)
+	return res, nil
+}
 
-			// Update status
-			if epic.Status != "" {
-				if statusID, ok := statusOptions[epic.Status]; ok {
-					err := client.UpdateProjectV2ItemStatus(ctx, githubv4.ID(projectID), projectItem.AddProjectV2ItemById.Item.ID, statusFieldID, statusID)
-					if err != nil {
-						return nil, fmt.Errorf("failed to update status for child issue: %w", err)
-					}
-				}
-			}
+// noun names the kind of issue spec describes in messages.
+func noun(spec issueSpec) string {
+	if spec.Kind == KindEpic {
+		return "epic"
+	}
+	return "child issue"
+}
+
+// findIssue looks up the existing issue for spec, reporting it as skipped
+// unless it is going to be reconciled.
+func (a *applier) findIssue(ctx context.Context, spec issueSpec) (state.Step, bool, error) {
+	// Idempotency: check if the issue already exists
+	existingNum, existingNodeID, err := a.finder.find(ctx, spec)
+	if err != nil {
+		return state.Step{}, false, fmt.Errorf("failed to check for existing %s %q: %w", noun(spec), spec.Title, err)
+	}
+	if existingNum == 0 {
+		return state.Step{}, false, nil
+	}
+	if !a.opts.Reconcile {
+		if spec.Kind == KindEpic {
+			fmt.Printf("Skipping epic (already exists): #%d %s\n", existingNum, spec.Title)
+			a.report.EpicsSkipped++
+		} else {
+			fmt.Printf("  Skipping child issue (already exists): #%d %s\n", existingNum, spec.Title)
+			a.report.IssuesSkipped++
 		}
+	}
+	return state.Step{Number: existingNum, NodeID: existingNodeID}, true, nil
+}
 
-		// Idempotency: check if epic issue already exists
-		existingEpicNum, existingEpicNodeID, err := client.FindIssueByTitle(ctx, owner, repo, epic.Title)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check for existing epic %q: %w", epic.Title, err)
+// createInput builds the input creating the issue for spec, resolving its labels,
+// milestone and assignees. It also returns the label IDs.
+func (a *applier) createInput(ctx context.Context, spec issueSpec) (githubv4.CreateIssueInput, []githubv4.ID, error) {
+	labelIDs, err := a.resolveLabels(ctx, spec.Labels)
+	if err != nil {
+		return githubv4.CreateIssueInput{}, nil, err
+	}
+
+	body := githubv4.String(spec.Body)
+	input := githubv4.CreateIssueInput{
+		RepositoryID: githubv4.ID(a.repoID),
+		Title:        githubv4.String(spec.Title),
+		Body:         &body,
+		LabelIDs:     &labelIDs,
+	}
+	if spec.Milestone != nil {
+		if mID, ok := a.milestones[*spec.Milestone]; ok {
+			id := githubv4.ID(mID)
+			input.MilestoneID = &id
 		}
-		if existingEpicNum > 0 {
-			fmt.Printf("Skipping epic (already exists): #%d %s\n", existingEpicNum, epic.Title)
-			report.EpicsSkipped++
-			// Still ensure it's on the project board
-			projectItem, err := client.AddIssueToProjectV2(ctx, githubv4.ID(projectID), githubv4.ID(existingEpicNodeID))
-			if err != nil {
-				return nil, fmt.Errorf("failed to add existing epic to project: %w", err)
-			}
-			if epic.Status != "" {
-				if statusID, ok := statusOptions[epic.Status]; ok {
-					_ = client.UpdateProjectV2ItemStatus(ctx, githubv4.ID(projectID), projectItem.AddProjectV2ItemById.Item.ID, statusFieldID, statusID)
-				}
-			}
-			continue
+	}
+	if spec.Assignees != nil {
+		assigneeIDs, err := a.resolveAssignees(ctx, *spec.Assignees)
+		if err != nil {
+			return githubv4.CreateIssueInput{}, nil, err
 		}
+		input.AssigneeIDs = &assigneeIDs
+	}
+	return input, labelIDs, nil
+}
 
-		// Step B (Epic Body)
-		epicBody := epic.Body + "\n\n" + strings.Join(childIssues, "\n")
+// created reports the issue created for spec and returns it as a resolve step.
+func (a *applier) created(spec issueSpec, issue *ghclient.CreateIssueMutation) state.Step {
+	step := state.Step{
+		Number:  issue.CreateIssue.Issue.Number,
+		NodeID:  fmt.Sprint(issue.CreateIssue.Issue.ID),
+		URL:     issue.CreateIssue.Issue.URL.String(),
+		Created: true,
+	}
+	if spec.Kind == KindEpic {
+		a.report.EpicsCreated++
+		a.report.EpicURLs = append(a.report.EpicURLs, step.URL)
+		fmt.Printf("Created epic: %s (%s)\n", spec.Title, step.URL)
+	} else {
+		a.report.IssuesCreated++
+	}
+	return step
+}
 
-		// Step C (Create Epic)
-		var milestoneID *githubv4.ID
-		if epic.Milestone != "" {
-			if mID, ok := milestones[epic.Milestone]; ok {
-				id := githubv4.ID(mID)
-				milestoneID = &id
+// record stores the GitHub objects an epic or child issue resolved to in the state.
+func (a *applier) record(spec issueSpec, number int, nodeID string, itemID githubv4.ID, labelIDs []githubv4.ID) {
+	a.resolved.add(number, nodeID)
+	key := state.Key(spec.ID, spec.Title)
+	recorded, ok := a.state.Issue(key)
+	// An element that gained an ID moves from its title key to the ID key
+	if titleKey := state.Key("", spec.Title); spec.ID != "" {
+		if old, found := a.state.Issue(titleKey); found && old.Number == number {
+			if !ok {
+				recorded = old
 			}
+			a.state.DeleteIssue(titleKey)
 		}
+	}
+	recorded.Kind = spec.Kind
+	recorded.Title = spec.Title
+	recorded.Number = number
+	recorded.NodeID = nodeID
+	if itemID != nil {
+		recorded.ProjectItemID = fmt.Sprint(itemID)
+	}
+	if spec.Milestone != nil {
+		recorded.MilestoneID = a.milestones[*spec.Milestone]
+	}
+	if labelIDs != nil {
+		recorded.LabelIDs = nil
+		for _, id := range labelIDs {
+			recorded.LabelIDs = append(recorded.LabelIDs, fmt.Sprint(id))
+		}
+	}
+	a.state.SetIssue(key, recorded)
+}
+
+// reconcileIssue updates an existing issue so that every field managed by the
+// plan matches spec, recording each changed field in the report.
+func (a *applier) reconcileIssue(ctx context.Context, number int, spec issueSpec) error {
+	current, err := a.client.GetIssue(ctx, a.owner, a.repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to read issue #%d: %w", number, err)
+	}
 
-		// Resolve label IDs
-		var labelIDs []githubv4.ID
-		for _, labelName := range epic.Labels {
-			labelID, err := client.GetOrCreateLabel(ctx, owner, repo, labelName)
+	diffs := issueDiffs(current, spec)
+	input := githubv4.UpdateIssueInput{ID: githubv4.ID(current.ID)}
+	for _, d := range diffs {
+		switch d.Field {
+		case "title":
+			title := githubv4.String(spec.Title)
+			input.Title = &title
+		case "body":
+			body := githubv4.String(spec.Body)
+			input.Body = &body
+		case "labels":
+			labelIDs, err := a.resolveLabels(ctx, spec.Labels)
 			if err != nil {
-				return nil, fmt.Errorf("failed to get or create label %s: %w", labelName, err)
+				return err
 			}
-			labelIDs = append(labelIDs, labelID)
-		}
-
-		// Resolve assignee IDs
-		var assigneeIDs []githubv4.ID
-		for _, assigneeLogin := range epic.Assignees {
-			assigneeID, err := client.GetUserID(ctx, assigneeLogin)
+			input.LabelIDs = &labelIDs
+		case "assignees":
+			assigneeIDs, err := a.resolveAssignees(ctx, *spec.Assignees)
 			if err != nil {
-				return nil, fmt.Errorf("failed to get user id for %s: %w", assigneeLogin, err)
+				return err
 			}
-			assigneeIDs = append(assigneeIDs, assigneeID)
+			input.AssigneeIDs = &assigneeIDs
+		case "milestone":
+			// A nil ID clears the milestone
+			var id githubv4.ID
+			if *spec.Milestone != "" {
+				mID, ok := a.milestones[*spec.Milestone]
+				if !ok {
+					return fmt.Errorf("failed to update issue #%d: milestone %q is not in the plan", number, *spec.Milestone)
+				}
+				id = githubv4.ID(mID)
+			}
+			input.MilestoneID = &id
 		}
+	}
+	if len(diffs) > 0 {
+		if err := a.client.UpdateIssue(ctx, input); err != nil {
+			return fmt.Errorf("failed to update issue #%d: %w", number, err)
+		}
+	}
 
-		epicBodyStr := githubv4.String(epicBody)
-		epicIssue, err := client.CreateIssue(ctx, githubv4.CreateIssueInput{
-			RepositoryID: githubv4.ID(repoID),
-			Title:        githubv4.String(epic.Title),
-			Body:         &epicBodyStr,
-			MilestoneID:  milestoneID,
-			LabelIDs:     &labelIDs,
-			AssigneeIDs:  &assigneeIDs,
-		})
+	// Ensure it's on the project board with the planned status
+	projectItem, onProject := current.ProjectItem(a.projectID)
+	itemID := githubv4.ID(projectItem.ID)
+	if !onProject {
+		added, err := a.client.AddIssueToProjectV2(ctx, githubv4.ID(a.projectID), githubv4.ID(current.ID))
 		if err != nil {
-			return nil, fmt.Errorf("failed to create epic issue: %w", err)
+			return fmt.Errorf("failed to add issue #%d to project: %w", number, err)
+		}
+		itemID = added.AddProjectV2ItemById.Item.ID
+		diffs = append(diffs, FieldDiff{Field: "project", Desired: "added"})
+	}
+	if statusID, ok := a.statusOptions[spec.Status]; ok && spec.Status != projectItem.Status {
+		if err := a.client.UpdateProjectV2ItemStatus(ctx, githubv4.ID(a.projectID), itemID, a.statusFieldID, statusID); err != nil {
+			return fmt.Errorf("failed to update status for issue #%d: %w", number, err)
 		}
+		diffs = append(diffs, FieldDiff{Field: "status", Current: projectItem.Status, Desired: spec.Status})
+	}
+	fieldUpdates, err := a.updateFields(ctx, itemID, spec, projectItem.Fields)
+	if err != nil {
+		return fmt.Errorf("failed to update fields for issue #%d: %w", number, err)
+	}
+	diffs = append(diffs, fieldUpdates...)
+	a.record(spec, number, current.ID, itemID, nil)
 
-		// Step D (Project Linkage)
-		projectItem, err := client.AddIssueToProjectV2(ctx, githubv4.ID(projectID), epicIssue.CreateIssue.Issue.ID)
+	if len(diffs) == 0 {
+		if spec.Kind == KindEpic {
+			a.report.EpicsSkipped++
+		} else {
+			a.report.IssuesSkipped++
+		}
+		return nil
+	}
+
+	for _, d := range diffs {
+		fmt.Printf("Updated #%d %s: %s %q -> %q\n", number, spec.Title, d.Field, d.Current, d.Desired)
+		a.report.Updates = append(a.report.Updates, IssueUpdate{
+			Number: number,
+			Title:  spec.Title,
+			Field:  d.Field,
+			From:   d.Current,
+			To:     d.Desired,
+		})
+	}
+	if spec.Kind == KindEpic {
+		a.report.EpicsUpdated++
+	} else {
+		a.report.IssuesUpdated++
+	}
+	return nil
+}
+
+func (a *applier) resolveLabels(ctx context.Context, names []string) ([]githubv4.ID, error) {
+	var labelIDs []githubv4.ID
+	for _, labelName := range names {
+		if recorded, ok := a.state.Label(labelName); ok {
+			labelIDs = append(labelIDs, githubv4.ID(recorded))
+			continue
+		}
+		labelID, err := a.client.GetOrCreateLabel(ctx, a.owner, a.repo, labelName)
 		if err != nil {
-			return nil, fmt.Errorf("failed to add epic issue to project: %w", err)
+			return nil, fmt.Errorf("failed to get or create label %s: %w", labelName, err)
 		}
+		a.state.SetLabel(labelName, fmt.Sprint(labelID))
+		labelIDs = append(labelIDs, labelID)
+	}
+	return labelIDs, nil
+}
 
-		// Update status
-		if epic.Status != "" {
-			if statusID, ok := statusOptions[epic.Status]; ok {
-				err := client.UpdateProjectV2ItemStatus(ctx, githubv4.ID(projectID), projectItem.AddProjectV2ItemById.Item.ID, statusFieldID, statusID)
-				if err != nil {
-					return nil, fmt.Errorf("failed to update status for epic issue: %w", err)
-				}
-			}
+func (a *applier) resolveAssignees(ctx context.Context, logins []string) ([]githubv4.ID, error) {
+	var assigneeIDs []githubv4.ID
+	for _, assigneeLogin := range logins {
+		assigneeID, err := a.client.GetUserID(ctx, assigneeLogin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user id for %s: %w", assigneeLogin, err)
 		}
+		assigneeIDs = append(assigneeIDs, assigneeID)
+	}
+	return assigneeIDs, nil
+}
 
-		report.EpicsCreated++
-		report.EpicURLs = append(report.EpicURLs, epicIssue.CreateIssue.Issue.URL.String())
-		fmt.Printf("Created epic: %s (%s)\n", epic.Title, epicIssue.CreateIssue.Issue.URL.String())
+// childSpec returns the desired state of a child issue of parent, an epic or
+// another child issue. Milestone, assignees, status and iteration the child
+// omits are inherited from the parent, and its fields are merged over the
+// parent's. Milestone and assignees stay unmanaged when neither the child nor
+// its ancestors set them. The body has no tasklist or dependencies; see
+// planNode.spec.
+func childSpec(parent issueSpec, child types.Issue) issueSpec {
+	spec := issueSpec{
+		Kind:      KindIssue,
+		ID:        child.ID,
+		Title:     child.Title,
+		Body:      withMarker(child.Body, child.ID),
+		Labels:    child.Labels,
+		Status:    child.Status,
+		Fields:    inheritFields(parent.Fields, child.Fields),
+		Iteration: child.Iteration,
+	}
+	if spec.Status == "" {
+		spec.Status = parent.Status
+	}
+	if spec.Iteration == "" {
+		spec.Iteration = parent.Iteration
 	}
 
-	return report, nil
+	milestone := child.Milestone
+	if milestone == "" && parent.Milestone != nil {
+		milestone = *parent.Milestone
+	}
+	if milestone != "" {
+		spec.Milestone = &milestone
+	}
+
+	spec.Assignees = child.Assignees
+	if spec.Assignees == nil && parent.Assignees != nil && *parent.Assignees != nil {
+		spec.Assignees = parent.Assignees
+	}
+	return spec
+}
+
+// inheritFields returns the parent's fields overridden by the child's.
+func inheritFields(parent, child types.Fields) types.Fields {
+	if len(parent) == 0 {
+		return child
+	}
+	merged := make(types.Fields, len(parent)+len(child))
+	for name, v := range parent {
+		merged[name] = v
+	}
+	for name, v := range child {
+		merged[name] = v
+	}
+	return merged
+}
+
+// epicSpec returns the desired state of an epic. The body has no tasklist or
+// dependencies; see planNode.spec.
+func epicSpec(epic types.Epic) issueSpec {
+	assignees := epic.Assignees
+	milestone := epic.Milestone
+	return issueSpec{
+		Kind:      KindEpic,
+		ID:        epic.ID,
+		Title:     epic.Title,
+		Body:      withMarker(epic.Body, epic.ID),
+		Labels:    epic.Labels,
+		Assignees: &assignees,
+		Milestone: &milestone,
+		Status:    epic.Status,
+		Fields:    epic.Fields,
+		Iteration: epic.Iteration,
+	}
+}
+
+// issueDiffs compares the content fields of an existing issue with spec.
+// Project membership and status are compared separately.
+func issueDiffs(current *ghclient.IssueDetails, spec issueSpec) []FieldDiff {
+	var diffs []FieldDiff
+	diffs = append(diffs, diffString("title", current.Title, spec.Title)...)
+	diffs = append(diffs, diffString("body", current.Body, spec.Body)...)
+	diffs = append(diffs, diffSet("labels", current.Labels, spec.Labels)...)
+	if spec.Assignees != nil {
+		diffs = append(diffs, diffSet("assignees", current.Assignees, *spec.Assignees)...)
+	}
+	if spec.Milestone != nil {
+		diffs = append(diffs, diffString("milestone", current.Milestone, *spec.Milestone)...)
+	}
+	return diffs
+}
+
+// parseRepository splits an owner/repo string.
+func parseRepository(repository string) (string, string, error) {
+	repoParts := strings.Split(repository, "/")
+	if len(repoParts) != 2 {
+		return "", "", fmt.Errorf("invalid repository format: %s", repository)
+	}
+	return repoParts[0], repoParts[1], nil
+}
+
+// forEachIssue calls fn for every issue in the tree below issues, parents first.
+func forEachIssue(issues []types.Issue, fn func(types.Issue)) {
+	for _, issue := range issues {
+		fn(issue)
+		forEachIssue(issue.Children, fn)
+	}
+}
+
+// usesTasklist reports whether the linking strategy lists children in the parent body.
+func usesTasklist(linking string) bool {
+	return linking != types.LinkSubIssues
+}
+
+// usesSubIssues reports whether the linking strategy makes children native sub-issues.
+func usesSubIssues(linking string) bool {
+	return linking == types.LinkSubIssues || linking == types.LinkBoth
+}
+
+// epicBody appends the tasklist of child issue references to an epic or parent issue body.
+func epicBody(body string, childRefs []string) string {
+	return body + "\n\n" + strings.Join(childRefs, "\n")
 }
@@ -2,22 +2,48 @@ package engine
 
 import (
 	"context"
+	"fmt"
 	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	ghclient "github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/state"
 	"github.com/goblinsan/gh-project-helper/pkg/types"
 	gogithub "github.com/google/go-github/v66/github"
 	"github.com/shurcooL/githubv4"
 )
 
-// mockClient implements GitHubClient for testing.
+// mockClient implements GitHubClient for testing. Its mutations are safe for
+// concurrent use.
 type mockClient struct {
+	mu             sync.Mutex
 	issueCounter   int
 	createdIssues  []string
 	projectItems   []string
 	statusUpdates  []string
 	labelRequests  []string
+	labels         []*gogithub.Label
+	labelEdits     []string
+	updatedIssues  []githubv4.UpdateIssueInput
+	issues         map[int]*ghclient.IssueDetails
+	milestones     []*gogithub.Milestone
+	milestoneEdits []string
+	listedIssues   []*gogithub.Issue
+	closedIssues   []string
+	removedItems   []string
+	subIssues      []string
+	projectFields  []ghclient.ProjectV2Field
+	fieldUpdates   []string
+	dependencies   bool
+	blockedBy      map[int][]int
+	blockedByLinks []string
+	boardItems     []ghclient.ProjectV2Item
+	rateLimit      ghclient.RateLimit
 }
 
 func newMockClient() *mockClient {
@@ -40,14 +66,45 @@ func (m *mockClient) GetProjectV2StatusFieldOptions(_ context.Context, _ githubv
 	}, nil
 }
 
-func (m *mockClient) GetOrCreateMilestone(_ context.Context, _, _, title, _, _ string) (*gogithub.Milestone, error) {
+func (m *mockClient) GetProjectV2Fields(_ context.Context, _ githubv4.ID) ([]ghclient.ProjectV2Field, error) {
+	return m.projectFields, nil
+}
+
+func (m *mockClient) ListMilestones(_ context.Context, _, _ string) ([]*gogithub.Milestone, error) {
+	return m.milestones, nil
+}
+
+func (m *mockClient) CreateMilestone(_ context.Context, _, _ string, milestone *gogithub.Milestone) (*gogithub.Milestone, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	num := 1
+	m.milestoneEdits = append(m.milestoneEdits, "create "+milestone.GetTitle())
 	return &gogithub.Milestone{
 		Number: &num,
-		Title:  &title,
+		Title:  milestone.Title,
 	}, nil
 }
 
+func (m *mockClient) EditMilestone(_ context.Context, _, _ string, number int, milestone *gogithub.Milestone) (*gogithub.Milestone, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	edit := fmt.Sprintf("edit #%d", number)
+	if milestone.Title != nil {
+		edit += fmt.Sprintf(" title=%s", milestone.GetTitle())
+	}
+	if milestone.Description != nil {
+		edit += fmt.Sprintf(" description=%q", milestone.GetDescription())
+	}
+	if milestone.DueOn != nil {
+		edit += " due_on=" + milestone.GetDueOn().Format(time.RFC3339)
+	}
+	if milestone.State != nil {
+		edit += " state=" + milestone.GetState()
+	}
+	m.milestoneEdits = append(m.milestoneEdits, edit)
+	return milestone, nil
+}
+
 func (m *mockClient) GetMilestoneID(_ context.Context, _, _ string, _ int) (string, error) {
 	return "milestone-node-id", nil
 }
@@ -56,16 +113,49 @@ func (m *mockClient) FindIssueByTitle(_ context.Context, _, _, _ string) (int, s
 	return 0, "", nil // No existing issues by default
 }
 
+func (m *mockClient) ListIssues(_ context.Context, _, _ string) ([]*gogithub.Issue, error) {
+	return m.listedIssues, nil
+}
+
+func (m *mockClient) GetIssue(_ context.Context, _, _ string, number int) (*ghclient.IssueDetails, error) {
+	if details, ok := m.issues[number]; ok {
+		return details, nil
+	}
+	return &ghclient.IssueDetails{Number: number}, nil
+}
+
 func (m *mockClient) GetOrCreateLabel(_ context.Context, _, _, labelName string) (githubv4.ID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.labelRequests = append(m.labelRequests, labelName)
 	return githubv4.ID("label-" + labelName), nil
 }
 
+func (m *mockClient) ListLabels(_ context.Context, _, _ string) ([]*gogithub.Label, error) {
+	return m.labels, nil
+}
+
+func (m *mockClient) CreateLabel(_ context.Context, _, _ string, label *gogithub.Label) (*gogithub.Label, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.labelEdits = append(m.labelEdits, fmt.Sprintf("create %s %s %q", label.GetName(), label.GetColor(), label.GetDescription()))
+	return &gogithub.Label{Name: label.Name, NodeID: gogithub.String("label-" + label.GetName())}, nil
+}
+
+func (m *mockClient) EditLabel(_ context.Context, _, _, name string, label *gogithub.Label) (*gogithub.Label, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.labelEdits = append(m.labelEdits, fmt.Sprintf("edit %s -> %s %s %q", name, label.GetName(), label.GetColor(), label.GetDescription()))
+	return &gogithub.Label{Name: label.Name, NodeID: gogithub.String("label-" + name)}, nil
+}
+
 func (m *mockClient) GetUserID(_ context.Context, login string) (githubv4.ID, error) {
 	return githubv4.ID("user-" + login), nil
 }
 
 func (m *mockClient) CreateIssue(_ context.Context, input githubv4.CreateIssueInput) (*ghclient.CreateIssueMutation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.issueCounter++
 	title := string(input.Title)
 	m.createdIssues = append(m.createdIssues, title)
@@ -77,14 +167,141 @@ func (m *mockClient) CreateIssue(_ context.Context, input githubv4.CreateIssueIn
 	return result, nil
 }
 
+func (m *mockClient) UpdateIssue(_ context.Context, input githubv4.UpdateIssueInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updatedIssues = append(m.updatedIssues, input)
+	return nil
+}
+
+func (m *mockClient) CloseIssue(_ context.Context, issueID githubv4.ID, _ githubv4.IssueClosedStateReason) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closedIssues = append(m.closedIssues, issueID.(string))
+	return nil
+}
+
+func (m *mockClient) DeleteProjectV2Item(_ context.Context, _, itemID githubv4.ID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removedItems = append(m.removedItems, itemID.(string))
+	return nil
+}
+
 func (m *mockClient) AddIssueToProjectV2(_ context.Context, _, contentID githubv4.ID) (*ghclient.AddProjectV2ItemMutation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.projectItems = append(m.projectItems, contentID.(string))
 	result := &ghclient.AddProjectV2ItemMutation{}
 	result.AddProjectV2ItemById.Item.ID = githubv4.ID("project-item-" + contentID.(string))
 	return result, nil
 }
 
+func (m *mockClient) AddSubIssue(_ context.Context, issueID, subIssueID githubv4.ID, replaceParent bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	link := issueID.(string) + " > " + subIssueID.(string)
+	if replaceParent {
+		link += " (replaced)"
+	}
+	m.subIssues = append(m.subIssues, link)
+	return nil
+}
+
+func (m *mockClient) ListProjectV2Items(_ context.Context, _ githubv4.ID) ([]ghclient.ProjectV2Item, error) {
+	return m.boardItems, nil
+}
+
+func (m *mockClient) SupportsIssueDependencies(_ context.Context) (bool, error) {
+	return m.dependencies, nil
+}
+
+func (m *mockClient) GetBlockedBy(_ context.Context, _, _ string, number int) ([]int, error) {
+	return m.blockedBy[number], nil
+}
+
+func (m *mockClient) AddBlockedBy(_ context.Context, issueID, blockingIssueID githubv4.ID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blockedByLinks = append(m.blockedByLinks, issueID.(string)+" < "+blockingIssueID.(string))
+	return nil
+}
+
+func (m *mockClient) CreateIssues(ctx context.Context, inputs []githubv4.CreateIssueInput) ([]*ghclient.CreateIssueMutation, error) {
+	return createEach(ctx, m.CreateIssue, inputs)
+}
+
+func (m *mockClient) AddIssuesToProjectV2(ctx context.Context, projectID githubv4.ID, contentIDs []githubv4.ID) ([]githubv4.ID, error) {
+	return addEach(ctx, m.AddIssueToProjectV2, projectID, contentIDs)
+}
+
+// UpdateProjectV2ItemFieldValues records updates of the status field as status updates.
+func (m *mockClient) UpdateProjectV2ItemFieldValues(ctx context.Context, inputs []githubv4.UpdateProjectV2ItemFieldValueInput) error {
+	for _, in := range inputs {
+		if in.FieldID == githubv4.ID("status-field-id") {
+			m.UpdateProjectV2ItemStatus(ctx, in.ProjectID, in.ItemID, in.FieldID, string(*in.Value.SingleSelectOptionID))
+			continue
+		}
+		m.UpdateProjectV2ItemFieldValue(ctx, in.ProjectID, in.ItemID, in.FieldID, in.Value)
+	}
+	return nil
+}
+
+// createEach implements CreateIssues with one create per input, the way GitHub
+// runs the mutations of a batch.
+func createEach(ctx context.Context, create func(context.Context, githubv4.CreateIssueInput) (*ghclient.CreateIssueMutation, error), inputs []githubv4.CreateIssueInput) ([]*ghclient.CreateIssueMutation, error) {
+	results := make([]*ghclient.CreateIssueMutation, len(inputs))
+	failed := make(map[int]error)
+	for i, input := range inputs {
+		result, err := create(ctx, input)
+		if err != nil {
+			failed[i] = err
+			continue
+		}
+		results[i] = result
+	}
+	if len(failed) > 0 {
+		return results, &ghclient.BatchError{Total: len(inputs), Errors: failed}
+	}
+	return results, nil
+}
+
+// addEach implements AddIssuesToProjectV2 with one add per issue.
+func addEach(ctx context.Context, add func(context.Context, githubv4.ID, githubv4.ID) (*ghclient.AddProjectV2ItemMutation, error), projectID githubv4.ID, contentIDs []githubv4.ID) ([]githubv4.ID, error) {
+	itemIDs := make([]githubv4.ID, len(contentIDs))
+	failed := make(map[int]error)
+	for i, contentID := range contentIDs {
+		result, err := add(ctx, projectID, contentID)
+		if err != nil {
+			failed[i] = err
+			continue
+		}
+		itemIDs[i] = result.AddProjectV2ItemById.Item.ID
+	}
+	if len(failed) > 0 {
+		return itemIDs, &ghclient.BatchError{Total: len(contentIDs), Errors: failed}
+	}
+	return itemIDs, nil
+}
+
+func (m *mockClient) RateLimit() ghclient.RateLimit {
+	return m.rateLimit
+}
+
+func (m *mockClient) Host() string {
+	return ghclient.DefaultHost
+}
+
+func (m *mockClient) UpdateProjectV2ItemFieldValue(_ context.Context, _, itemID, fieldID githubv4.ID, _ githubv4.ProjectV2FieldValue) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fieldUpdates = append(m.fieldUpdates, itemID.(string)+" "+fieldID.(string))
+	return nil
+}
+
 func (m *mockClient) UpdateProjectV2ItemStatus(_ context.Context, _, _, _ githubv4.ID, optionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.statusUpdates = append(m.statusUpdates, optionID)
 	return nil
 }
@@ -152,6 +369,300 @@ func TestApplyPlan_BasicPlan(t *testing.T) {
 	}
 }
 
+func TestApplyPlan_SubIssues(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics: []types.Epic{
+			{
+				Title: "Epic 1",
+				Body:  "Epic body",
+				Children: []types.Issue{
+					{Title: "Child 1"},
+					{Title: "Child 2"},
+				},
+			},
+		},
+	}
+
+	t.Run("tasklist", func(t *testing.T) {
+		mock := newMockClient()
+		if _, err := ApplyPlan(context.Background(), mock, plan, Options{}); err != nil {
+			t.Fatalf("ApplyPlan failed: %v", err)
+		}
+		if len(mock.subIssues) != 0 {
+			t.Errorf("expected no sub-issue links, got %v", mock.subIssues)
+		}
+	})
+
+	t.Run("sub_issues", func(t *testing.T) {
+		mock := newMockClient()
+		subPlan := plan
+		subPlan.Linking = types.LinkSubIssues
+		report, err := ApplyPlan(context.Background(), mock, subPlan, Options{})
+		if err != nil {
+			t.Fatalf("ApplyPlan failed: %v", err)
+		}
+		want := []string{"issue-id-Epic 1 > issue-id-Child 1", "issue-id-Epic 1 > issue-id-Child 2"}
+		if len(mock.subIssues) != 2 || mock.subIssues[0] != want[0] || mock.subIssues[1] != want[1] {
+			t.Errorf("expected links %v, got %v", want, mock.subIssues)
+		}
+		if report.SubIssuesLinked != 2 {
+			t.Errorf("expected 2 sub-issues linked, got %d", report.SubIssuesLinked)
+		}
+		roots, _ := buildTree(plan)
+		if body := roots[0].spec([]string{"- [ ] #1"}, nil, types.LinkSubIssues).Body; body != "Epic body" {
+			t.Errorf("expected epic body without tasklist, got %q", body)
+		}
+	})
+
+	t.Run("existing children", func(t *testing.T) {
+		mock := newMockClient()
+		mock.issues = map[int]*ghclient.IssueDetails{
+			42: {ID: "existing-node-Child 1", Number: 42, ParentID: "existing-node-Epic 1", ParentNumber: 99},
+			43: {ID: "existing-node-Child 2", Number: 43, ParentID: "other-epic", ParentNumber: 7},
+		}
+		existingMock := &idempotentMockClient{mockClient: mock, existingIssues: map[string]int{
+			"Child 1": 42,
+			"Child 2": 43,
+			"Epic 1":  99,
+		}}
+		bothPlan := plan
+		bothPlan.Linking = types.LinkBoth
+
+		if _, err := ApplyPlan(context.Background(), existingMock, bothPlan, Options{}); err != nil {
+			t.Fatalf("ApplyPlan failed: %v", err)
+		}
+		if len(mock.subIssues) != 0 {
+			t.Errorf("expected children with a parent to be left alone without reconcile, got %v", mock.subIssues)
+		}
+
+		mock.issues[99] = &ghclient.IssueDetails{ID: "existing-node-Epic 1", Number: 99, Title: "Epic 1"}
+		if _, err := ApplyPlan(context.Background(), existingMock, bothPlan, Options{Reconcile: true}); err != nil {
+			t.Fatalf("ApplyPlan with reconcile failed: %v", err)
+		}
+		want := "existing-node-Epic 1 > existing-node-Child 2 (replaced)"
+		if len(mock.subIssues) != 1 || mock.subIssues[0] != want {
+			t.Errorf("expected only Child 2 to be moved, got %v", mock.subIssues)
+		}
+	})
+}
+
+func TestApplyPlan_NestedChildren(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Linking:    types.LinkBoth,
+		Epics: []types.Epic{
+			{
+				Title:  "Initiative",
+				Status: "Todo",
+				Children: []types.Issue{
+					{Title: "Epic A", Body: "Epic A body", Children: []types.Issue{
+						{Title: "Story A1", Children: []types.Issue{
+							{Title: "Task A1a"},
+						}},
+					}},
+					{Title: "Epic B"},
+				},
+			},
+		},
+	}
+
+	mock := newMockClient()
+	var bodies []string
+	client := &bodyRecordingClient{mockClient: mock, bodies: &bodies}
+	if _, err := ApplyPlan(context.Background(), client, plan, Options{}); err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+
+	wantOrder := []string{"Task A1a", "Story A1", "Epic A", "Epic B", "Initiative"}
+	if strings.Join(mock.createdIssues, "|") != strings.Join(wantOrder, "|") {
+		t.Errorf("expected leaves first %v, got %v", wantOrder, mock.createdIssues)
+	}
+	// Issue numbers follow creation order: Task A1a is #1, Story A1 #2, Epic A #3, Epic B #4
+	wantBodies := []string{"", "\n\n- [ ] #1", "Epic A body\n\n- [ ] #2", "", "\n\n- [ ] #3\n- [ ] #4"}
+	for i, want := range wantBodies {
+		if bodies[i] != want {
+			t.Errorf("%s: expected body %q, got %q", wantOrder[i], want, bodies[i])
+		}
+	}
+	wantLinks := []string{
+		"issue-id-Story A1 > issue-id-Task A1a",
+		"issue-id-Epic A > issue-id-Story A1",
+		"issue-id-Initiative > issue-id-Epic A",
+		"issue-id-Initiative > issue-id-Epic B",
+	}
+	if strings.Join(mock.subIssues, "|") != strings.Join(wantLinks, "|") {
+		t.Errorf("expected links %v, got %v", wantLinks, mock.subIssues)
+	}
+	if len(mock.statusUpdates) != 5 {
+		t.Errorf("expected the status to be inherited by every level, got %d updates", len(mock.statusUpdates))
+	}
+
+	cs, err := ComputeChangeSet(context.Background(), newMockClient(), plan, Options{})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+	var order []string
+	for _, c := range cs.Changes {
+		order = append(order, c.Title+"<"+c.Parent)
+	}
+	wantChanges := []string{"Initiative<", "Epic A<Initiative", "Story A1<Epic A", "Task A1a<Story A1", "Epic B<Initiative"}
+	if strings.Join(order, "|") != strings.Join(wantChanges, "|") {
+		t.Errorf("expected changes %v, got %v", wantChanges, order)
+	}
+}
+
+func TestApplyPlan_Dependencies(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics: []types.Epic{
+			{Title: "UI", DependsOn: []string{"api"}},
+			{ID: "api", Title: "API", Children: []types.Issue{
+				{Title: "Endpoints", DependsOn: []string{"Schema"}},
+				{Title: "Schema"},
+			}},
+		},
+	}
+	wantOrder := []string{"Schema", "Endpoints", "API", "UI"}
+
+	t.Run("body fallback", func(t *testing.T) {
+		mock := newMockClient()
+		var bodies []string
+		client := &bodyRecordingClient{mockClient: mock, bodies: &bodies}
+		if _, err := ApplyPlan(context.Background(), client, plan, Options{}); err != nil {
+			t.Fatalf("ApplyPlan failed: %v", err)
+		}
+		if strings.Join(mock.createdIssues, "|") != strings.Join(wantOrder, "|") {
+			t.Errorf("expected blockers first %v, got %v", wantOrder, mock.createdIssues)
+		}
+		// UI is an epic, so it has an (empty) tasklist before the blockers
+		wantBodies := []string{"", "\n\nBlocked by #1", withMarker("\n\n- [ ] #2\n- [ ] #1", "api"), "\n\n\n\nBlocked by #3"}
+		for i, want := range wantBodies {
+			if bodies[i] != want {
+				t.Errorf("%s: expected body %q, got %q", wantOrder[i], want, bodies[i])
+			}
+		}
+		if len(mock.blockedByLinks) != 0 {
+			t.Errorf("expected no native dependencies, got %v", mock.blockedByLinks)
+		}
+	})
+
+	t.Run("native", func(t *testing.T) {
+		mock := newMockClient()
+		mock.dependencies = true
+		var bodies []string
+		client := &bodyRecordingClient{mockClient: mock, bodies: &bodies}
+		report, err := ApplyPlan(context.Background(), client, plan, Options{})
+		if err != nil {
+			t.Fatalf("ApplyPlan failed: %v", err)
+		}
+		wantLinks := []string{"issue-id-Endpoints < issue-id-Schema", "issue-id-UI < issue-id-API"}
+		if strings.Join(mock.blockedByLinks, "|") != strings.Join(wantLinks, "|") {
+			t.Errorf("expected dependencies %v, got %v", wantLinks, mock.blockedByLinks)
+		}
+		if report.DependenciesAdded != 2 {
+			t.Errorf("expected 2 dependencies added, got %d", report.DependenciesAdded)
+		}
+		for _, body := range bodies {
+			if strings.Contains(body, "Blocked by") {
+				t.Errorf("expected no Blocked by line with native dependencies, got %q", body)
+			}
+		}
+	})
+
+	t.Run("existing dependencies", func(t *testing.T) {
+		mock := newMockClient()
+		mock.dependencies = true
+		mock.blockedBy = map[int][]int{20: {10}}
+		client := &idempotentMockClient{mockClient: mock, existingIssues: map[string]int{"Schema": 10, "Endpoints": 20}}
+		if _, err := ApplyPlan(context.Background(), client, plan, Options{}); err != nil {
+			t.Fatalf("ApplyPlan failed: %v", err)
+		}
+		if len(mock.blockedByLinks) != 1 || mock.blockedByLinks[0] != "issue-id-UI < issue-id-API" {
+			t.Errorf("expected only the missing dependency to be added, got %v", mock.blockedByLinks)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		cyclic := plan
+		cyclic.Epics = append([]types.Epic(nil), plan.Epics...)
+		cyclic.Epics[1].DependsOn = []string{"UI"}
+		mock := newMockClient()
+		_, err := ApplyPlan(context.Background(), mock, cyclic, Options{})
+		if err == nil || err.Error() != "dependency cycle: UI -> API -> UI" {
+			t.Errorf("expected a dependency cycle error, got %v", err)
+		}
+		if len(mock.createdIssues) != 0 {
+			t.Errorf("expected nothing to be created, got %v", mock.createdIssues)
+		}
+	})
+}
+
+// bodyRecordingClient records the body of every created issue.
+type bodyRecordingClient struct {
+	*mockClient
+	bodies *[]string
+}
+
+func (m *bodyRecordingClient) CreateIssue(ctx context.Context, input githubv4.CreateIssueInput) (*ghclient.CreateIssueMutation, error) {
+	*m.bodies = append(*m.bodies, string(*input.Body))
+	return m.mockClient.CreateIssue(ctx, input)
+}
+
+func (m *bodyRecordingClient) CreateIssues(ctx context.Context, inputs []githubv4.CreateIssueInput) ([]*ghclient.CreateIssueMutation, error) {
+	return createEach(ctx, m.CreateIssue, inputs)
+}
+
+func TestChildSpec_Inheritance(t *testing.T) {
+	epic := types.Epic{
+		Title:     "Epic 1",
+		Milestone: "Phase 1",
+		Status:    "Todo",
+		Assignees: []string{"lead"},
+		Iteration: "current",
+		Fields:    types.Fields{"Priority": "P1", "Team": "Core"},
+	}
+
+	inherited := childSpec(epicSpec(epic), types.Issue{Title: "Child 1"})
+	if inherited.Status != "Todo" || inherited.Iteration != "current" {
+		t.Errorf("expected status and iteration from the epic, got %q and %q", inherited.Status, inherited.Iteration)
+	}
+	if inherited.Milestone == nil || *inherited.Milestone != "Phase 1" {
+		t.Errorf("expected milestone from the epic, got %v", inherited.Milestone)
+	}
+	if inherited.Assignees == nil || len(*inherited.Assignees) != 1 || (*inherited.Assignees)[0] != "lead" {
+		t.Errorf("expected assignees from the epic, got %v", inherited.Assignees)
+	}
+
+	own := childSpec(epicSpec(epic), types.Issue{
+		Title:     "Child 2",
+		Milestone: "Phase 2",
+		Status:    "In Progress",
+		Assignees: &[]string{},
+		Fields:    types.Fields{"Priority": "P0"},
+	})
+	if own.Status != "In Progress" || *own.Milestone != "Phase 2" {
+		t.Errorf("expected the child's own status and milestone, got %q and %q", own.Status, *own.Milestone)
+	}
+	if own.Assignees == nil || len(*own.Assignees) != 0 {
+		t.Errorf("expected an explicit empty assignee list to unassign, got %v", own.Assignees)
+	}
+	if own.Fields["Priority"] != "P0" || own.Fields["Team"] != "Core" {
+		t.Errorf("expected fields merged over the epic's, got %v", own.Fields)
+	}
+	if epic.Fields["Priority"] != "P1" {
+		t.Errorf("merging fields must not modify the epic, got %v", epic.Fields)
+	}
+
+	unmanaged := childSpec(epicSpec(types.Epic{Title: "Epic 2"}), types.Issue{Title: "Child 3"})
+	if unmanaged.Milestone != nil || unmanaged.Assignees != nil {
+		t.Errorf("expected milestone and assignees to stay unmanaged, got %v and %v", unmanaged.Milestone, unmanaged.Assignees)
+	}
+}
+
 func TestApplyPlan_InvalidRepository(t *testing.T) {
 	mock := newMockClient()
 	plan := types.Plan{
@@ -247,6 +758,337 @@ func TestApplyPlan_Idempotency(t *testing.T) {
 	}
 }
 
+func TestApplyPlan_Reconcile(t *testing.T) {
+	mock := newMockClient()
+	mock.issues = map[int]*ghclient.IssueDetails{
+		42: {
+			ID:           "existing-node-Child 1",
+			Number:       42,
+			Title:        "Child 1",
+			Body:         "Child body",
+			Assignees:    []string{"dev1"}, // inherited from the epic
+			ProjectItems: []ghclient.ProjectItemRef{{ID: "item-42", ProjectID: "project-node-id", Status: "Todo"}},
+		},
+		99: {
+			ID:           "existing-node-Epic 1",
+			Number:       99,
+			Title:        "Epic 1",
+			Body:         "Old epic body\n\n- [ ] #42",
+			Labels:       []string{"frontend"},
+			Assignees:    []string{"dev1"},
+			ProjectItems: []ghclient.ProjectItemRef{{ID: "item-99", ProjectID: "project-node-id", Status: "Done"}},
+		},
+	}
+	existingMock := &idempotentMockClient{mockClient: mock, existingIssues: map[string]int{
+		"Child 1": 42,
+		"Epic 1":  99,
+	}}
+
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics: []types.Epic{
+			{
+				Title:     "Epic 1",
+				Body:      "Epic body",
+				Status:    "Todo",
+				Labels:    []string{"backend"},
+				Assignees: []string{"dev1"},
+				Children: []types.Issue{
+					{Title: "Child 1", Body: "Child body"},
+				},
+			},
+		},
+	}
+
+	report, err := ApplyPlan(context.Background(), existingMock, plan, Options{Reconcile: true})
+	if err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+
+	if report.IssuesSkipped != 1 {
+		t.Errorf("expected unchanged child to be skipped, got %d skipped", report.IssuesSkipped)
+	}
+	if report.EpicsUpdated != 1 {
+		t.Errorf("expected 1 updated epic, got %d", report.EpicsUpdated)
+	}
+	if len(mock.updatedIssues) != 1 {
+		t.Fatalf("expected 1 UpdateIssue call, got %d", len(mock.updatedIssues))
+	}
+	input := mock.updatedIssues[0]
+	if input.Body == nil || string(*input.Body) != "Epic body\n\n- [ ] #42" {
+		t.Errorf("expected epic body to be updated, got %v", input.Body)
+	}
+	if input.LabelIDs == nil || len(*input.LabelIDs) != 1 {
+		t.Errorf("expected labels to be updated, got %v", input.LabelIDs)
+	}
+	if input.AssigneeIDs != nil {
+		t.Errorf("expected assignees to be left alone, got %v", input.AssigneeIDs)
+	}
+	if len(mock.projectItems) != 0 {
+		t.Errorf("issues already on the board should not be re-added, got %v", mock.projectItems)
+	}
+
+	fields := map[string]bool{}
+	for _, u := range report.Updates {
+		if u.Number != 99 {
+			t.Errorf("unexpected update for #%d: %+v", u.Number, u)
+		}
+		fields[u.Field] = true
+	}
+	for _, f := range []string{"body", "labels", "status"} {
+		if !fields[f] {
+			t.Errorf("expected %s change in report, got %+v", f, report.Updates)
+		}
+	}
+}
+
+func TestApplyPlan_ReconcileUnknownMilestone(t *testing.T) {
+	mock := newMockClient()
+	mock.issues = map[int]*ghclient.IssueDetails{
+		99: {
+			ID:           "existing-node-Epic 1",
+			Number:       99,
+			Title:        "Epic 1",
+			Body:         "Epic body",
+			ProjectItems: []ghclient.ProjectItemRef{{ID: "item-99", ProjectID: "project-node-id"}},
+		},
+	}
+	existingMock := &idempotentMockClient{mockClient: mock, existingIssues: map[string]int{"Epic 1": 99}}
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics:      []types.Epic{{Title: "Epic 1", Body: "Epic body", Milestone: "Phase 9"}},
+	}
+
+	_, err := ApplyPlan(context.Background(), existingMock, plan, Options{Reconcile: true})
+	if err == nil || !strings.Contains(err.Error(), `milestone "Phase 9" is not in the plan`) {
+		t.Fatalf("expected the unknown milestone to fail, got %v", err)
+	}
+	if len(mock.updatedIssues) != 0 {
+		t.Errorf("expected no update, got %+v", mock.updatedIssues)
+	}
+}
+
+func TestApplyPlan_FindsIssueByMarker(t *testing.T) {
+	mock := newMockClient()
+	mock.listedIssues = []*gogithub.Issue{
+		{Number: gogithub.Int(99), NodeID: gogithub.String("epic-node"), Title: gogithub.String("Old epic title"), Body: gogithub.String("Epic body\n\n<!-- gh-project-helper:id=epic-1 -->")},
+	}
+	mock.issues = map[int]*ghclient.IssueDetails{
+		99: {ID: "epic-node", Number: 99, Title: "Old epic title", Body: "Epic body\n\n\n\n<!-- gh-project-helper:id=epic-1 -->"},
+	}
+
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics: []types.Epic{
+			{ID: "epic-1", Title: "New epic title", Body: "Epic body"},
+		},
+	}
+
+	report, err := ApplyPlan(context.Background(), mock, plan, Options{Reconcile: true})
+	if err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+
+	if report.EpicsCreated != 0 {
+		t.Errorf("renamed epic should not be created again, got %d created", report.EpicsCreated)
+	}
+	if len(mock.updatedIssues) != 1 {
+		t.Fatalf("expected 1 UpdateIssue call, got %d", len(mock.updatedIssues))
+	}
+	if title := mock.updatedIssues[0].Title; title == nil || *title != "New epic title" {
+		t.Errorf("expected epic to be renamed, got %v", title)
+	}
+	if body := mock.updatedIssues[0].Body; body != nil {
+		t.Errorf("expected body to be unchanged, got %q", *body)
+	}
+}
+
+func TestMarker_RoundTrip(t *testing.T) {
+	body := withMarker("Some body", "epic-1")
+	if body != "Some body\n\n<!-- gh-project-helper:id=epic-1 -->" {
+		t.Errorf("unexpected body with marker: %q", body)
+	}
+	if id := parseMarker(body); id != "epic-1" {
+		t.Errorf("expected id epic-1, got %q", id)
+	}
+	if id := parseMarker("no marker here"); id != "" {
+		t.Errorf("expected no id, got %q", id)
+	}
+	if withMarker("Some body", "") != "Some body" {
+		t.Error("body without id should be unchanged")
+	}
+}
+
+func TestApplyPlan_State(t *testing.T) {
+	mock := newMockClient()
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Milestones: []types.Milestone{
+			{Title: "Phase 1"},
+		},
+		Epics: []types.Epic{
+			{
+				ID:        "epic-1",
+				Title:     "Epic 1",
+				Milestone: "Phase 1",
+				Labels:    []string{"backend"},
+				Children: []types.Issue{
+					{Title: "Child 1", Labels: []string{"backend"}},
+				},
+			},
+		},
+	}
+	st := state.New("", "")
+
+	if _, err := ApplyPlan(context.Background(), mock, plan, Options{State: st}); err != nil {
+		t.Fatalf("first ApplyPlan failed: %v", err)
+	}
+	if st.RepositoryID != "repo-node-id" || st.ProjectID != "project-node-id" {
+		t.Errorf("expected repository and project ids in state, got %q and %q", st.RepositoryID, st.ProjectID)
+	}
+	epic, ok := st.Issue("id:epic-1")
+	if !ok || epic.Number != 2 || epic.MilestoneID != "milestone-node-id" || len(epic.LabelIDs) != 1 {
+		t.Errorf("unexpected epic state: %+v", epic)
+	}
+	child, ok := st.Issue("title:Child 1")
+	if !ok || child.Number != 1 || child.ProjectItemID != "project-item-issue-id-Child 1" {
+		t.Errorf("unexpected child state: %+v", child)
+	}
+	// The label is shared by the epic and child, so it should only be looked up once
+	if len(mock.labelRequests) != 1 {
+		t.Errorf("expected 1 label request, got %v", mock.labelRequests)
+	}
+
+	// The mock never finds issues by title, so only the state prevents duplicates
+	report, err := ApplyPlan(context.Background(), mock, plan, Options{State: st})
+	if err != nil {
+		t.Fatalf("second ApplyPlan failed: %v", err)
+	}
+	if report.EpicsCreated != 0 || report.IssuesCreated != 0 {
+		t.Errorf("expected nothing created on second run, got %+v", report)
+	}
+	if report.EpicsSkipped != 1 || report.IssuesSkipped != 1 {
+		t.Errorf("expected 1 epic and 1 issue skipped, got %+v", report)
+	}
+	if len(mock.createdIssues) != 2 {
+		t.Errorf("expected 2 issues created overall, got %v", mock.createdIssues)
+	}
+}
+
+func TestApplyPlan_StateForOtherPlan(t *testing.T) {
+	mock := newMockClient()
+	plan := types.Plan{Project: "Test Project", Repository: "owner/repo"}
+
+	_, err := ApplyPlan(context.Background(), mock, plan, Options{State: state.New("owner/other", "Test Project")})
+	if err == nil {
+		t.Fatal("expected error for state that belongs to another repository")
+	}
+}
+
+func TestApplyPlan_Prune(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics: []types.Epic{
+			{ID: "epic-1", Title: "Epic 1"},
+		},
+	}
+
+	newPruneMock := func() (*mockClient, *state.State) {
+		mock := newMockClient()
+		mock.listedIssues = []*gogithub.Issue{
+			{Number: gogithub.Int(5), NodeID: gogithub.String("node-5"), Title: gogithub.String("Dropped by id"), State: gogithub.String("open"), Body: gogithub.String("<!-- gh-project-helper:id=old-1 -->")},
+			{Number: gogithub.Int(6), NodeID: gogithub.String("node-6"), Title: gogithub.String("Closed already"), State: gogithub.String("closed"), Body: gogithub.String("<!-- gh-project-helper:id=old-2 -->")},
+			{Number: gogithub.Int(7), NodeID: gogithub.String("node-7"), Title: gogithub.String("Unmanaged"), State: gogithub.String("open")},
+		}
+		mock.issues = map[int]*ghclient.IssueDetails{
+			5: {ID: "node-5", Number: 5, State: "OPEN", ProjectItems: []ghclient.ProjectItemRef{{ID: "item-5", ProjectID: "project-node-id"}}},
+			8: {ID: "node-8", Number: 8, State: "OPEN", ProjectItems: []ghclient.ProjectItemRef{{ID: "item-8", ProjectID: "project-node-id"}}},
+		}
+		st := state.New("owner/repo", "Test Project")
+		st.SetIssue("title:Dropped child", state.Issue{Kind: KindIssue, Title: "Dropped child", Number: 8, NodeID: "node-8"})
+		return mock, st
+	}
+
+	t.Run("report", func(t *testing.T) {
+		mock, st := newPruneMock()
+		report, err := ApplyPlan(context.Background(), mock, plan, Options{State: st, Prune: PruneReport})
+		if err != nil {
+			t.Fatalf("ApplyPlan failed: %v", err)
+		}
+		if len(report.Pruned) != 2 || report.Pruned[0].Number != 5 || report.Pruned[1].Number != 8 {
+			t.Errorf("expected #5 and #8 to be reported, got %+v", report.Pruned)
+		}
+		if len(mock.closedIssues) != 0 || len(mock.removedItems) != 0 {
+			t.Errorf("report policy should not modify issues")
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		mock, st := newPruneMock()
+		if _, err := ApplyPlan(context.Background(), mock, plan, Options{State: st, Prune: PruneClose}); err != nil {
+			t.Fatalf("ApplyPlan failed: %v", err)
+		}
+		if len(mock.closedIssues) != 2 || mock.closedIssues[0] != "node-5" || mock.closedIssues[1] != "node-8" {
+			t.Errorf("expected node-5 and node-8 to be closed, got %v", mock.closedIssues)
+		}
+		if _, ok := st.Issue("title:Dropped child"); ok {
+			t.Error("closed issue should be removed from state")
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		mock, st := newPruneMock()
+		if _, err := ApplyPlan(context.Background(), mock, plan, Options{State: st, Prune: PruneRemove}); err != nil {
+			t.Fatalf("ApplyPlan failed: %v", err)
+		}
+		if len(mock.removedItems) != 2 || mock.removedItems[0] != "item-5" || mock.removedItems[1] != "item-8" {
+			t.Errorf("expected item-5 and item-8 to be removed, got %v", mock.removedItems)
+		}
+		if len(mock.closedIssues) != 0 {
+			t.Errorf("remove policy should not close issues, got %v", mock.closedIssues)
+		}
+	})
+}
+
+func TestApplyPlan_PruneKeepsIssueThatGainedID(t *testing.T) {
+	mock := newMockClient()
+	existingMock := &idempotentMockClient{mockClient: mock, existingIssues: map[string]int{"Epic A": 1}}
+	st := state.New("owner/repo", "Test Project")
+	st.SetIssue("title:Epic A", state.Issue{Kind: KindEpic, Title: "Epic A", Number: 1, NodeID: "existing-node-Epic A"})
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics:      []types.Epic{{ID: "epic-a", Title: "Epic A"}},
+	}
+
+	cs, err := ComputeChangeSet(context.Background(), existingMock, plan, Options{State: st, Prune: PruneClose})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+	if n := cs.Count(ActionPrune); n != 0 {
+		t.Errorf("expected nothing to prune, got %s", cs.String())
+	}
+
+	report, err := ApplyPlan(context.Background(), existingMock, plan, Options{State: st, Prune: PruneClose})
+	if err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	if len(mock.closedIssues) != 0 || len(report.Pruned) != 0 {
+		t.Errorf("expected the issue the plan resolved to stay open, closed %v, pruned %+v", mock.closedIssues, report.Pruned)
+	}
+	if recorded, ok := st.Issue("id:epic-a"); !ok || recorded.Number != 1 {
+		t.Errorf("expected the issue to be recorded under its ID, got %+v", recorded)
+	}
+	if _, ok := st.Issue("title:Epic A"); ok {
+		t.Error("expected the old title key to be removed")
+	}
+}
+
 // idempotentMockClient wraps mockClient but returns existing issues for specified titles.
 type idempotentMockClient struct {
 	*mockClient
@@ -260,6 +1102,18 @@ func (m *idempotentMockClient) FindIssueByTitle(_ context.Context, _, _, title s
 	return 0, "", nil
 }
 
+func (m *idempotentMockClient) GetIssue(ctx context.Context, owner, repo string, number int) (*ghclient.IssueDetails, error) {
+	if details, ok := m.issues[number]; ok {
+		return details, nil
+	}
+	for title, num := range m.existingIssues {
+		if num == number {
+			return &ghclient.IssueDetails{ID: "existing-node-" + title, Number: num, Title: title}, nil
+		}
+	}
+	return m.mockClient.GetIssue(ctx, owner, repo, number)
+}
+
 func TestReport_String(t *testing.T) {
 	r := &Report{
 		MilestonesCreated: 2,
@@ -268,8 +1122,269 @@ func TestReport_String(t *testing.T) {
 		IssuesCreated:     10,
 		IssuesSkipped:     2,
 	}
-	expected := "Summary: 2 milestones synced, 3 epics created (1 skipped), 10 issues created (2 skipped)"
+	expected := "Summary: 2 milestones created, 3 epics created (1 skipped), 10 issues created (2 skipped)"
 	if r.String() != expected {
 		t.Errorf("expected %q, got %q", expected, r.String())
 	}
 }
+
+func TestApplyPlan_PacesOnLowRateLimit(t *testing.T) {
+	var waits []time.Duration
+	defer func(orig func(context.Context, time.Duration) error) { sleep = orig }(sleep)
+	sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	mock := newMockClient()
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics:      []types.Epic{{Title: "Epic 1", Children: []types.Issue{{Title: "Child 1"}}}},
+	}
+
+	if _, err := ApplyPlan(context.Background(), mock, plan, Options{}); err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	if len(waits) != 0 {
+		t.Errorf("expected no pauses without a known rate limit, got %v", waits)
+	}
+
+	mock = newMockClient()
+	mock.rateLimit = ghclient.RateLimit{Resource: "graphql", Limit: 5000, Remaining: 20, Reset: time.Now().Add(time.Minute)}
+	if _, err := ApplyPlan(context.Background(), mock, plan, Options{}); err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	if len(waits) != 2 || waits[0] < 59*time.Second || waits[0] > 62*time.Second {
+		t.Errorf("expected a pause of about a minute before each issue, got %v", waits)
+	}
+
+	// The search budget is 30 a minute, so 20 left is plenty
+	waits = nil
+	mock = newMockClient()
+	mock.rateLimit = ghclient.RateLimit{Resource: "search", Limit: 30, Remaining: 20, Reset: time.Now().Add(time.Minute)}
+	if _, err := ApplyPlan(context.Background(), mock, plan, Options{}); err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	if len(waits) != 0 {
+		t.Errorf("expected no pauses with most of the search budget left, got %v", waits)
+	}
+}
+
+// failingProjectClient fails to add one issue to the project.
+type failingProjectClient struct {
+	*mockClient
+	failFor githubv4.ID
+}
+
+func (c *failingProjectClient) AddIssueToProjectV2(ctx context.Context, projectID, contentID githubv4.ID) (*ghclient.AddProjectV2ItemMutation, error) {
+	if contentID == c.failFor {
+		return nil, fmt.Errorf("boom")
+	}
+	return c.mockClient.AddIssueToProjectV2(ctx, projectID, contentID)
+}
+
+func (c *failingProjectClient) AddIssuesToProjectV2(ctx context.Context, projectID githubv4.ID, contentIDs []githubv4.ID) ([]githubv4.ID, error) {
+	return addEach(ctx, c.AddIssueToProjectV2, projectID, contentIDs)
+}
+
+func TestApplyPlan_Resume(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics: []types.Epic{{
+			Title:    "Epic 1",
+			Status:   "Todo",
+			Children: []types.Issue{{Title: "Child 1"}, {Title: "Child 2"}},
+		}},
+	}
+	path := state.JournalPath(filepath.Join(t.TempDir(), state.DefaultPath))
+	journal, err := state.OpenJournal(path, false)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+
+	mock := newMockClient()
+	failing := &failingProjectClient{mockClient: mock, failFor: "issue-id-Epic 1"}
+	if _, err := ApplyPlan(context.Background(), failing, plan, Options{Journal: journal}); err == nil {
+		t.Fatal("expected the first apply to fail")
+	}
+	journal.Close()
+
+	journal, err = state.OpenJournal(path, true)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	report, err := ApplyPlan(context.Background(), mock, plan, Options{Journal: journal})
+	if err != nil {
+		t.Fatalf("resumed ApplyPlan failed: %v", err)
+	}
+
+	if strings.Join(mock.createdIssues, "|") != "Child 1|Child 2|Epic 1" {
+		t.Errorf("expected each issue to be created once, got %v", mock.createdIssues)
+	}
+	wantItems := "issue-id-Child 1|issue-id-Child 2|issue-id-Epic 1"
+	if strings.Join(mock.projectItems, "|") != wantItems {
+		t.Errorf("expected each issue to be added to the project once, got %v", mock.projectItems)
+	}
+	wantResumed := []string{
+		"Child 1: resolve", "Child 1: project", "Child 1: status", "Child 1: fields",
+		"Child 2: resolve", "Child 2: project", "Child 2: status", "Child 2: fields",
+		"Epic 1: resolve",
+	}
+	if strings.Join(report.Resumed, "|") != strings.Join(wantResumed, "|") {
+		t.Errorf("expected resumed steps %v, got %v", wantResumed, report.Resumed)
+	}
+	if report.EpicsCreated != 0 || report.IssuesCreated != 0 {
+		t.Errorf("expected nothing new to be created, got %s", report)
+	}
+}
+
+// slowClient delays issue creation and tracks how many creations overlap.
+type slowClient struct {
+	*mockClient
+	mu       sync.Mutex
+	inFlight int
+	maxSeen  int
+}
+
+func (c *slowClient) CreateIssue(ctx context.Context, input githubv4.CreateIssueInput) (*ghclient.CreateIssueMutation, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.maxSeen = max(c.maxSeen, c.inFlight)
+	c.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+	return c.mockClient.CreateIssue(ctx, input)
+}
+
+func (c *slowClient) CreateIssues(ctx context.Context, inputs []githubv4.CreateIssueInput) ([]*ghclient.CreateIssueMutation, error) {
+	return createEach(ctx, c.CreateIssue, inputs)
+}
+
+func TestApplyPlan_Concurrency(t *testing.T) {
+	plan := types.Plan{Project: "Test Project", Repository: "owner/repo"}
+	for i := 1; i <= 4; i++ {
+		epic := types.Epic{Title: fmt.Sprintf("Epic %d", i), Status: "Todo"}
+		for j := 1; j <= 3; j++ {
+			epic.Children = append(epic.Children, types.Issue{Title: fmt.Sprintf("Task %d.%d", i, j)})
+		}
+		plan.Epics = append(plan.Epics, epic)
+	}
+	plan.Epics[1].DependsOn = []string{"Epic 1"}
+
+	sequential, err := ApplyPlan(context.Background(), newMockClient(), plan, Options{})
+	if err != nil {
+		t.Fatalf("sequential ApplyPlan failed: %v", err)
+	}
+
+	for run := 0; run < 10; run++ {
+		mock := newMockClient()
+		client := &slowClient{mockClient: mock}
+		report, err := ApplyPlan(context.Background(), client, plan, Options{Concurrency: 4})
+		if err != nil {
+			t.Fatalf("concurrent ApplyPlan failed: %v", err)
+		}
+		if client.maxSeen < 2 || client.maxSeen > 4 {
+			t.Errorf("expected between 2 and 4 issues created at once, saw %d", client.maxSeen)
+		}
+		if report.String() != sequential.String() || strings.Join(report.EpicURLs, "|") != strings.Join(sequential.EpicURLs, "|") {
+			t.Errorf("expected the report of a sequential apply %+v, got %+v", sequential, report)
+		}
+
+		created := make(map[string]int)
+		for i, title := range mock.createdIssues {
+			created[title] = i
+		}
+		if len(created) != 16 {
+			t.Fatalf("expected 16 issues created once each, got %v", mock.createdIssues)
+		}
+		for _, epic := range plan.Epics {
+			for _, child := range epic.Children {
+				if created[child.Title] > created[epic.Title] {
+					t.Errorf("expected %s to be created before %s", child.Title, epic.Title)
+				}
+			}
+		}
+		if created["Epic 1"] > created["Epic 2"] {
+			t.Errorf("expected Epic 1 to be created before Epic 2, which depends on it")
+		}
+	}
+}
+
+// batchCountingClient counts the batch requests made.
+type batchCountingClient struct {
+	*mockClient
+	creates []int
+	adds    []int
+	updates []int
+}
+
+func (c *batchCountingClient) CreateIssues(ctx context.Context, inputs []githubv4.CreateIssueInput) ([]*ghclient.CreateIssueMutation, error) {
+	c.creates = append(c.creates, len(inputs))
+	return c.mockClient.CreateIssues(ctx, inputs)
+}
+
+func (c *batchCountingClient) AddIssuesToProjectV2(ctx context.Context, projectID githubv4.ID, contentIDs []githubv4.ID) ([]githubv4.ID, error) {
+	c.adds = append(c.adds, len(contentIDs))
+	return c.mockClient.AddIssuesToProjectV2(ctx, projectID, contentIDs)
+}
+
+func (c *batchCountingClient) UpdateProjectV2ItemFieldValues(ctx context.Context, inputs []githubv4.UpdateProjectV2ItemFieldValueInput) error {
+	c.updates = append(c.updates, len(inputs))
+	return c.mockClient.UpdateProjectV2ItemFieldValues(ctx, inputs)
+}
+
+func TestApplyPlan_BatchesSiblings(t *testing.T) {
+	mock := newMockClient()
+	mock.projectFields = []ghclient.ProjectV2Field{
+		{ID: "priority-field", Name: "Priority", DataType: ghclient.FieldTypeSingleSelect, Options: map[string]string{"P1": "p1"}},
+		{ID: "estimate-field", Name: "Estimate", DataType: ghclient.FieldTypeNumber},
+	}
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics: []types.Epic{{
+			Title:  "Epic 1",
+			Status: "Todo",
+			Fields: types.Fields{"Priority": "P1", "Estimate": 3},
+			Children: []types.Issue{
+				{Title: "Child 1"},
+				{Title: "Child 2", DependsOn: []string{"Child 1"}},
+				{Title: "Child 3", Fields: types.Fields{"Estimate": 5}},
+			},
+		}},
+	}
+	client := &batchCountingClient{mockClient: mock}
+
+	report, err := ApplyPlan(context.Background(), client, plan, Options{})
+	if err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	if report.IssuesCreated != 3 || report.EpicsCreated != 1 {
+		t.Errorf("unexpected report: %s", report)
+	}
+
+	// Child 1 and Child 3 go in one batch; Child 2 waits for Child 1
+	if strings.Join(mock.createdIssues, "|") != "Child 1|Child 3|Child 2|Epic 1" {
+		t.Errorf("unexpected creation order %v", mock.createdIssues)
+	}
+	if len(client.creates) != 1 || client.creates[0] != 2 || len(client.adds) != 1 || client.adds[0] != 2 {
+		t.Errorf("expected one create and one add batch of 2, got %v and %v", client.creates, client.adds)
+	}
+	// One batch with the status and both fields of each batched child, then one
+	// with the fields of each issue applied on its own
+	if fmt.Sprint(client.updates) != "[6 2 2]" {
+		t.Errorf("expected field update batches [6 2 2], got %v", client.updates)
+	}
+	if len(mock.statusUpdates) != 4 {
+		t.Errorf("expected a status for every issue, got %v", mock.statusUpdates)
+	}
+	if !slices.Contains(mock.fieldUpdates, "project-item-issue-id-Child 3 estimate-field") {
+		t.Errorf("expected Child 3's estimate to be set, got %v", mock.fieldUpdates)
+	}
+}
@@ -0,0 +1,215 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ghclient "github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/state"
+	"github.com/shurcooL/githubv4"
+)
+
+// errBatched tells applyBatch that an issue was not found and should be created
+// with the rest of its batch.
+var errBatched = errors.New("created in batch")
+
+// workUnits groups the nodes, given in creation order, into units applied
+// together. Children of the same parent that have no children or blockers of
+// their own form one unit, created with batched mutations; every other node is
+// a unit by itself. Units are ordered by their first node.
+func workUnits(order []*planNode) [][]*planNode {
+	siblings := make(map[*planNode][]*planNode)
+	for _, n := range order {
+		if batchable(n) {
+			siblings[n.parent] = append(siblings[n.parent], n)
+		}
+	}
+
+	var units [][]*planNode
+	for _, n := range order {
+		if !batchable(n) || len(siblings[n.parent]) < 2 {
+			units = append(units, []*planNode{n})
+			continue
+		}
+		if group := siblings[n.parent]; group[0] == n {
+			units = append(units, group)
+		}
+	}
+	return units
+}
+
+// batchable reports whether n can be created in a batch with its siblings.
+func batchable(n *planNode) bool {
+	return n.parent != nil && len(n.children) == 0 && len(n.blockedBy) == 0
+}
+
+// batchItem is an issue of a batch that does not exist yet.
+type batchItem struct {
+	node     *planNode
+	spec     issueSpec
+	input    githubv4.CreateIssueInput
+	labelIDs []githubv4.ID
+	resolved state.Step
+	itemID   githubv4.ID
+}
+
+// applyBatch applies sibling leaf issues. Those that already exist, or were
+// resolved by an interrupted apply, are applied one by one; the rest are created,
+// added to the project and given their status and fields with one batch of
+// mutations each.
+func (a *applier) applyBatch(ctx context.Context, nodes []*planNode, results *resultMap) error {
+	var batch []*batchItem
+	for _, n := range nodes {
+		spec := n.spec(nil, nil, a.linking)
+		resolved, err := a.step(state.Key(spec.ID, spec.Title), spec.Title, "resolve", func() (state.Step, error) {
+			step, found, err := a.findIssue(ctx, spec)
+			if err == nil && !found {
+				err = errBatched
+			}
+			return step, err
+		})
+		if errors.Is(err, errBatched) {
+			input, labelIDs, err := a.createInput(ctx, spec)
+			if err != nil {
+				return err
+			}
+			batch = append(batch, &batchItem{node: n, spec: spec, input: input, labelIDs: labelIDs})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		res, err := a.finishIssue(ctx, spec, resolved, nil)
+		if err != nil {
+			return err
+		}
+		results.set(n, res)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	// Every step that succeeded is journaled before a failure is returned
+	inputs := make([]githubv4.CreateIssueInput, len(batch))
+	for i, item := range batch {
+		inputs[i] = item.input
+	}
+	issues, err := a.client.CreateIssues(ctx, inputs)
+	for i, item := range batch {
+		if i >= len(issues) || issues[i] == nil {
+			continue
+		}
+		item.resolved = a.created(item.spec, issues[i])
+		if err := a.journal(item, "resolve", item.resolved); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return batchItemError("create", batch, err)
+	}
+
+	contentIDs := make([]githubv4.ID, len(batch))
+	for i, item := range batch {
+		contentIDs[i] = githubv4.ID(item.resolved.NodeID)
+	}
+	itemIDs, err := a.client.AddIssuesToProjectV2(ctx, githubv4.ID(a.projectID), contentIDs)
+	for i, item := range batch {
+		if i >= len(itemIDs) || itemIDs[i] == nil {
+			continue
+		}
+		item.itemID = itemIDs[i]
+		if err := a.journal(item, "project", state.Step{ItemID: fmt.Sprint(item.itemID)}); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return batchItemError("add to project", batch, err)
+	}
+
+	// Status and custom fields of every item go in one batch
+	var updates []fieldUpdate
+	var owners []int
+	for i, item := range batch {
+		if statusID, ok := a.statusOptions[item.spec.Status]; ok && item.spec.Status != "" {
+			option := githubv4.String(statusID)
+			updates = append(updates, fieldUpdate{
+				field: "Status",
+				input: githubv4.UpdateProjectV2ItemFieldValueInput{
+					ProjectID: githubv4.ID(a.projectID),
+					ItemID:    item.itemID,
+					FieldID:   a.statusFieldID,
+					Value:     githubv4.ProjectV2FieldValue{SingleSelectOptionID: &option},
+				},
+			})
+			owners = append(owners, i)
+		}
+		fields, err := a.fieldUpdates(item.itemID, item.spec, nil)
+		if err != nil {
+			return fmt.Errorf("failed to update fields for %s: %w", noun(item.spec), err)
+		}
+		updates = append(updates, fields...)
+		for range fields {
+			owners = append(owners, i)
+		}
+	}
+	failed := make(map[int]bool)
+	if len(updates) > 0 {
+		inputs := make([]githubv4.UpdateProjectV2ItemFieldValueInput, len(updates))
+		for i, u := range updates {
+			inputs[i] = u.input
+		}
+		err = a.client.UpdateProjectV2ItemFieldValues(ctx, inputs)
+		var batchErr *ghclient.BatchError
+		switch {
+		case errors.As(err, &batchErr):
+			for i := range batchErr.Errors {
+				failed[owners[i]] = true
+			}
+		case err != nil:
+			return fmt.Errorf("failed to update fields: %w", err)
+		}
+	}
+
+	for i, item := range batch {
+		if failed[i] {
+			continue
+		}
+		if _, ok := a.statusOptions[item.spec.Status]; ok && item.spec.Status != "" {
+			if err := a.journal(item, "status", state.Step{}); err != nil {
+				return err
+			}
+		}
+		if err := a.journal(item, "fields", state.Step{}); err != nil {
+			return err
+		}
+		a.record(item.spec, item.resolved.Number, item.resolved.NodeID, item.itemID, item.labelIDs)
+		results.set(item.node, issueResult{Number: item.resolved.Number, NodeID: item.resolved.NodeID, URL: item.resolved.URL, Created: true})
+	}
+	if err != nil {
+		var batchErr *ghclient.BatchError
+		errors.As(err, &batchErr)
+		i, first := batchErr.First()
+		return fmt.Errorf("failed to update %s for %s %q: %w", updates[i].field, noun(batch[owners[i]].spec), batch[owners[i]].spec.Title, first)
+	}
+	return nil
+}
+
+// journal records a step of a batch item that has already been carried out.
+func (a *applier) journal(item *batchItem, name string, done state.Step) error {
+	_, err := a.step(state.Key(item.spec.ID, item.spec.Title), item.spec.Title, name, func() (state.Step, error) {
+		return done, nil
+	})
+	return err
+}
+
+// batchItemError describes a failed batch of create or add-to-project mutations
+// by its first failed item.
+func batchItemError(action string, batch []*batchItem, err error) error {
+	var batchErr *ghclient.BatchError
+	if !errors.As(err, &batchErr) {
+		return fmt.Errorf("failed to %s %d issues: %w", action, len(batch), err)
+	}
+	i, first := batchErr.First()
+	return fmt.Errorf("failed to %s %s %q: %w", action, noun(batch[i].spec), batch[i].spec.Title, first)
+}
@@ -0,0 +1,208 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	ghclient "github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// CachingClient is a GitHubClient that remembers the results of lookups whose
+// answer does not change during a run: repository, project, project field,
+// milestone, label and user IDs, and whether issue dependencies are supported.
+// All other calls go straight to the wrapped client. The cache can be saved to
+// disk and loaded by later runs, except for project fields; see runOnly. It is
+// safe for concurrent use.
+type CachingClient struct {
+	GitHubClient
+
+	// host prefixes the keys, so that one cache file can serve several hosts.
+	host    string
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// cacheEntry is a cached result, stored as JSON so that callers never share it.
+type cacheEntry struct {
+	Value  json.RawMessage `json:"value"`
+	Stored time.Time       `json:"stored"`
+}
+
+// runOnly lists the kinds of lookups that are cached for one run but never saved:
+// project fields and their options change whenever the board is edited, and a
+// stale option ID would make the plan fail.
+var runOnly = []string{"status:", "fields:"}
+
+// saved reports whether the entry with the given key may be saved and loaded.
+func saved(key string) bool {
+	for _, kind := range runOnly {
+		if strings.Contains(key, "/"+kind) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewCachingClient wraps client, which talks to the given GitHub host, with an
+// empty cache.
+func NewCachingClient(client GitHubClient, host string) *CachingClient {
+	return &CachingClient{GitHubClient: client, host: host, entries: make(map[string]cacheEntry)}
+}
+
+// Load adds the entries of the cache file at path that are younger than ttl. A
+// missing file is not an error.
+func (c *CachingClient) Load(path string, ttl time.Duration) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache file: %w", err)
+	}
+	var stored map[string]cacheEntry
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to parse cache file %s: %w", path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range stored {
+		if saved(key) && time.Since(entry.Stored) < ttl {
+			c.entries[key] = entry
+		}
+	}
+	return nil
+}
+
+// Save writes the cache to path, replacing the previous file atomically.
+func (c *CachingClient) Save(path string) error {
+	c.mu.Lock()
+	entries := make(map[string]cacheEntry, len(c.entries))
+	for key, entry := range c.entries {
+		if saved(key) {
+			entries[key] = entry
+		}
+	}
+	c.mu.Unlock()
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// cached returns the cached result for key, or calls fetch and caches what it
+// returns. Errors are not cached.
+func cached[T any](c *CachingClient, key string, fetch func() (T, error)) (T, error) {
+	key = c.host + "/" + key
+	var value T
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && json.Unmarshal(entry.Value, &value) == nil {
+		return value, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value, nil
+	}
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{Value: data, Stored: time.Now().UTC()}
+	c.mu.Unlock()
+	return value, nil
+}
+
+func (c *CachingClient) GetRepositoryID(ctx context.Context, owner, name string) (string, error) {
+	return cached(c, "repository:"+owner+"/"+name, func() (string, error) {
+		return c.GitHubClient.GetRepositoryID(ctx, owner, name)
+	})
+}
+
+func (c *CachingClient) GetProjectV2ID(ctx context.Context, owner, title string) (string, error) {
+	return cached(c, "project:"+owner+"/"+title, func() (string, error) {
+		return c.GitHubClient.GetProjectV2ID(ctx, owner, title)
+	})
+}
+
+// statusField is the cached result of GetProjectV2StatusFieldOptions.
+type statusField struct {
+	ID      string
+	Options map[string]string
+}
+
+func (c *CachingClient) GetProjectV2StatusFieldOptions(ctx context.Context, projectID githubv4.ID) (githubv4.ID, map[string]string, error) {
+	field, err := cached(c, fmt.Sprintf("status:%v", projectID), func() (statusField, error) {
+		id, options, err := c.GitHubClient.GetProjectV2StatusFieldOptions(ctx, projectID)
+		return statusField{ID: fmt.Sprint(id), Options: options}, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return githubv4.ID(field.ID), field.Options, nil
+}
+
+func (c *CachingClient) GetProjectV2Fields(ctx context.Context, projectID githubv4.ID) ([]ghclient.ProjectV2Field, error) {
+	return cached(c, fmt.Sprintf("fields:%v", projectID), func() ([]ghclient.ProjectV2Field, error) {
+		return c.GitHubClient.GetProjectV2Fields(ctx, projectID)
+	})
+}
+
+func (c *CachingClient) GetMilestoneID(ctx context.Context, owner, name string, number int) (string, error) {
+	return cached(c, fmt.Sprintf("milestone:%s/%s#%d", owner, name, number), func() (string, error) {
+		return c.GitHubClient.GetMilestoneID(ctx, owner, name, number)
+	})
+}
+
+func (c *CachingClient) GetOrCreateLabel(ctx context.Context, owner, repo, labelName string) (githubv4.ID, error) {
+	id, err := cached(c, "label:"+owner+"/"+repo+":"+labelName, func() (string, error) {
+		id, err := c.GitHubClient.GetOrCreateLabel(ctx, owner, repo, labelName)
+		return fmt.Sprint(id), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return githubv4.ID(id), nil
+}
+
+func (c *CachingClient) GetUserID(ctx context.Context, login string) (githubv4.ID, error) {
+	id, err := cached(c, "user:"+login, func() (string, error) {
+		id, err := c.GitHubClient.GetUserID(ctx, login)
+		return fmt.Sprint(id), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return githubv4.ID(id), nil
+}
+
+func (c *CachingClient) SupportsIssueDependencies(ctx context.Context) (bool, error) {
+	return cached(c, "dependencies", func() (bool, error) {
+		return c.GitHubClient.SupportsIssueDependencies(ctx)
+	})
+}
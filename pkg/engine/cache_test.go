@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ghclient "github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	"github.com/shurcooL/githubv4"
+)
+
+// userCountingClient counts GetUserID calls and fails them while err is set.
+type userCountingClient struct {
+	*mockClient
+	calls int
+	err   error
+}
+
+func (c *userCountingClient) GetUserID(ctx context.Context, login string) (githubv4.ID, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.mockClient.GetUserID(ctx, login)
+}
+
+func TestCachingClient_LabelsLookedUpOnce(t *testing.T) {
+	mock := newMockClient()
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics: []types.Epic{
+			{Title: "Epic 1", Labels: []string{"backend"}, Children: []types.Issue{
+				{Title: "Child 1", Labels: []string{"backend"}},
+				{Title: "Child 2", Labels: []string{"backend", "api"}},
+			}},
+			{Title: "Epic 2", Labels: []string{"api"}},
+		},
+	}
+
+	if _, err := ApplyPlan(context.Background(), NewCachingClient(mock, "github.com"), plan, Options{}); err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	if fmt.Sprint(mock.labelRequests) != "[backend api]" {
+		t.Errorf("expected each label to be looked up once, got %v", mock.labelRequests)
+	}
+}
+
+func TestCachingClient_ErrorsNotCached(t *testing.T) {
+	inner := &userCountingClient{mockClient: newMockClient(), err: errors.New("boom")}
+	client := NewCachingClient(inner, "github.com")
+
+	if _, err := client.GetUserID(context.Background(), "dev1"); err == nil {
+		t.Fatal("expected the error to be returned")
+	}
+	inner.err = nil
+	id, err := client.GetUserID(context.Background(), "dev1")
+	if err != nil || id != "user-dev1" {
+		t.Fatalf("unexpected result %v, %v", id, err)
+	}
+	client.GetUserID(context.Background(), "dev1")
+	if inner.calls != 2 {
+		t.Errorf("expected 2 calls, got %d", inner.calls)
+	}
+}
+
+func TestCachingClient_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	first := &userCountingClient{mockClient: newMockClient()}
+	client := NewCachingClient(first, "github.com")
+	client.GetUserID(context.Background(), "dev1")
+	if err := client.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A later run within the TTL reuses the saved ID
+	second := &userCountingClient{mockClient: newMockClient()}
+	client = NewCachingClient(second, "github.com")
+	if err := client.Load(path, time.Hour); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	id, err := client.GetUserID(context.Background(), "dev1")
+	if err != nil || id != "user-dev1" {
+		t.Fatalf("unexpected result %v, %v", id, err)
+	}
+	if second.calls != 0 {
+		t.Errorf("expected the cached ID, made %d calls", second.calls)
+	}
+
+	// Expired entries are fetched again
+	third := &userCountingClient{mockClient: newMockClient()}
+	client = NewCachingClient(third, "github.com")
+	if err := client.Load(path, 0); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	client.GetUserID(context.Background(), "dev1")
+	if third.calls != 1 {
+		t.Errorf("expected an expired entry to be fetched, made %d calls", third.calls)
+	}
+
+	// A missing cache file is an empty cache
+	if err := NewCachingClient(third, "github.com").Load(filepath.Join(t.TempDir(), "missing.json"), time.Hour); err != nil {
+		t.Errorf("expected a missing file to be ignored, got %v", err)
+	}
+}
+
+// fieldCountingClient counts GetProjectV2Fields calls.
+type fieldCountingClient struct {
+	*mockClient
+	calls int
+}
+
+func (c *fieldCountingClient) GetProjectV2Fields(ctx context.Context, projectID githubv4.ID) ([]ghclient.ProjectV2Field, error) {
+	c.calls++
+	return c.mockClient.GetProjectV2Fields(ctx, projectID)
+}
+
+func TestCachingClient_FieldsNotSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	first := &fieldCountingClient{mockClient: newMockClient()}
+	client := NewCachingClient(first, "github.com")
+	client.GetProjectV2Fields(context.Background(), "project-id")
+	client.GetProjectV2Fields(context.Background(), "project-id")
+	if first.calls != 1 {
+		t.Errorf("expected the fields to be cached within a run, made %d calls", first.calls)
+	}
+	if err := client.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A later run reads the fields again, since options may have changed
+	second := &fieldCountingClient{mockClient: newMockClient()}
+	client = NewCachingClient(second, "github.com")
+	if err := client.Load(path, time.Hour); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	client.GetProjectV2Fields(context.Background(), "project-id")
+	if second.calls != 1 {
+		t.Errorf("expected the fields to be fetched again, made %d calls", second.calls)
+	}
+}
@@ -0,0 +1,443 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	ghclient "github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/state"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	gogithub "github.com/google/go-github/v66/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// Action describes what applying a plan will do to a single plan element.
+type Action string
+
+const (
+	// ActionCreate means the element does not exist yet and will be created.
+	ActionCreate Action = "create"
+	// ActionUpdate means the element exists and apply will change it.
+	ActionUpdate Action = "update"
+	// ActionUnchanged means the element already matches the plan.
+	ActionUnchanged Action = "unchanged"
+	// ActionDrift means the element differs from the plan but apply will not change it.
+	ActionDrift Action = "drift"
+	// ActionPrune means the issue is managed by the tool but no longer in the plan.
+	ActionPrune Action = "prune"
+)
+
+// Kinds of plan elements in a change set.
+const (
+	KindLabel     = "label"
+	KindMilestone = "milestone"
+	KindEpic      = "epic"
+	KindIssue     = "issue"
+)
+
+// ErrStaleChangeSet is returned when a saved change set no longer matches the current state.
+var ErrStaleChangeSet = errors.New("saved change set is stale")
+
+// FieldDiff is a single field whose current value differs from the plan.
+type FieldDiff struct {
+	Field   string `json:"field"`
+	Current string `json:"current"`
+	Desired string `json:"desired"`
+}
+
+// Change is the computed action for one label, milestone, epic or child issue.
+type Change struct {
+	Kind   string      `json:"kind"`
+	Title  string      `json:"title"`
+	Parent string      `json:"parent,omitempty"`
+	Action Action      `json:"action"`
+	Number int         `json:"number,omitempty"`
+	Diffs  []FieldDiff `json:"diffs,omitempty"`
+	Drift  []FieldDiff `json:"drift,omitempty"`
+}
+
+// ChangeSet is the result of comparing a plan against the current state on GitHub.
+type ChangeSet struct {
+	Plan      types.Plan  `json:"plan"`
+	Reconcile bool        `json:"reconcile,omitempty"`
+	Prune     PrunePolicy `json:"prune,omitempty"`
+	Changes   []Change    `json:"changes"`
+}
+
+// Count returns the number of changes with the given action.
+func (cs *ChangeSet) Count(action Action) int {
+	n := 0
+	for _, c := range cs.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// HasChanges reports whether applying the change set would modify anything.
+func (cs *ChangeSet) HasChanges() bool {
+	return cs.Count(ActionCreate) > 0 || cs.Count(ActionUpdate) > 0 || cs.Count(ActionPrune) > 0
+}
+
+func (cs *ChangeSet) String() string {
+	s := fmt.Sprintf("Plan: %d to create, %d to update, %d unchanged, %d drifted",
+		cs.Count(ActionCreate), cs.Count(ActionUpdate), cs.Count(ActionUnchanged), cs.Count(ActionDrift))
+	if n := cs.Count(ActionPrune); n > 0 {
+		s += fmt.Sprintf(", %d to prune (%s)", n, cs.Prune)
+	}
+	return s
+}
+
+// Render writes a human-readable view of the change set to w.
+func (cs *ChangeSet) Render(w io.Writer) {
+	symbols := map[Action]string{
+		ActionCreate:    "+",
+		ActionUpdate:    "~",
+		ActionUnchanged: "=",
+		ActionDrift:     "!",
+		ActionPrune:     "-",
+	}
+	// Nested issues are indented under their parent
+	depths := make(map[string]int)
+	for _, c := range cs.Changes {
+		depth := 0
+		if c.Parent != "" {
+			depth = depths[c.Parent] + 1
+		}
+		if c.Kind == KindEpic || c.Kind == KindIssue {
+			depths[c.Title] = depth
+		}
+		indent := strings.Repeat("  ", depth)
+		ref := ""
+		if c.Number > 0 {
+			ref = fmt.Sprintf(" (#%d)", c.Number)
+		}
+		fmt.Fprintf(w, "%s%s %s %q%s: %s\n", indent, symbols[c.Action], c.Kind, c.Title, ref, c.Action)
+		for _, d := range c.Diffs {
+			fmt.Fprintf(w, "%s    ~ %s: %q -> %q\n", indent, d.Field, d.Current, d.Desired)
+		}
+		for _, d := range c.Drift {
+			fmt.Fprintf(w, "%s    ! %s: %q (plan: %q)\n", indent, d.Field, d.Current, d.Desired)
+		}
+	}
+	fmt.Fprintln(w, cs)
+}
+
+func (c *Change) resolveAction() {
+	switch {
+	case len(c.Diffs) > 0:
+		c.Action = ActionUpdate
+	case len(c.Drift) > 0:
+		c.Action = ActionDrift
+	default:
+		c.Action = ActionUnchanged
+	}
+}
+
+// ComputeChangeSet reads the current state of the repository, milestones, labels,
+// issues and Project V2 items and describes what ApplyPlan would do with the plan.
+// It never modifies anything on GitHub.
+func ComputeChangeSet(ctx context.Context, client GitHubClient, plan types.Plan, opts Options) (*ChangeSet, error) {
+	owner, repo, err := parseRepository(plan.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	var projectID string
+	if opts.State != nil && opts.State.Repository == plan.Repository && opts.State.Project == plan.Project {
+		projectID = opts.State.ProjectID
+	}
+	if projectID == "" {
+		if _, err := client.GetRepositoryID(ctx, owner, repo); err != nil {
+			return nil, fmt.Errorf("failed to get repository id: %w", err)
+		}
+		projectID, err = client.GetProjectV2ID(ctx, owner, plan.Project)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get project id: %w", err)
+		}
+	}
+
+	roots, err := buildTree(plan)
+	if err != nil {
+		return nil, err
+	}
+	order, err := creationOrder(roots)
+	if err != nil {
+		return nil, err
+	}
+
+	_, statusOptions, err := client.GetProjectV2StatusFieldOptions(ctx, githubv4.ID(projectID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project status field options: %w", err)
+	}
+
+	fields, err := loadFields(ctx, client, projectID, plan.IterationField, order)
+	if err != nil {
+		return nil, err
+	}
+	native, err := nativeDependencies(ctx, client, order)
+	if err != nil {
+		return nil, err
+	}
+
+	finder, err := newIssueFinder(ctx, client, owner, repo, plan, opts.State)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &ChangeSet{Plan: plan, Reconcile: opts.Reconcile, Prune: opts.Prune}
+
+	// Labels
+	if len(plan.Labels) > 0 {
+		existingLabels, err := client.ListLabels(ctx, owner, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list labels: %w", err)
+		}
+		for _, l := range plan.Labels {
+			cs.Changes = append(cs.Changes, labelChange(l, existingLabels))
+		}
+	}
+
+	// Milestones
+	existingMilestones, err := client.ListMilestones(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list milestones: %w", err)
+	}
+	for _, m := range plan.Milestones {
+		cs.Changes = append(cs.Changes, milestoneChange(m, recordedMilestone(opts.State, state.Key(m.ID, m.Title)), existingMilestones))
+	}
+
+	// Each epic or issue is compared in creation order, so the numbers of its
+	// children and blockers are known for its body
+	changes := make(map[*planNode]*Change)
+	resolved := newResolvedIssues()
+	currents := make(map[*planNode]*ghclient.IssueDetails)
+	ref := func(n *planNode) string {
+		if changes[n].Number > 0 {
+			return fmt.Sprintf("#%d", changes[n].Number)
+		}
+		return "#?"
+	}
+	for _, n := range order {
+		var childRefs, blockerRefs []string
+		for _, c := range n.children {
+			childRefs = append(childRefs, "- [ ] "+ref(c))
+		}
+		if !native {
+			for _, b := range n.blockedBy {
+				blockerRefs = append(blockerRefs, ref(b))
+			}
+		}
+		spec := n.spec(childRefs, blockerRefs, plan.Linking)
+
+		change := &Change{Kind: spec.Kind, Title: spec.Title}
+		if n.parent != nil {
+			change.Parent = n.parent.issue.Title
+		}
+		changes[n] = change
+		num, _, err := finder.find(ctx, spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for existing issue %q: %w", spec.Title, err)
+		}
+		if num == 0 {
+			change.Action = ActionCreate
+			continue
+		}
+
+		current, err := client.GetIssue(ctx, owner, repo, num)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read issue #%d: %w", num, err)
+		}
+		currents[n] = current
+		resolved.add(num, current.ID)
+		change.Number = num
+		change.Diffs = projectDiffs(current, projectID, spec, statusOptions, fields)
+		if opts.Reconcile {
+			change.Diffs = append(issueDiffs(current, spec), change.Diffs...)
+		} else {
+			change.Drift = issueDiffs(current, spec)
+		}
+		if native && len(n.blockedBy) > 0 {
+			blockedBy, err := client.GetBlockedBy(ctx, owner, repo, num)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read dependencies of #%d: %w", num, err)
+			}
+			var desired []string
+			for _, b := range n.blockedBy {
+				desired = append(desired, ref(b))
+			}
+			change.Diffs = append(change.Diffs, blockedByDiffs(blockedBy, desired)...)
+		}
+		change.resolveAction()
+	}
+
+	// Sub-issue linkage of existing children, once their parents' numbers are known
+	if usesSubIssues(plan.Linking) {
+		for _, n := range order {
+			current := currents[n]
+			if n.parent == nil || current == nil {
+				continue
+			}
+			change := changes[n]
+			diffs := parentDiffs(current, changes[n.parent].Number)
+			// Moving a child away from another parent only happens when reconciling
+			if current.ParentNumber == 0 || opts.Reconcile {
+				change.Diffs = append(change.Diffs, diffs...)
+			} else {
+				change.Drift = append(change.Drift, diffs...)
+			}
+			change.resolveAction()
+		}
+	}
+
+	// Each epic is followed by its descendants, parents before children
+	for _, n := range preOrder(roots) {
+		cs.Changes = append(cs.Changes, *changes[n])
+	}
+
+	if opts.Prune != PruneNone {
+		candidates, err := findPruneCandidates(ctx, client, owner, repo, plan, opts.State, resolved)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candidates {
+			cs.Changes = append(cs.Changes, Change{Kind: KindIssue, Title: c.Title, Action: ActionPrune, Number: c.Number})
+		}
+	}
+
+	return cs, nil
+}
+
+// ApplyChangeSet applies a previously computed change set. The change set is
+// recomputed first and the apply is refused if the current state no longer
+// produces exactly the reviewed changes. The change set's reconcile and prune
+// modes override the ones in opts.
+func ApplyChangeSet(ctx context.Context, client GitHubClient, saved *ChangeSet, opts Options) (*Report, error) {
+	opts.Reconcile = saved.Reconcile
+	opts.Prune = saved.Prune
+	// A resumed apply has already changed GitHub; the change set was checked before it started
+	if opts.Journal != nil && opts.Journal.Len() > 0 {
+		return ApplyPlan(ctx, client, saved.Plan, opts)
+	}
+	current, err := ComputeChangeSet(ctx, client, saved.Plan, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	savedJSON, err := json.Marshal(saved.Changes)
+	if err != nil {
+		return nil, err
+	}
+	currentJSON, err := json.Marshal(current.Changes)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(savedJSON, currentJSON) {
+		return nil, fmt.Errorf("%w: the repository or project changed since the plan was computed, run plan again", ErrStaleChangeSet)
+	}
+
+	return ApplyPlan(ctx, client, saved.Plan, opts)
+}
+
+func milestoneChange(m types.Milestone, recorded int, existing []*gogithub.Milestone) Change {
+	change := Change{Kind: KindMilestone, Title: m.Title}
+	em := findMilestone(m, recorded, existing)
+	if em == nil {
+		change.Action = ActionCreate
+		return change
+	}
+
+	change.Number = em.GetNumber()
+	change.Diffs = milestoneDiffs(m, em)
+	change.resolveAction()
+	return change
+}
+
+// projectDiffs compares the project membership, status and custom fields of an
+// existing issue. ApplyPlan always ensures existing issues are on the board with
+// the plan's status and fields.
+func projectDiffs(current *ghclient.IssueDetails, projectID string, spec issueSpec, statusOptions map[string]string, fields *projectFields) []FieldDiff {
+	// Field values were checked by loadFields
+	resolved, _ := resolveFields(spec, fields)
+
+	item, ok := current.ProjectItem(projectID)
+	if !ok {
+		diffs := []FieldDiff{{Field: "project", Current: "", Desired: "added"}}
+		if _, known := statusOptions[spec.Status]; known {
+			diffs = append(diffs, FieldDiff{Field: "status", Current: "", Desired: spec.Status})
+		}
+		return append(diffs, fieldDiffs(resolved, nil)...)
+	}
+	var diffs []FieldDiff
+	if _, known := statusOptions[spec.Status]; known {
+		diffs = diffString("status", item.Status, spec.Status)
+	}
+	return append(diffs, fieldDiffs(resolved, item.Fields)...)
+}
+
+// parentDiffs compares the parent of an existing child issue with its epic or
+// parent issue. A parent number of 0 means the parent does not exist yet.
+func parentDiffs(current *ghclient.IssueDetails, parentNumber int) []FieldDiff {
+	if parentNumber > 0 && current.ParentNumber == parentNumber {
+		return nil
+	}
+	currentRef := ""
+	if current.ParentNumber > 0 {
+		currentRef = fmt.Sprintf("#%d", current.ParentNumber)
+	}
+	desiredRef := "#?"
+	if parentNumber > 0 {
+		desiredRef = fmt.Sprintf("#%d", parentNumber)
+	}
+	return []FieldDiff{{Field: "parent", Current: currentRef, Desired: desiredRef}}
+}
+
+// blockedByDiffs compares the issues currently blocking an existing issue with
+// the plan's blockers. Only missing blockers are a difference, since apply never
+// removes dependencies.
+func blockedByDiffs(current []int, desired []string) []FieldDiff {
+	var currentRefs []string
+	have := make(map[string]bool)
+	for _, number := range current {
+		ref := fmt.Sprintf("#%d", number)
+		currentRefs = append(currentRefs, ref)
+		have[ref] = true
+	}
+	missing := false
+	for _, ref := range desired {
+		missing = missing || !have[ref]
+	}
+	if !missing {
+		return nil
+	}
+	return []FieldDiff{{Field: "blocked_by", Current: strings.Join(currentRefs, ", "), Desired: strings.Join(desired, ", ")}}
+}
+
+func diffString(field, current, desired string) []FieldDiff {
+	if current == desired {
+		return nil
+	}
+	return []FieldDiff{{Field: field, Current: current, Desired: desired}}
+}
+
+func diffSet(field string, current, desired []string) []FieldDiff {
+	a := sortedCopy(current)
+	b := sortedCopy(desired)
+	if strings.Join(a, ",") == strings.Join(b, ",") {
+		return nil
+	}
+	return []FieldDiff{{Field: field, Current: strings.Join(a, ", "), Desired: strings.Join(b, ", ")}}
+}
+
+func sortedCopy(values []string) []string {
+	out := append([]string(nil), values...)
+	sort.Strings(out)
+	return out
+}
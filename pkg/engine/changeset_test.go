@@ -0,0 +1,279 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	ghclient "github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	gogithub "github.com/google/go-github/v66/github"
+)
+
+func changeSetTestPlan() types.Plan {
+	return types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Milestones: []types.Milestone{
+			{Title: "Phase 1", DueOn: "2026-04-01", Description: "First phase"},
+		},
+		Epics: []types.Epic{
+			{
+				Title:  "Epic 1",
+				Body:   "Epic body",
+				Status: "Todo",
+				Labels: []string{"backend"},
+				Children: []types.Issue{
+					{Title: "Child 1", Body: "Child body"},
+				},
+			},
+		},
+	}
+}
+
+func findChange(t *testing.T, cs *ChangeSet, kind, title string) Change {
+	t.Helper()
+	for _, c := range cs.Changes {
+		if c.Kind == kind && c.Title == title {
+			return c
+		}
+	}
+	t.Fatalf("no %s change for %q in %+v", kind, title, cs.Changes)
+	return Change{}
+}
+
+func TestComputeChangeSet_AllNew(t *testing.T) {
+	mock := newMockClient()
+
+	cs, err := ComputeChangeSet(context.Background(), mock, changeSetTestPlan(), Options{})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+
+	if len(cs.Changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d: %+v", len(cs.Changes), cs.Changes)
+	}
+	if cs.Count(ActionCreate) != 3 {
+		t.Errorf("expected 3 creates, got %d", cs.Count(ActionCreate))
+	}
+	if child := findChange(t, cs, KindIssue, "Child 1"); child.Parent != "Epic 1" {
+		t.Errorf("expected child parent Epic 1, got %q", child.Parent)
+	}
+	if len(mock.createdIssues) != 0 {
+		t.Errorf("ComputeChangeSet should not create issues, got %v", mock.createdIssues)
+	}
+}
+
+func TestComputeChangeSet_ExistingState(t *testing.T) {
+	mock := newMockClient()
+	due := time.Date(2026, 4, 1, 7, 0, 0, 0, time.UTC)
+	mock.milestones = []*gogithub.Milestone{
+		{Number: gogithub.Int(1), Title: gogithub.String("Phase 1"), Description: gogithub.String("Old text"), DueOn: &gogithub.Timestamp{Time: due}},
+	}
+	mock.issues = map[int]*ghclient.IssueDetails{
+		42: {
+			Number:       42,
+			Title:        "Child 1",
+			Body:         "Child body",
+			ProjectItems: []ghclient.ProjectItemRef{{ID: "item-42", ProjectID: "project-node-id", Status: "Done"}},
+		},
+		99: {
+			Number:       99,
+			Title:        "Epic 1",
+			Body:         "Epic body\n\n- [ ] #42",
+			Labels:       []string{"backend"},
+			ProjectItems: []ghclient.ProjectItemRef{{ID: "item-99", ProjectID: "project-node-id", Status: "Todo"}},
+		},
+	}
+	client := &idempotentMockClient{mockClient: mock, existingIssues: map[string]int{
+		"Child 1": 42,
+		"Epic 1":  99,
+	}}
+
+	cs, err := ComputeChangeSet(context.Background(), client, changeSetTestPlan(), Options{})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+
+	milestone := findChange(t, cs, KindMilestone, "Phase 1")
+	if milestone.Action != ActionUpdate {
+		t.Errorf("expected milestone update, got %s", milestone.Action)
+	}
+	if len(milestone.Diffs) != 1 || milestone.Diffs[0].Field != "description" {
+		t.Errorf("expected description change only, got %+v", milestone.Diffs)
+	}
+
+	child := findChange(t, cs, KindIssue, "Child 1")
+	if child.Action != ActionUpdate || child.Number != 42 {
+		t.Errorf("expected child update of #42, got %s #%d", child.Action, child.Number)
+	}
+	if len(child.Diffs) != 1 || child.Diffs[0] != (FieldDiff{Field: "status", Current: "Done", Desired: "Todo"}) {
+		t.Errorf("unexpected child diffs: %+v", child.Diffs)
+	}
+
+	epic := findChange(t, cs, KindEpic, "Epic 1")
+	if epic.Action != ActionUnchanged {
+		t.Errorf("expected epic unchanged, got %s (%+v, %+v)", epic.Action, epic.Diffs, epic.Drift)
+	}
+}
+
+func TestComputeChangeSet_NotOnProject(t *testing.T) {
+	mock := newMockClient()
+	client := &idempotentMockClient{mockClient: mock, existingIssues: map[string]int{"Child 1": 42}}
+
+	cs, err := ComputeChangeSet(context.Background(), client, changeSetTestPlan(), Options{})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+
+	child := findChange(t, cs, KindIssue, "Child 1")
+	if child.Action != ActionUpdate {
+		t.Fatalf("expected update for issue missing from project, got %s", child.Action)
+	}
+	if child.Diffs[0].Field != "project" {
+		t.Errorf("expected project diff first, got %+v", child.Diffs)
+	}
+	// Body is empty on GitHub but the plan has one; apply would not touch it
+	if len(child.Drift) != 1 || child.Drift[0].Field != "body" {
+		t.Errorf("expected body drift, got %+v", child.Drift)
+	}
+}
+
+func TestComputeChangeSet_SubIssues(t *testing.T) {
+	mock := newMockClient()
+	mock.issues = map[int]*ghclient.IssueDetails{
+		42: {ID: "existing-node-Child 1", Number: 42, Title: "Child 1", Body: "Child body", ParentID: "other-epic", ParentNumber: 7},
+	}
+	client := &idempotentMockClient{mockClient: mock, existingIssues: map[string]int{"Child 1": 42, "Epic 1": 99}}
+	plan := changeSetTestPlan()
+	plan.Linking = types.LinkSubIssues
+
+	cs, err := ComputeChangeSet(context.Background(), client, plan, Options{})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+	child := findChange(t, cs, KindIssue, "Child 1")
+	want := FieldDiff{Field: "parent", Current: "#7", Desired: "#99"}
+	if len(child.Drift) != 1 || child.Drift[0] != want {
+		t.Errorf("expected parent drift without reconcile, got %+v", child.Drift)
+	}
+
+	cs, err = ComputeChangeSet(context.Background(), client, plan, Options{Reconcile: true})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+	child = findChange(t, cs, KindIssue, "Child 1")
+	if child.Action != ActionUpdate || child.Diffs[len(child.Diffs)-1] != want {
+		t.Errorf("expected parent update with reconcile, got %+v", child)
+	}
+}
+
+func TestComputeChangeSet_Dependencies(t *testing.T) {
+	mock := newMockClient()
+	mock.issues = map[int]*ghclient.IssueDetails{
+		42: {Number: 42, Title: "Child 1", Body: "Child body"},
+	}
+	client := &idempotentMockClient{mockClient: mock, existingIssues: map[string]int{"Child 1": 42, "Epic 1": 99}}
+	plan := changeSetTestPlan()
+	plan.Epics = append(plan.Epics, types.Epic{Title: "Epic 2", Blocks: []string{"Child 1"}})
+
+	// Without native dependencies the blocker goes into the body
+	cs, err := ComputeChangeSet(context.Background(), client, plan, Options{Reconcile: true})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+	child := findChange(t, cs, KindIssue, "Child 1")
+	want := FieldDiff{Field: "body", Current: "Child body", Desired: "Child body\n\nBlocked by #?"}
+	if len(child.Diffs) == 0 || child.Diffs[0] != want {
+		t.Errorf("expected body diff %+v, got %+v", want, child.Diffs)
+	}
+
+	mock.dependencies = true
+	mock.blockedBy = map[int][]int{42: {7}}
+	cs, err = ComputeChangeSet(context.Background(), client, plan, Options{})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+	child = findChange(t, cs, KindIssue, "Child 1")
+	want = FieldDiff{Field: "blocked_by", Current: "#7", Desired: "#?"}
+	if child.Diffs[len(child.Diffs)-1] != want {
+		t.Errorf("expected blocked_by diff %+v, got %+v", want, child.Diffs)
+	}
+	if len(child.Drift) != 0 {
+		t.Errorf("expected no body drift with native dependencies, got %+v", child.Drift)
+	}
+
+	var order []string
+	for _, c := range cs.Changes {
+		order = append(order, c.Title)
+	}
+	if got := strings.Join(order, "|"); got != "Phase 1|Epic 1|Child 1|Epic 2" {
+		t.Errorf("expected changes in plan order, got %s", got)
+	}
+}
+
+func TestApplyChangeSet_Stale(t *testing.T) {
+	mock := newMockClient()
+	plan := changeSetTestPlan()
+
+	cs, err := ComputeChangeSet(context.Background(), mock, plan, Options{})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+
+	// Round-trip through JSON as the plan command does
+	data, err := json.Marshal(cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved ChangeSet
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	// Someone created the child issue in the meantime
+	client := &idempotentMockClient{mockClient: mock, existingIssues: map[string]int{"Child 1": 42}}
+	_, err = ApplyChangeSet(context.Background(), client, &saved, Options{})
+	if !errors.Is(err, ErrStaleChangeSet) {
+		t.Fatalf("expected ErrStaleChangeSet, got %v", err)
+	}
+	if len(mock.createdIssues) != 0 {
+		t.Errorf("stale change set should not create issues, got %v", mock.createdIssues)
+	}
+
+	report, err := ApplyChangeSet(context.Background(), mock, &saved, Options{})
+	if err != nil {
+		t.Fatalf("ApplyChangeSet failed: %v", err)
+	}
+	if report.EpicsCreated != 1 || report.IssuesCreated != 1 {
+		t.Errorf("expected 1 epic and 1 issue created, got %+v", report)
+	}
+}
+
+func TestChangeSet_KeepsExplicitEmptyAssignees(t *testing.T) {
+	plan := changeSetTestPlan()
+	plan.Epics[0].Assignees = []string{"lead"}
+	plan.Epics[0].Children = append(plan.Epics[0].Children, types.Issue{Title: "Child 2", Assignees: &[]string{}})
+
+	// Round-trip through JSON as plan --out and apply --plan-file do
+	data, err := json.Marshal(ChangeSet{Plan: plan})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved ChangeSet
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	epic := saved.Plan.Epics[0]
+	if spec := childSpec(epicSpec(epic), epic.Children[0]); spec.Assignees == nil || fmt.Sprint(*spec.Assignees) != "[lead]" {
+		t.Errorf("expected Child 1 to inherit the epic's assignees, got %v", spec.Assignees)
+	}
+	if spec := childSpec(epicSpec(epic), epic.Children[1]); spec.Assignees == nil || len(*spec.Assignees) != 0 {
+		t.Errorf("expected Child 2 to stay unassigned, got %v", spec.Assignees)
+	}
+}
@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// resultMap holds the issue each plan node resolved to. Workers applying nodes
+// concurrently share it.
+type resultMap struct {
+	mu sync.Mutex
+	m  map[*planNode]issueResult
+}
+
+func newResultMap() *resultMap {
+	return &resultMap{m: make(map[*planNode]issueResult)}
+}
+
+func (r *resultMap) get(n *planNode) issueResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.m[n]
+}
+
+func (r *resultMap) set(n *planNode, res issueResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.m[n] = res
+}
+
+// applyNodes applies the nodes of a plan, given in creation order, in the work
+// units of workUnits. With Options.Concurrency above 1, up to that many units are
+// applied at once; each starts once the children and blockers of its nodes are
+// done, and ready units start in creation order. Every unit reports into its own
+// Report, and those are merged in creation order, so the report does not depend
+// on scheduling.
+func (a *applier) applyNodes(ctx context.Context, order []*planNode) error {
+	results := newResultMap()
+	units := workUnits(order)
+	if a.opts.Concurrency <= 1 {
+		for _, unit := range units {
+			if err := a.applyUnit(ctx, unit, results); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	unitOf := make(map[*planNode]int, len(order))
+	for i, unit := range units {
+		for _, n := range unit {
+			unitOf[n] = i
+		}
+	}
+	pending := make([]int, len(units))
+	dependents := make([][]int, len(units))
+	var ready []int
+	for i, unit := range units {
+		deps := make(map[int]bool)
+		for _, n := range unit {
+			for _, c := range n.children {
+				deps[unitOf[c]] = true
+			}
+			for _, b := range n.blockedBy {
+				deps[unitOf[b]] = true
+			}
+		}
+		for d := range deps {
+			dependents[d] = append(dependents[d], i)
+		}
+		pending[i] = len(deps)
+		if len(deps) == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	type outcome struct {
+		unit int
+		err  error
+	}
+	finished := make(chan outcome)
+	reports := make([]*Report, len(units))
+	running := 0
+	var firstErr error
+	for {
+		// After a failure, let running units finish but start no more
+		for firstErr == nil && running < a.opts.Concurrency && len(ready) > 0 {
+			i := ready[0]
+			ready = ready[1:]
+			worker := *a
+			worker.report = &Report{}
+			reports[i] = worker.report
+			running++
+			go func() {
+				finished <- outcome{unit: i, err: worker.applyUnit(ctx, units[i], results)}
+			}()
+		}
+		if running == 0 {
+			break
+		}
+
+		done := <-finished
+		running--
+		if done.err != nil {
+			if firstErr == nil {
+				firstErr = done.err
+			}
+			continue
+		}
+		for _, d := range dependents[done.unit] {
+			pending[d]--
+			if pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+		sort.Ints(ready)
+	}
+
+	for _, r := range reports {
+		if r != nil {
+			a.report.merge(r)
+		}
+	}
+	return firstErr
+}
+
+// applyUnit applies one work unit once the rate limit allows.
+func (a *applier) applyUnit(ctx context.Context, unit []*planNode, results *resultMap) error {
+	if err := pace(ctx, a.client); err != nil {
+		return err
+	}
+	if len(unit) > 1 {
+		return a.applyBatch(ctx, unit, results)
+	}
+	return a.applyNode(ctx, unit[0], results)
+}
+
+// merge adds the counts and entries of other to the report.
+func (r *Report) merge(other *Report) {
+	r.MilestonesCreated += other.MilestonesCreated
+	r.EpicsCreated += other.EpicsCreated
+	r.EpicsUpdated += other.EpicsUpdated
+	r.EpicsSkipped += other.EpicsSkipped
+	r.IssuesCreated += other.IssuesCreated
+	r.IssuesUpdated += other.IssuesUpdated
+	r.IssuesSkipped += other.IssuesSkipped
+	r.SubIssuesLinked += other.SubIssuesLinked
+	r.DependenciesAdded += other.DependenciesAdded
+	r.EpicURLs = append(r.EpicURLs, other.EpicURLs...)
+	r.Updates = append(r.Updates, other.Updates...)
+	r.Pruned = append(r.Pruned, other.Pruned...)
+	r.Resumed = append(r.Resumed, other.Resumed...)
+}
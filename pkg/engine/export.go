@@ -0,0 +1,437 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	ghclient "github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	"github.com/shurcooL/githubv4"
+)
+
+var (
+	// tasklistLine matches a tasklist entry referencing an issue.
+	tasklistLine = regexp.MustCompile(`^- \[[ xX]\] #(\d+)$`)
+	// blockedByLine matches the line added to bodies when native dependencies are unavailable.
+	blockedByLine = regexp.MustCompile(`^Blocked by #\d+(, #\d+)*$`)
+	issueRef      = regexp.MustCompile(`#(\d+)`)
+)
+
+// exportNode is an issue on the board being exported.
+type exportNode struct {
+	item ghclient.ProjectV2Item
+	id   string
+	body string
+	// hasTasklist is set when the body ends in a tasklist, even an empty one
+	hasTasklist bool
+	tasklist    []int
+	blockedBy   []int
+	parent      *exportNode
+	children    []*exportNode
+}
+
+func (n *exportNode) number() int {
+	return n.item.Issue.Number
+}
+
+// ref is how depends_on refers to the issue.
+func (n *exportNode) ref() string {
+	if n.id != "" {
+		return n.id
+	}
+	return n.item.Issue.Title
+}
+
+// inherited is what apply derives for a child issue from its ancestors.
+type inherited struct {
+	milestone string
+	status    string
+	assignees []string
+	fields    map[string]string
+}
+
+// exporter holds what ExportPlan has read from GitHub.
+type exporter struct {
+	nodes    map[int]*exportNode
+	settable map[string]string
+	warnings []string
+}
+
+// ExportPlan reads the open issues on a Project V2 board and describes them as a
+// plan for the client's host that apply leaves unchanged: status and custom
+// fields, milestones, labels, assignees, the tasklist or sub-issue hierarchy and
+// dependencies. Issues that cannot be described exactly are still exported and
+// listed in the warnings; closed issues are skipped with a warning.
+func ExportPlan(ctx context.Context, client GitHubClient, repository, project string) (types.Plan, []string, error) {
+	plan := types.Plan{Project: project, Repository: repository, Host: client.Host()}
+	owner, repo, err := parseRepository(repository)
+	if err != nil {
+		return plan, nil, err
+	}
+
+	projectID, err := client.GetProjectV2ID(ctx, owner, project)
+	if err != nil {
+		return plan, nil, fmt.Errorf("failed to get project id: %w", err)
+	}
+	items, err := client.ListProjectV2Items(ctx, githubv4.ID(projectID))
+	if err != nil {
+		return plan, nil, fmt.Errorf("failed to list project items: %w", err)
+	}
+	fieldList, err := client.GetProjectV2Fields(ctx, githubv4.ID(projectID))
+	if err != nil {
+		return plan, nil, fmt.Errorf("failed to get project fields: %w", err)
+	}
+	native, err := client.SupportsIssueDependencies(ctx)
+	if err != nil {
+		return plan, nil, fmt.Errorf("failed to check for issue dependency support: %w", err)
+	}
+
+	e := &exporter{nodes: make(map[int]*exportNode), settable: make(map[string]string)}
+	for _, f := range fieldList {
+		switch f.DataType {
+		case ghclient.FieldTypeText, ghclient.FieldTypeNumber, ghclient.FieldTypeDate, ghclient.FieldTypeSingleSelect, ghclient.FieldTypeIteration:
+			// Status is exported on its own
+			if f.Name != "Status" {
+				e.settable[f.Name] = f.DataType
+			}
+		}
+	}
+
+	// Issues from other repositories cannot be part of the plan, and apply only
+	// finds open issues by title, so closed ones would be created again
+	skipped := 0
+	for _, item := range items {
+		if !strings.EqualFold(item.Repository, repository) {
+			skipped++
+			continue
+		}
+		if item.Issue.State == string(githubv4.IssueStateClosed) {
+			e.warn(fmt.Sprintf("#%d %s: skipped because it is closed", item.Issue.Number, item.Issue.Title))
+			continue
+		}
+		e.nodes[item.Issue.Number] = parseExportBody(item, !native)
+	}
+	if skipped > 0 {
+		e.warn(fmt.Sprintf("skipped %d issues from other repositories", skipped))
+	}
+	numbers := make([]int, 0, len(e.nodes))
+	for number := range e.nodes {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	if native {
+		for _, number := range numbers {
+			n := e.nodes[number]
+			n.blockedBy, err = client.GetBlockedBy(ctx, owner, repo, number)
+			if err != nil {
+				return plan, nil, fmt.Errorf("failed to read dependencies of #%d: %w", number, err)
+			}
+		}
+	}
+
+	plan.Linking = e.buildHierarchy(numbers)
+
+	// Milestones in order of first use
+	seenMilestones := make(map[string]bool)
+	for _, number := range numbers {
+		issue := e.nodes[number].item
+		title := issue.Issue.Milestone
+		if title == "" || seenMilestones[title] {
+			continue
+		}
+		seenMilestones[title] = true
+		id := parseMarker(issue.MilestoneDescription)
+		plan.Milestones = append(plan.Milestones, types.Milestone{
+			ID:          id,
+			Title:       title,
+			DueOn:       issue.MilestoneDueOn,
+			Description: stripMarker(issue.MilestoneDescription, id),
+		})
+	}
+
+	titles := make(map[string]bool)
+	for _, number := range numbers {
+		n := e.nodes[number]
+		if titles[n.item.Issue.Title] {
+			e.warn(fmt.Sprintf("#%d %s: duplicate title; give the issues ids before applying", number, n.item.Issue.Title))
+		}
+		titles[n.item.Issue.Title] = true
+		if n.parent == nil {
+			plan.Epics = append(plan.Epics, e.exportEpic(n))
+		}
+	}
+
+	if err := ValidateDependencies(plan); err != nil {
+		e.warn(err.Error())
+	}
+	return plan, e.warnings, nil
+}
+
+func (e *exporter) warn(msg string) {
+	e.warnings = append(e.warnings, msg)
+}
+
+// parseExportBody splits what apply adds to a body (tasklist, "Blocked by" line
+// and ID marker) from the text written by hand.
+func parseExportBody(item ghclient.ProjectV2Item, bodyDependencies bool) *exportNode {
+	body := item.Issue.Body
+	n := &exportNode{item: item, id: parseMarker(body)}
+	body = stripMarker(body, n.id)
+
+	if bodyDependencies {
+		if i := strings.LastIndex(body, "\n\n"); i >= 0 && blockedByLine.MatchString(body[i+2:]) {
+			for _, m := range issueRef.FindAllStringSubmatch(body[i+2:], -1) {
+				number, _ := strconv.Atoi(m[1])
+				n.blockedBy = append(n.blockedBy, number)
+			}
+			body = body[:i]
+		}
+	}
+
+	if i := strings.LastIndex(body, "\n\n"); i >= 0 {
+		var refs []int
+		isTasklist := true
+		rest := body[i+2:]
+		if rest != "" {
+			for _, line := range strings.Split(rest, "\n") {
+				m := tasklistLine.FindStringSubmatch(line)
+				if m == nil {
+					isTasklist = false
+					break
+				}
+				number, _ := strconv.Atoi(m[1])
+				refs = append(refs, number)
+			}
+		}
+		if isTasklist {
+			n.hasTasklist = true
+			n.tasklist = refs
+			body = body[:i]
+		}
+	}
+	n.body = body
+	return n
+}
+
+// stripMarker removes the ID marker that withMarker appends.
+func stripMarker(body, id string) string {
+	if id == "" {
+		return body
+	}
+	body = strings.TrimSuffix(body, marker(id))
+	return strings.TrimSuffix(body, "\n\n")
+}
+
+// buildHierarchy gives each issue at most one parent, preferring its native
+// parent over a tasklist that lists it, and returns the linking strategy that
+// reproduces the board.
+func (e *exporter) buildHierarchy(numbers []int) string {
+	hasTasklists, hasSubIssues := false, false
+	for _, number := range numbers {
+		n := e.nodes[number]
+		if parent, ok := e.nodes[n.item.Issue.ParentNumber]; ok {
+			hasSubIssues = true
+			e.setParent(n, parent)
+		}
+	}
+	for _, number := range numbers {
+		n := e.nodes[number]
+		hasTasklists = hasTasklists || n.hasTasklist
+		for _, ref := range n.tasklist {
+			child, ok := e.nodes[ref]
+			switch {
+			case !ok:
+				e.warn(fmt.Sprintf("#%d %s: tasklist entry #%d is not on the board", number, n.item.Issue.Title, ref))
+			case child.parent == nil:
+				e.setParent(child, n)
+			case child.parent != n:
+				e.warn(fmt.Sprintf("#%d %s: tasklist entry #%d already belongs to #%d", number, n.item.Issue.Title, ref, child.parent.number()))
+			}
+		}
+	}
+
+	// Children come in tasklist order, then by number
+	for _, number := range numbers {
+		n := e.nodes[number]
+		order := make(map[int]int)
+		for i, ref := range n.tasklist {
+			if _, ok := order[ref]; !ok {
+				order[ref] = i
+			}
+		}
+		sort.SliceStable(n.children, func(i, j int) bool {
+			oi, iListed := order[n.children[i].number()]
+			oj, jListed := order[n.children[j].number()]
+			if iListed != jListed {
+				return iListed
+			}
+			if iListed {
+				return oi < oj
+			}
+			return n.children[i].number() < n.children[j].number()
+		})
+	}
+
+	switch {
+	case hasTasklists && hasSubIssues:
+		return types.LinkBoth
+	case hasTasklists:
+		return ""
+	}
+	// Without tasklists, sub_issues keeps apply from adding empty ones to epics
+	return types.LinkSubIssues
+}
+
+// setParent makes parent the parent of n unless that would form a cycle.
+func (e *exporter) setParent(n, parent *exportNode) {
+	for p := parent; p != nil; p = p.parent {
+		if p == n {
+			e.warn(fmt.Sprintf("#%d %s: not nesting under #%d, which would form a cycle", n.number(), n.item.Issue.Title, parent.number()))
+			return
+		}
+	}
+	n.parent = parent
+	parent.children = append(parent.children, n)
+}
+
+func (e *exporter) exportEpic(n *exportNode) types.Epic {
+	issue := n.item.Issue
+	item := issue.ProjectItems[0]
+	epic := types.Epic{
+		ID:        n.id,
+		Title:     issue.Title,
+		Body:      n.body,
+		Milestone: issue.Milestone,
+		Status:    item.Status,
+		Labels:    issue.Labels,
+		Assignees: issue.Assignees,
+		Fields:    e.fieldValues(item.Fields, nil),
+		DependsOn: e.dependsOn(n),
+	}
+	own := inherited{milestone: issue.Milestone, status: item.Status, assignees: issue.Assignees, fields: e.settableFields(item.Fields)}
+	for _, c := range n.children {
+		epic.Children = append(epic.Children, e.exportIssue(c, own))
+	}
+	return epic
+}
+
+// exportIssue describes a child issue, leaving out whatever it inherits from its
+// parent. Inherited values the child does not have cannot be cleared in a plan,
+// so those are warnings.
+func (e *exporter) exportIssue(n *exportNode, parent inherited) types.Issue {
+	issue := n.item.Issue
+	item := issue.ProjectItems[0]
+	out := types.Issue{
+		ID:     n.id,
+		Title:  issue.Title,
+		Body:   n.body,
+		Labels: issue.Labels,
+	}
+	cannotClear := func(what string) {
+		e.warn(fmt.Sprintf("#%d %s: has no %s but would inherit one from #%d", issue.Number, issue.Title, what, n.parent.number()))
+	}
+
+	own := parent
+	switch {
+	case issue.Milestone == parent.milestone:
+	case issue.Milestone == "":
+		cannotClear("milestone")
+	default:
+		out.Milestone = issue.Milestone
+		own.milestone = issue.Milestone
+	}
+	switch {
+	case item.Status == parent.status:
+	case item.Status == "":
+		cannotClear("status")
+	default:
+		out.Status = item.Status
+		own.status = item.Status
+	}
+	if diffSet("", issue.Assignees, parent.assignees) != nil {
+		// An explicit empty list keeps the parent's assignees off the child
+		assignees := append([]string{}, issue.Assignees...)
+		out.Assignees = &assignees
+		own.assignees = issue.Assignees
+	}
+
+	fields := e.settableFields(item.Fields)
+	var missing []string
+	for name := range parent.fields {
+		if _, ok := fields[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		cannotClear("value for field " + strconv.Quote(name))
+	}
+	out.Fields = e.fieldValues(fields, parent.fields)
+	own.fields = make(map[string]string, len(fields)+len(parent.fields))
+	for name, v := range parent.fields {
+		own.fields[name] = v
+	}
+	for name, v := range fields {
+		own.fields[name] = v
+	}
+	out.DependsOn = e.dependsOn(n)
+
+	for _, c := range n.children {
+		out.Children = append(out.Children, e.exportIssue(c, own))
+	}
+	return out
+}
+
+// settableFields returns the values of the fields a plan can set.
+func (e *exporter) settableFields(values map[string]string) map[string]string {
+	fields := make(map[string]string)
+	for name, v := range values {
+		if _, ok := e.settable[name]; ok {
+			fields[name] = v
+		}
+	}
+	return fields
+}
+
+// fieldValues converts field values that differ from the inherited ones to plan
+// values; numbers become numbers so they are not quoted.
+func (e *exporter) fieldValues(values, inheritedValues map[string]string) types.Fields {
+	var fields types.Fields
+	for name, v := range e.settableFields(values) {
+		if inherited, ok := inheritedValues[name]; ok && inherited == v {
+			continue
+		}
+		if fields == nil {
+			fields = make(types.Fields)
+		}
+		fields[name] = v
+		if e.settable[name] == ghclient.FieldTypeNumber {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				fields[name] = f
+				if f == float64(int(f)) {
+					fields[name] = int(f)
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// dependsOn refers to the issues blocking n that are part of the export.
+func (e *exporter) dependsOn(n *exportNode) []string {
+	var refs []string
+	for _, number := range n.blockedBy {
+		blocker, ok := e.nodes[number]
+		if !ok {
+			e.warn(fmt.Sprintf("#%d %s: blocked by #%d, which is not on the board", n.number(), n.item.Issue.Title, number))
+			continue
+		}
+		refs = append(refs, blocker.ref())
+	}
+	return refs
+}
@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	ghclient "github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	gogithub "github.com/google/go-github/v66/github"
+)
+
+// boardItem builds a project item in owner/repo on the mock project.
+func boardItem(number int, title, body, status string, fields map[string]string) ghclient.ProjectV2Item {
+	if fields == nil {
+		fields = make(map[string]string)
+	}
+	fields["Title"] = title
+	fields["Status"] = status
+	return ghclient.ProjectV2Item{
+		Repository: "owner/repo",
+		Issue: ghclient.IssueDetails{
+			ID:     "node-" + title,
+			Number: number,
+			Title:  title,
+			Body:   body,
+			ProjectItems: []ghclient.ProjectItemRef{
+				{ID: "item-" + title, ProjectID: "project-node-id", Status: status, Fields: fields},
+			},
+		},
+	}
+}
+
+func TestExportPlan_RoundTrip(t *testing.T) {
+	epic := boardItem(1, "Epic 1", "Epic body\n\n- [ ] #3\n- [ ] #2\n\n"+marker("epic-1"), "Todo",
+		map[string]string{"Priority": "P1", "Estimate": "3"})
+	epic.Issue.Milestone = "Phase 1"
+	epic.Issue.Labels = []string{"backend"}
+	epic.Issue.Assignees = []string{"dev1"}
+	epic.MilestoneDescription = "First phase"
+	epic.MilestoneDueOn = "2026-04-01"
+
+	child1 := boardItem(2, "Child 1", "Child body", "Todo", map[string]string{"Priority": "P1", "Estimate": "3"})
+	child1.Issue.Milestone = "Phase 1"
+	child1.Issue.Labels = []string{"database"}
+	child1.Issue.Assignees = []string{"dev1"}
+
+	child2 := boardItem(3, "Child 2", "Second\n\nBlocked by #2", "Done", map[string]string{"Priority": "P2", "Estimate": "3"})
+	child2.Issue.Milestone = "Phase 1"
+	child2.Issue.Assignees = []string{"dev2", "dev1"}
+
+	other := boardItem(4, "Elsewhere", "", "Todo", nil)
+	other.Repository = "owner/other"
+
+	mock := newMockClient()
+	mock.boardItems = []ghclient.ProjectV2Item{child2, epic, child1, other}
+	mock.projectFields = []ghclient.ProjectV2Field{
+		{ID: "title-field", Name: "Title", DataType: "TITLE"},
+		{ID: "status-field-id", Name: "Status", DataType: ghclient.FieldTypeSingleSelect, Options: map[string]string{"Todo": "todo-option-id", "Done": "done-option-id"}},
+		{ID: "priority-field", Name: "Priority", DataType: ghclient.FieldTypeSingleSelect, Options: map[string]string{"P1": "p1", "P2": "p2"}},
+		{ID: "estimate-field", Name: "Estimate", DataType: ghclient.FieldTypeNumber},
+	}
+
+	plan, warnings, err := ExportPlan(context.Background(), mock, "owner/repo", "Test Project")
+	if err != nil {
+		t.Fatalf("ExportPlan failed: %v", err)
+	}
+	if len(warnings) != 1 || warnings[0] != "skipped 1 issues from other repositories" {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if plan.Linking != "" {
+		t.Errorf("expected the default tasklist linking, got %q", plan.Linking)
+	}
+	if plan.Host != ghclient.DefaultHost {
+		t.Errorf("expected the plan to target the client's host, got %q", plan.Host)
+	}
+	if len(plan.Milestones) != 1 || plan.Milestones[0] != (types.Milestone{Title: "Phase 1", DueOn: "2026-04-01", Description: "First phase"}) {
+		t.Errorf("unexpected milestones: %+v", plan.Milestones)
+	}
+	if len(plan.Epics) != 1 {
+		t.Fatalf("expected 1 epic, got %+v", plan.Epics)
+	}
+	got := plan.Epics[0]
+	if got.ID != "epic-1" || got.Body != "Epic body" || got.Status != "Todo" || got.Fields["Estimate"] != 3 || len(got.Fields) != 2 {
+		t.Errorf("unexpected epic: %+v", got)
+	}
+	if len(got.Children) != 2 || got.Children[0].Title != "Child 2" || got.Children[1].Title != "Child 1" {
+		t.Fatalf("expected children in tasklist order, got %+v", got.Children)
+	}
+	inheriting := got.Children[1]
+	if inheriting.Milestone != "" || inheriting.Status != "" || inheriting.Assignees != nil || inheriting.Fields != nil {
+		t.Errorf("expected Child 1 to inherit everything, got %+v", inheriting)
+	}
+	own := got.Children[0]
+	if own.Status != "Done" || len(own.Fields) != 1 || own.Fields["Priority"] != "P2" || len(own.DependsOn) != 1 || own.DependsOn[0] != "Child 1" {
+		t.Errorf("unexpected Child 2: %+v", own)
+	}
+
+	// Applying the export to the same board changes nothing
+	mock.issues = make(map[int]*ghclient.IssueDetails)
+	existing := make(map[string]int)
+	for _, item := range mock.boardItems {
+		issue := item.Issue
+		mock.issues[issue.Number] = &issue
+		existing[issue.Title] = issue.Number
+		mock.listedIssues = append(mock.listedIssues, &gogithub.Issue{Number: gogithub.Int(issue.Number), NodeID: gogithub.String(issue.ID), Body: gogithub.String(issue.Body)})
+	}
+	mock.milestones = []*gogithub.Milestone{{
+		Number:      gogithub.Int(1),
+		Title:       gogithub.String("Phase 1"),
+		Description: gogithub.String("First phase"),
+		DueOn:       &gogithub.Timestamp{Time: time.Date(2026, 4, 1, 7, 0, 0, 0, time.UTC)},
+	}}
+	client := &idempotentMockClient{mockClient: mock, existingIssues: existing}
+	cs, err := ComputeChangeSet(context.Background(), client, plan, Options{Reconcile: true})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+	if cs.HasChanges() || cs.Count(ActionDrift) > 0 {
+		t.Errorf("expected the exported plan to round-trip, got %+v", cs.Changes)
+	}
+}
+
+func TestExportPlan_SubIssues(t *testing.T) {
+	parent := boardItem(10, "Parent", "Parent body", "Todo", map[string]string{"Priority": "P1"})
+	inherits := boardItem(11, "Inherits", "", "Todo", map[string]string{"Priority": "P1"})
+	inherits.Issue.ParentNumber = 10
+	unset := boardItem(12, "Unset", "", "", nil)
+	unset.Issue.ParentNumber = 10
+	parent.Issue.Assignees = []string{"dev1"}
+	inherits.Issue.Assignees = []string{"dev1"}
+
+	mock := newMockClient()
+	mock.dependencies = true
+	mock.blockedBy = map[int][]int{12: {11, 99}}
+	mock.boardItems = []ghclient.ProjectV2Item{parent, inherits, unset}
+	mock.projectFields = []ghclient.ProjectV2Field{
+		{ID: "priority-field", Name: "Priority", DataType: ghclient.FieldTypeSingleSelect, Options: map[string]string{"P1": "p1"}},
+	}
+
+	plan, warnings, err := ExportPlan(context.Background(), mock, "owner/repo", "Test Project")
+	if err != nil {
+		t.Fatalf("ExportPlan failed: %v", err)
+	}
+	if plan.Linking != types.LinkSubIssues {
+		t.Errorf("expected sub_issues linking, got %q", plan.Linking)
+	}
+	if len(plan.Epics) != 1 || len(plan.Epics[0].Children) != 2 {
+		t.Fatalf("expected one epic with two children, got %+v", plan.Epics)
+	}
+	if deps := plan.Epics[0].Children[1].DependsOn; len(deps) != 1 || deps[0] != "Inherits" {
+		t.Errorf("expected Unset to depend on Inherits, got %v", deps)
+	}
+	if a := plan.Epics[0].Children[0].Assignees; a != nil {
+		t.Errorf("expected Inherits to inherit its assignees, got %v", *a)
+	}
+	if a := plan.Epics[0].Children[1].Assignees; a == nil || len(*a) != 0 {
+		t.Errorf("expected Unset to have an explicit empty assignee list, got %v", a)
+	}
+	want := []string{
+		"#12 Unset: has no status but would inherit one from #10",
+		`#12 Unset: has no value for field "Priority" but would inherit one from #10`,
+		"#12 Unset: blocked by #99, which is not on the board",
+	}
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected warnings %q, got %q", want, warnings)
+	}
+}
@@ -0,0 +1,382 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ghclient "github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	"github.com/shurcooL/githubv4"
+)
+
+// resolvedField is a plan field value converted for a specific Project V2 field.
+// Display is the value as GetIssue reports it, used for comparisons.
+type resolvedField struct {
+	Field   ghclient.ProjectV2Field
+	Value   githubv4.ProjectV2FieldValue
+	Display string
+}
+
+// projectFields is the set of custom fields on the project. Iteration names the
+// field set by the iteration key, or iterationErr says why there is none.
+type projectFields struct {
+	byName       map[string]ghclient.ProjectV2Field
+	iteration    string
+	iterationErr error
+}
+
+// now returns the current time when resolving relative iterations; tests replace it.
+var now = time.Now
+
+// relativeIteration matches iteration references like "+2".
+var relativeIteration = regexp.MustCompile(`^\+(\d+)$`)
+
+// planUsesFields reports whether any epic or child issue sets custom fields or an iteration.
+func planUsesFields(nodes []*planNode) bool {
+	for _, n := range nodes {
+		if len(n.issue.Fields) > 0 || n.issue.Iteration != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// loadFields reads the project's fields when the plan sets any and checks every
+// value in the plan against them, so bad values fail before anything is changed.
+// It returns nil when the plan sets no fields.
+func loadFields(ctx context.Context, client GitHubClient, projectID, iterationField string, nodes []*planNode) (*projectFields, error) {
+	if !planUsesFields(nodes) {
+		return nil, nil
+	}
+
+	list, err := client.GetProjectV2Fields(ctx, githubv4.ID(projectID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project fields: %w", err)
+	}
+	fields := &projectFields{byName: make(map[string]ghclient.ProjectV2Field)}
+	var iterationFields []string
+	for _, f := range list {
+		fields.byName[f.Name] = f
+		if f.DataType == ghclient.FieldTypeIteration {
+			iterationFields = append(iterationFields, f.Name)
+		}
+	}
+	switch {
+	case iterationField != "":
+		if f, ok := fields.byName[iterationField]; !ok || f.DataType != ghclient.FieldTypeIteration {
+			return nil, fmt.Errorf("iteration_field %q is not an iteration field on the project", iterationField)
+		}
+		fields.iteration = iterationField
+	case len(iterationFields) == 1:
+		fields.iteration = iterationFields[0]
+	case len(iterationFields) == 0:
+		fields.iterationErr = fmt.Errorf("project has no iteration field")
+	default:
+		fields.iterationErr = fmt.Errorf("project has several iteration fields (%s); set iteration_field in the plan", strings.Join(iterationFields, ", "))
+	}
+
+	var errs []string
+	for _, n := range nodes {
+		if _, err := resolveFields(n.base, fields); err != nil {
+			errs = append(errs, fmt.Sprintf("%s %q: %v", n.kind, n.issue.Title, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid project fields:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return fields, nil
+}
+
+// resolveFields converts the field values and iteration of spec for the
+// project's fields, in field name order.
+func resolveFields(spec issueSpec, fields *projectFields) ([]resolvedField, error) {
+	values := spec.Fields
+	if spec.Iteration != "" {
+		if fields.iterationErr != nil {
+			return nil, fields.iterationErr
+		}
+		if _, ok := values[fields.iteration]; ok {
+			return nil, fmt.Errorf("iteration and fields both set %q", fields.iteration)
+		}
+		values = make(types.Fields, len(spec.Fields)+1)
+		for name, v := range spec.Fields {
+			values[name] = v
+		}
+		values[fields.iteration] = spec.Iteration
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var resolved []resolvedField
+	for _, name := range names {
+		f, ok := fields.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("project has no field named %q", name)
+		}
+		r, err := fieldValue(f, values[name])
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, r)
+	}
+	return resolved, nil
+}
+
+// fieldValue converts a single plan value according to the field's data type.
+func fieldValue(f ghclient.ProjectV2Field, raw interface{}) (resolvedField, error) {
+	r := resolvedField{Field: f}
+	switch f.DataType {
+	case ghclient.FieldTypeText:
+		text, ok := scalarString(raw)
+		if !ok {
+			return r, fmt.Errorf("field %q is a text field, got %v", f.Name, raw)
+		}
+		r.Value.Text = githubv4.NewString(githubv4.String(text))
+		r.Display = text
+
+	case ghclient.FieldTypeNumber:
+		var n float64
+		switch v := raw.(type) {
+		case int:
+			n = float64(v)
+		case float64:
+			n = v
+		case string:
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return r, fmt.Errorf("field %q is a number field, got %q", f.Name, v)
+			}
+			n = parsed
+		default:
+			return r, fmt.Errorf("field %q is a number field, got %v", f.Name, raw)
+		}
+		r.Value.Number = githubv4.NewFloat(githubv4.Float(n))
+		r.Display = strconv.FormatFloat(n, 'f', -1, 64)
+
+	case ghclient.FieldTypeDate:
+		date, err := parseFieldDate(raw)
+		if err != nil {
+			return r, fmt.Errorf("field %q is a date field: %w", f.Name, err)
+		}
+		r.Value.Date = githubv4.NewDate(githubv4.Date{Time: date})
+		r.Display = date.Format("2006-01-02")
+
+	case ghclient.FieldTypeSingleSelect:
+		name, ok := raw.(string)
+		if !ok {
+			return r, fmt.Errorf("field %q is a single-select field, got %v", f.Name, raw)
+		}
+		optionID, ok := f.Options[name]
+		if !ok {
+			options := make([]string, 0, len(f.Options))
+			for option := range f.Options {
+				options = append(options, option)
+			}
+			sort.Strings(options)
+			return r, fmt.Errorf("field %q has no option %q (options: %s)", f.Name, name, strings.Join(options, ", "))
+		}
+		r.Value.SingleSelectOptionID = githubv4.NewString(githubv4.String(optionID))
+		r.Display = name
+
+	case ghclient.FieldTypeIteration:
+		ref, ok := raw.(string)
+		if !ok {
+			return r, fmt.Errorf("field %q is an iteration field, got %v", f.Name, raw)
+		}
+		it, err := resolveIteration(f, ref, now())
+		if err != nil {
+			return r, err
+		}
+		r.Value.IterationID = githubv4.NewString(githubv4.String(it.ID))
+		r.Display = it.Title
+
+	default:
+		return r, fmt.Errorf("field %q of type %s cannot be set from the plan", f.Name, f.DataType)
+	}
+	return r, nil
+}
+
+// resolveIteration finds the iteration that ref names: an iteration title, or
+// "current", "next" or "+N" counted from the iteration in progress on today.
+func resolveIteration(f ghclient.ProjectV2Field, ref string, today time.Time) (ghclient.ProjectV2Iteration, error) {
+	offset := -1
+	switch ref {
+	case "current":
+		offset = 0
+	case "next":
+		offset = 1
+	default:
+		if m := relativeIteration.FindStringSubmatch(ref); m != nil {
+			offset, _ = strconv.Atoi(m[1])
+		}
+	}
+	if offset < 0 {
+		var titles []string
+		for _, it := range f.Iterations {
+			if it.Title == ref {
+				return it, nil
+			}
+			titles = append(titles, it.Title)
+		}
+		return ghclient.ProjectV2Iteration{}, fmt.Errorf("field %q has no iteration %q (iterations: %s)", f.Name, ref, strings.Join(titles, ", "))
+	}
+
+	var active []ghclient.ProjectV2Iteration
+	for _, it := range f.Iterations {
+		if !it.Completed {
+			active = append(active, it)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool { return active[i].StartDate < active[j].StartDate })
+
+	// Dates are YYYY-MM-DD, so they compare as strings
+	date := today.Format("2006-01-02")
+	current, next := -1, len(active)
+	for i, it := range active {
+		start, err := time.Parse("2006-01-02", it.StartDate)
+		if err != nil {
+			continue
+		}
+		end := start.AddDate(0, 0, it.Duration).Format("2006-01-02")
+		if it.StartDate <= date && date < end {
+			current = i
+		}
+		if it.StartDate > date && next == len(active) {
+			next = i
+		}
+	}
+
+	base := current
+	if current < 0 {
+		if offset == 0 {
+			return ghclient.ProjectV2Iteration{}, fmt.Errorf("field %q has no iteration in progress on %s", f.Name, date)
+		}
+		base = next - 1
+	}
+	if base+offset >= len(active) {
+		return ghclient.ProjectV2Iteration{}, fmt.Errorf("field %q has no iteration %q on %s: only %d later iterations are planned", f.Name, ref, date, len(active)-base-1)
+	}
+	return active[base+offset], nil
+}
+
+// scalarString formats a string or number plan value as text.
+func scalarString(raw interface{}) (string, bool) {
+	switch v := raw.(type) {
+	case string:
+		return v, true
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// parseFieldDate accepts a YAML date, a YYYY-MM-DD string, or an RFC 3339
+// timestamp (as a YAML date becomes after a JSON round-trip).
+func parseFieldDate(raw interface{}) (time.Time, error) {
+	switch v := raw.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, nil
+		}
+		return time.Time{}, fmt.Errorf("%q is not a YYYY-MM-DD date", v)
+	}
+	return time.Time{}, fmt.Errorf("%v is not a YYYY-MM-DD date", raw)
+}
+
+// fieldDiffs compares resolved field values with an item's current values, which
+// are nil when the item is not on the project yet.
+func fieldDiffs(resolved []resolvedField, current map[string]string) []FieldDiff {
+	var diffs []FieldDiff
+	for _, r := range resolved {
+		diffs = append(diffs, diffString("fields."+r.Field.Name, current[r.Field.Name], r.Display)...)
+	}
+	return diffs
+}
+
+// updateFields sets the custom fields of a project item from spec in one batch.
+// Only values that differ from current are written; a nil current writes every
+// value.
+func (a *applier) updateFields(ctx context.Context, itemID githubv4.ID, spec issueSpec, current map[string]string) ([]FieldDiff, error) {
+	updates, err := a.fieldUpdates(itemID, spec, current)
+	if err != nil {
+		return nil, err
+	}
+	if len(updates) == 0 {
+		return nil, nil
+	}
+
+	inputs := make([]githubv4.UpdateProjectV2ItemFieldValueInput, len(updates))
+	diffs := make([]FieldDiff, len(updates))
+	for i, u := range updates {
+		inputs[i] = u.input
+		diffs[i] = u.diff
+	}
+	if err := a.client.UpdateProjectV2ItemFieldValues(ctx, inputs); err != nil {
+		return nil, fieldUpdateError(updates, err)
+	}
+	return diffs, nil
+}
+
+// fieldUpdate is the write of one custom field of a project item.
+type fieldUpdate struct {
+	field string
+	input githubv4.UpdateProjectV2ItemFieldValueInput
+	diff  FieldDiff
+}
+
+// fieldUpdates returns the writes that set the custom fields of a project item
+// from spec, skipping values that already match current.
+func (a *applier) fieldUpdates(itemID githubv4.ID, spec issueSpec, current map[string]string) ([]fieldUpdate, error) {
+	resolved, err := resolveFields(spec, a.fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []fieldUpdate
+	for _, r := range resolved {
+		if current != nil && current[r.Field.Name] == r.Display {
+			continue
+		}
+		updates = append(updates, fieldUpdate{
+			field: r.Field.Name,
+			input: githubv4.UpdateProjectV2ItemFieldValueInput{
+				ProjectID: githubv4.ID(a.projectID),
+				ItemID:    itemID,
+				FieldID:   githubv4.ID(r.Field.ID),
+				Value:     r.Value,
+			},
+			diff: FieldDiff{Field: "fields." + r.Field.Name, Current: current[r.Field.Name], Desired: r.Display},
+		})
+	}
+	return updates, nil
+}
+
+// fieldUpdateError names the first field of a failed batch of updates.
+func fieldUpdateError(updates []fieldUpdate, err error) error {
+	var batchErr *ghclient.BatchError
+	if errors.As(err, &batchErr) {
+		i, first := batchErr.First()
+		return fmt.Errorf("failed to set field %q: %w", updates[i].field, first)
+	}
+	return fmt.Errorf("failed to set fields: %w", err)
+}
@@ -0,0 +1,248 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	ghclient "github.com/goblinsan/gh-project-helper/pkg/github"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+)
+
+func testProjectFields() []ghclient.ProjectV2Field {
+	return []ghclient.ProjectV2Field{
+		{ID: "field-priority", Name: "Priority", DataType: ghclient.FieldTypeSingleSelect, Options: map[string]string{"P0": "opt-p0", "P1": "opt-p1"}},
+		{ID: "field-estimate", Name: "Estimate", DataType: ghclient.FieldTypeNumber},
+		{ID: "field-target", Name: "Target Date", DataType: ghclient.FieldTypeDate},
+		{ID: "field-notes", Name: "Notes", DataType: ghclient.FieldTypeText},
+		{ID: "field-sprint", Name: "Sprint", DataType: ghclient.FieldTypeIteration, Iterations: []ghclient.ProjectV2Iteration{{ID: "it-14", Title: "Sprint 14"}}},
+		{ID: "field-assignees", Name: "Assignees", DataType: "ASSIGNEES"},
+	}
+}
+
+func testFieldIndex() *projectFields {
+	fields := &projectFields{byName: make(map[string]ghclient.ProjectV2Field), iteration: "Sprint"}
+	for _, f := range testProjectFields() {
+		fields.byName[f.Name] = f
+	}
+	return fields
+}
+
+func TestResolveFields(t *testing.T) {
+	fields := testFieldIndex()
+
+	resolved, err := resolveFields(issueSpec{Fields: types.Fields{
+		"Priority":    "P1",
+		"Estimate":    3,
+		"Target Date": time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		"Notes":       "See design doc",
+		"Sprint":      "Sprint 14",
+	}}, fields)
+	if err != nil {
+		t.Fatalf("resolveFields failed: %v", err)
+	}
+	want := map[string]string{
+		"Priority":    "P1",
+		"Estimate":    "3",
+		"Target Date": "2026-04-01",
+		"Notes":       "See design doc",
+		"Sprint":      "Sprint 14",
+	}
+	for _, r := range resolved {
+		if r.Display != want[r.Field.Name] {
+			t.Errorf("%s: expected %q, got %q", r.Field.Name, want[r.Field.Name], r.Display)
+		}
+	}
+	if *resolved[2].Value.SingleSelectOptionID != "opt-p1" {
+		t.Errorf("expected Priority option id opt-p1, got %v", *resolved[2].Value.SingleSelectOptionID)
+	}
+
+	tests := []struct {
+		values types.Fields
+		err    string
+	}{
+		{types.Fields{"Size": "L"}, `project has no field named "Size"`},
+		{types.Fields{"Priority": "P9"}, `field "Priority" has no option "P9" (options: P0, P1)`},
+		{types.Fields{"Estimate": "lots"}, `field "Estimate" is a number field, got "lots"`},
+		{types.Fields{"Target Date": "next week"}, `field "Target Date" is a date field: "next week" is not a YYYY-MM-DD date`},
+		{types.Fields{"Sprint": "Sprint 99"}, `field "Sprint" has no iteration "Sprint 99" (iterations: Sprint 14)`},
+		{types.Fields{"Assignees": "dev1"}, `field "Assignees" of type ASSIGNEES cannot be set from the plan`},
+	}
+	for _, tt := range tests {
+		_, err := resolveFields(issueSpec{Fields: tt.values}, fields)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("expected error %q, got %v", tt.err, err)
+		}
+	}
+}
+
+func TestResolveIteration(t *testing.T) {
+	f := ghclient.ProjectV2Field{Name: "Sprint", DataType: ghclient.FieldTypeIteration, Iterations: []ghclient.ProjectV2Iteration{
+		{ID: "it-12", Title: "Sprint 12", StartDate: "2026-09-21", Duration: 14, Completed: true},
+		{ID: "it-14", Title: "Sprint 14", StartDate: "2026-10-19", Duration: 14},
+		{ID: "it-13", Title: "Sprint 13", StartDate: "2026-10-05", Duration: 14},
+		{ID: "it-15", Title: "Sprint 15", StartDate: "2026-11-02", Duration: 14},
+	}}
+	inSprint13 := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	betweenSprints := time.Date(2026, 11, 20, 12, 0, 0, 0, time.UTC)
+	beforeSprints := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		ref   string
+		today time.Time
+		want  string
+		err   string
+	}{
+		{"Sprint 12", inSprint13, "Sprint 12", ""},
+		{"current", inSprint13, "Sprint 13", ""},
+		{"next", inSprint13, "Sprint 14", ""},
+		{"+2", inSprint13, "Sprint 15", ""},
+		{"+0", inSprint13, "Sprint 13", ""},
+		{"next", beforeSprints, "Sprint 13", ""},
+		{"+3", inSprint13, "", `field "Sprint" has no iteration "+3" on 2026-10-16: only 2 later iterations are planned`},
+		{"current", betweenSprints, "", `field "Sprint" has no iteration in progress on 2026-11-20`},
+		{"Sprint 99", inSprint13, "", `field "Sprint" has no iteration "Sprint 99" (iterations: Sprint 12, Sprint 14, Sprint 13, Sprint 15)`},
+	}
+	for _, tt := range tests {
+		it, err := resolveIteration(f, tt.ref, tt.today)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: expected error %q, got %v", tt.ref, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.ref, err)
+			continue
+		}
+		if it.Title != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.ref, tt.want, it.Title)
+		}
+	}
+}
+
+func TestApplyPlan_Iteration(t *testing.T) {
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics: []types.Epic{
+			{Title: "Epic 1", Iteration: "next"},
+		},
+	}
+
+	mock := newMockClient()
+	mock.projectFields = []ghclient.ProjectV2Field{
+		{ID: "field-sprint", Name: "Sprint", DataType: ghclient.FieldTypeIteration, Iterations: []ghclient.ProjectV2Iteration{
+			{ID: "it-13", Title: "Sprint 13", StartDate: "2026-10-05", Duration: 14},
+			{ID: "it-14", Title: "Sprint 14", StartDate: "2026-10-19", Duration: 14},
+		}},
+	}
+	cs, err := ComputeChangeSet(context.Background(), mock, plan, Options{})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+	if len(cs.Changes) != 1 || cs.Changes[0].Action != ActionCreate {
+		t.Fatalf("unexpected change set: %+v", cs.Changes)
+	}
+	if _, err := ApplyPlan(context.Background(), mock, plan, Options{}); err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	if len(mock.fieldUpdates) != 1 || mock.fieldUpdates[0] != "project-item-issue-id-Epic 1 field-sprint" {
+		t.Errorf("expected the sprint field to be set, got %v", mock.fieldUpdates)
+	}
+
+	// A second iteration field makes the iteration key ambiguous
+	mock.projectFields = append(mock.projectFields, ghclient.ProjectV2Field{ID: "field-release", Name: "Release", DataType: ghclient.FieldTypeIteration})
+	_, err = ApplyPlan(context.Background(), mock, plan, Options{})
+	if err == nil || !strings.Contains(err.Error(), "set iteration_field in the plan") {
+		t.Errorf("expected ambiguous iteration field error, got %v", err)
+	}
+	plan.IterationField = "Sprint"
+	if _, err := ApplyPlan(context.Background(), mock, plan, Options{}); err != nil {
+		t.Errorf("ApplyPlan with iteration_field failed: %v", err)
+	}
+}
+
+func TestApplyPlan_Fields(t *testing.T) {
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics: []types.Epic{
+			{
+				Title:  "Epic 1",
+				Fields: types.Fields{"Priority": "P0"},
+				Children: []types.Issue{
+					{Title: "Child 1", Fields: types.Fields{"Estimate": 5, "Notes": "Needs review"}},
+				},
+			},
+		},
+	}
+
+	mock := newMockClient()
+	mock.projectFields = testProjectFields()
+	if _, err := ApplyPlan(context.Background(), mock, plan, Options{}); err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	want := []string{
+		"project-item-issue-id-Child 1 field-estimate",
+		"project-item-issue-id-Child 1 field-notes",
+		"project-item-issue-id-Child 1 field-priority", // inherited from the epic
+		"project-item-issue-id-Epic 1 field-priority",
+	}
+	if strings.Join(mock.fieldUpdates, "|") != strings.Join(want, "|") {
+		t.Errorf("expected field updates %v, got %v", want, mock.fieldUpdates)
+	}
+
+	// Bad values fail before anything is created
+	plan.Epics[0].Children[0].Fields["Estimate"] = "lots"
+	mock = newMockClient()
+	mock.projectFields = testProjectFields()
+	_, err := ApplyPlan(context.Background(), mock, plan, Options{})
+	if err == nil || !strings.Contains(err.Error(), `issue "Child 1": field "Estimate" is a number field`) {
+		t.Fatalf("expected field validation error, got %v", err)
+	}
+	if len(mock.createdIssues) != 0 {
+		t.Errorf("expected no issues to be created, got %v", mock.createdIssues)
+	}
+}
+
+func TestApplyPlan_ReconcileFields(t *testing.T) {
+	mock := newMockClient()
+	mock.projectFields = testProjectFields()
+	mock.issues = map[int]*ghclient.IssueDetails{
+		99: {
+			ID:     "existing-node-Epic 1",
+			Number: 99,
+			Title:  "Epic 1",
+			Body:   "\n\n",
+			ProjectItems: []ghclient.ProjectItemRef{{
+				ID:        "item-99",
+				ProjectID: "project-node-id",
+				Fields:    map[string]string{"Priority": "P1", "Estimate": "8"},
+			}},
+		},
+	}
+	client := &idempotentMockClient{mockClient: mock, existingIssues: map[string]int{"Epic 1": 99}}
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Epics: []types.Epic{
+			{Title: "Epic 1", Fields: types.Fields{"Priority": "P0", "Estimate": 8}},
+		},
+	}
+
+	report, err := ApplyPlan(context.Background(), client, plan, Options{Reconcile: true})
+	if err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	if len(mock.fieldUpdates) != 1 || mock.fieldUpdates[0] != "item-99 field-priority" {
+		t.Errorf("expected only Priority to be updated, got %v", mock.fieldUpdates)
+	}
+	if len(report.Updates) != 1 || report.Updates[0].Field != "fields.Priority" || report.Updates[0].From != "P1" {
+		t.Errorf("unexpected updates: %+v", report.Updates)
+	}
+}
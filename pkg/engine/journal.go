@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/goblinsan/gh-project-helper/pkg/state"
+)
+
+// step runs one mutation of the plan element with state key key and journals it
+// once it succeeds. If the journal shows an interrupted apply already completed
+// it, fn is skipped, the journaled step is returned and the report notes it as
+// resumed.
+func (a *applier) step(key, title, name string, fn func() (state.Step, error)) (state.Step, error) {
+	j := a.opts.Journal
+	if j == nil {
+		return fn()
+	}
+	if done, ok := j.Step(key, name); ok {
+		a.report.Resumed = append(a.report.Resumed, fmt.Sprintf("%s: %s", title, name))
+		return done, nil
+	}
+
+	step, err := fn()
+	if err != nil {
+		return step, err
+	}
+	step.Key = key
+	step.Name = name
+	if err := j.Record(step); err != nil {
+		return step, err
+	}
+	return step, nil
+}
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	gogithub "github.com/google/go-github/v66/github"
+)
+
+// findLabel returns the existing label with the name of l, or failing that the
+// first one with one of its aliases. Names are compared case-insensitively, as
+// GitHub does.
+func findLabel(l types.Label, existing []*gogithub.Label) *gogithub.Label {
+	for _, name := range append([]string{l.Name}, l.Aliases...) {
+		for _, el := range existing {
+			if strings.EqualFold(el.GetName(), name) {
+				return el
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeColor returns a label color the way GitHub stores it.
+func normalizeColor(color string) string {
+	return strings.ToLower(strings.TrimPrefix(color, "#"))
+}
+
+// labelDiffs compares an existing label with its declaration. An empty color or
+// description in the plan leaves the existing one alone.
+func labelDiffs(l types.Label, current *gogithub.Label) []FieldDiff {
+	diffs := diffString("name", current.GetName(), l.Name)
+	if l.Color != "" {
+		diffs = append(diffs, diffString("color", current.GetColor(), normalizeColor(l.Color))...)
+	}
+	if l.Description != "" {
+		diffs = append(diffs, diffString("description", current.GetDescription(), l.Description)...)
+	}
+	return diffs
+}
+
+func labelChange(l types.Label, existing []*gogithub.Label) Change {
+	change := Change{Kind: KindLabel, Title: l.Name}
+	current := findLabel(l, existing)
+	if current == nil {
+		change.Action = ActionCreate
+		return change
+	}
+	change.Diffs = labelDiffs(l, current)
+	change.resolveAction()
+	return change
+}
+
+// syncLabels creates, renames and updates the repository labels to match their
+// declarations, and records their node IDs for the issues that use them.
+func (a *applier) syncLabels(ctx context.Context, labels []types.Label) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	existing, err := a.client.ListLabels(ctx, a.owner, a.repo)
+	if err != nil {
+		return fmt.Errorf("failed to list labels: %w", err)
+	}
+
+	for _, l := range labels {
+		edit := &gogithub.Label{Name: gogithub.String(l.Name)}
+		if l.Color != "" {
+			edit.Color = gogithub.String(normalizeColor(l.Color))
+		}
+		if l.Description != "" {
+			edit.Description = gogithub.String(l.Description)
+		}
+
+		current := findLabel(l, existing)
+		var diffs []FieldDiff
+		if current != nil {
+			diffs = labelDiffs(l, current)
+		}
+		switch {
+		case current == nil:
+			current, err = a.client.CreateLabel(ctx, a.owner, a.repo, edit)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created label: %s\n", l.Name)
+			a.report.LabelsCreated++
+		case len(diffs) > 0:
+			current, err = a.client.EditLabel(ctx, a.owner, a.repo, current.GetName(), edit)
+			if err != nil {
+				return err
+			}
+			for _, d := range diffs {
+				fmt.Printf("Updated label %s: %s %q -> %q\n", l.Name, d.Field, d.Current, d.Desired)
+			}
+			a.report.LabelsUpdated++
+		}
+		a.state.SetLabel(l.Name, current.GetNodeID())
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	gogithub "github.com/google/go-github/v66/github"
+)
+
+func labelPlan() types.Plan {
+	return types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Labels: []types.Label{
+			{Name: "bug", Color: "#D73A4A"},
+			{Name: "ui", Color: "1d76db", Description: "User interface", Aliases: []string{"frontend", "old-ui"}},
+			{Name: "docs", Color: "0075ca"},
+			{Name: "new", Color: "00ff00", Description: "Brand new"},
+		},
+		Epics: []types.Epic{
+			{Title: "Epic 1", Labels: []string{"ui", "other"}},
+		},
+	}
+}
+
+func labelMock() *mockClient {
+	mock := newMockClient()
+	mock.labels = []*gogithub.Label{
+		{Name: gogithub.String("bug"), Color: gogithub.String("ededed"), NodeID: gogithub.String("label-bug")},
+		{Name: gogithub.String("Old-UI"), Color: gogithub.String("1d76db"), NodeID: gogithub.String("label-old-ui")},
+		{Name: gogithub.String("docs"), Color: gogithub.String("0075ca"), Description: gogithub.String("Documentation"), NodeID: gogithub.String("label-docs")},
+	}
+	return mock
+}
+
+func TestApplyPlan_SyncsLabels(t *testing.T) {
+	mock := labelMock()
+	report, err := ApplyPlan(context.Background(), mock, labelPlan(), Options{})
+	if err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+
+	expected := []string{
+		`edit bug -> bug d73a4a ""`,
+		`edit Old-UI -> ui 1d76db "User interface"`,
+		`create new 00ff00 "Brand new"`,
+	}
+	if fmt.Sprint(mock.labelEdits) != fmt.Sprint(expected) {
+		t.Errorf("unexpected label changes:\n got %v\nwant %v", mock.labelEdits, expected)
+	}
+	if report.LabelsCreated != 1 || report.LabelsUpdated != 2 {
+		t.Errorf("expected 1 label created and 2 updated, got %d and %d", report.LabelsCreated, report.LabelsUpdated)
+	}
+	// The renamed label is already resolved; only the undeclared one is looked up
+	if fmt.Sprint(mock.labelRequests) != "[other]" {
+		t.Errorf("expected only the undeclared label to be looked up, got %v", mock.labelRequests)
+	}
+}
+
+func TestComputeChangeSet_Labels(t *testing.T) {
+	cs, err := ComputeChangeSet(context.Background(), labelMock(), labelPlan(), Options{})
+	if err != nil {
+		t.Fatalf("ComputeChangeSet failed: %v", err)
+	}
+
+	actions := make(map[string]Change)
+	for _, c := range cs.Changes {
+		if c.Kind == KindLabel {
+			actions[c.Title] = c
+		}
+	}
+	if actions["bug"].Action != ActionUpdate || fmt.Sprint(actions["bug"].Diffs) != "[{color ededed d73a4a}]" {
+		t.Errorf("unexpected change for bug: %+v", actions["bug"])
+	}
+	if actions["ui"].Action != ActionUpdate || actions["ui"].Diffs[0] != (FieldDiff{Field: "name", Current: "Old-UI", Desired: "ui"}) {
+		t.Errorf("expected ui to be renamed, got %+v", actions["ui"])
+	}
+	if actions["docs"].Action != ActionUnchanged {
+		t.Errorf("expected docs unchanged, got %+v", actions["docs"])
+	}
+	if actions["new"].Action != ActionCreate {
+		t.Errorf("expected new to be created, got %+v", actions["new"])
+	}
+}
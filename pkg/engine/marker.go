@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/goblinsan/gh-project-helper/pkg/state"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	gogithub "github.com/google/go-github/v66/github"
+)
+
+// markerPattern matches the hidden HTML comment that carries a plan ID.
+var markerPattern = regexp.MustCompile(`<!-- gh-project-helper:id=([^ ]+) -->`)
+
+// marker returns the hidden HTML comment embedded in bodies for the given plan ID.
+func marker(id string) string {
+	return fmt.Sprintf("<!-- gh-project-helper:id=%s -->", id)
+}
+
+// withMarker appends the marker for id to body. Bodies without an ID are returned unchanged.
+func withMarker(body, id string) string {
+	if id == "" {
+		return body
+	}
+	if body == "" {
+		return marker(id)
+	}
+	return body + "\n\n" + marker(id)
+}
+
+// parseMarker returns the plan ID embedded in body, or "" if there is none.
+func parseMarker(body string) string {
+	m := markerPattern.FindStringSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// planUsesIDs reports whether any epic or child issue in the plan has an ID.
+func planUsesIDs(plan types.Plan) bool {
+	uses := false
+	for _, epic := range plan.Epics {
+		uses = uses || epic.ID != ""
+		forEachIssue(epic.Children, func(issue types.Issue) {
+			uses = uses || issue.ID != ""
+		})
+	}
+	return uses
+}
+
+// issueFinder locates existing issues by recorded state first, then by plan ID
+// marker, and finally by title.
+type issueFinder struct {
+	client GitHubClient
+	owner  string
+	repo   string
+	state  *state.State
+	byID   map[string]*gogithub.Issue
+}
+
+// newIssueFinder builds the marker index when the plan uses IDs. Listing every
+// issue avoids the search index, which lags behind and only sees open issues.
+// The state may be nil.
+func newIssueFinder(ctx context.Context, client GitHubClient, owner, repo string, plan types.Plan, st *state.State) (*issueFinder, error) {
+	f := &issueFinder{client: client, owner: owner, repo: repo, state: st}
+	if !planUsesIDs(plan) {
+		return f, nil
+	}
+
+	issues, err := client.ListIssues(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues: %w", err)
+	}
+	f.byID = make(map[string]*gogithub.Issue)
+	for _, issue := range issues {
+		if id := parseMarker(issue.GetBody()); id != "" {
+			f.byID[id] = issue
+		}
+	}
+	return f, nil
+}
+
+// find returns the number and node ID of the existing issue for spec, or 0/"" if there is none.
+func (f *issueFinder) find(ctx context.Context, spec issueSpec) (int, string, error) {
+	if f.state != nil {
+		if recorded, ok := f.state.Issue(state.Key(spec.ID, spec.Title)); ok {
+			return recorded.Number, recorded.NodeID, nil
+		}
+	}
+	if spec.ID != "" {
+		if issue, ok := f.byID[spec.ID]; ok {
+			return issue.GetNumber(), issue.GetNodeID(), nil
+		}
+	}
+	return f.client.FindIssueByTitle(ctx, f.owner, f.repo, spec.Title)
+}
+
+// findMilestone returns the existing milestone for m, matching the number
+// recorded in the state first (0 if none), then the ID marker in the
+// description and finally the title.
+func findMilestone(m types.Milestone, recorded int, existing []*gogithub.Milestone) *gogithub.Milestone {
+	if recorded > 0 {
+		for _, em := range existing {
+			if em.GetNumber() == recorded {
+				return em
+			}
+		}
+	}
+	if m.ID != "" {
+		for _, em := range existing {
+			if parseMarker(em.GetDescription()) == m.ID {
+				return em
+			}
+		}
+	}
+	for _, em := range existing {
+		if em.GetTitle() == m.Title {
+			return em
+		}
+	}
+	return nil
+}
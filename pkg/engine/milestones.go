@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/goblinsan/gh-project-helper/pkg/state"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	gogithub "github.com/google/go-github/v66/github"
+)
+
+// dueOnLayout is the format of milestone due dates in change sets and exports.
+const dueOnLayout = "2006-01-02"
+
+// ParseDueOn parses a milestone due date, either a date such as 2026-04-01 or
+// an RFC 3339 time such as 2026-04-01T17:00:00-07:00. GitHub milestones are due
+// on a day rather than at a time, so the result is midnight UTC of the day the
+// value falls on in its own timezone.
+func ParseDueOn(value string) (time.Time, error) {
+	t, err := time.Parse(dueOnLayout, value)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, value)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("due_on %q must be a date (2006-01-02) or an RFC 3339 time", value)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// milestoneDiffs compares an existing milestone with the plan. A due date,
+// description or state the plan leaves out is not managed, except that the ID
+// marker is always added to the description.
+func milestoneDiffs(m types.Milestone, em *gogithub.Milestone) []FieldDiff {
+	diffs := diffString("title", em.GetTitle(), m.Title)
+
+	description := withMarker(m.Description, m.ID)
+	if m.Description == "" {
+		description = em.GetDescription()
+		if m.ID != "" && parseMarker(description) != m.ID {
+			description = withMarker(description, m.ID)
+		}
+	}
+	diffs = append(diffs, diffString("description", em.GetDescription(), description)...)
+
+	if m.DueOn != "" {
+		// Invalid dates are rejected before anything is compared
+		dueOn, _ := ParseDueOn(m.DueOn)
+		current := ""
+		if em.DueOn != nil {
+			current = em.GetDueOn().UTC().Format(dueOnLayout)
+		}
+		diffs = append(diffs, diffString("due_on", current, dueOn.Format(dueOnLayout))...)
+	}
+	if m.State != "" {
+		diffs = append(diffs, diffString("state", em.GetState(), m.State)...)
+	}
+	return diffs
+}
+
+// milestoneEdit returns the REST request body that applies diffs.
+func milestoneEdit(diffs []FieldDiff) *gogithub.Milestone {
+	edit := &gogithub.Milestone{}
+	for _, d := range diffs {
+		switch d.Field {
+		case "title":
+			edit.Title = gogithub.String(d.Desired)
+		case "description":
+			edit.Description = gogithub.String(d.Desired)
+		case "due_on":
+			dueOn, _ := time.Parse(dueOnLayout, d.Desired)
+			edit.DueOn = &gogithub.Timestamp{Time: dueOn}
+		case "state":
+			edit.State = gogithub.String(d.Desired)
+		}
+	}
+	return edit
+}
+
+// recordedMilestone returns the number of the milestone recorded for key in st,
+// or 0 if there is none.
+func recordedMilestone(st *state.State, key string) int {
+	if st == nil {
+		return 0
+	}
+	recorded, _ := st.Milestone(key)
+	return recorded.Number
+}
+
+// syncMilestone creates the milestone for m, or updates the existing one to
+// match it, and returns its number and node ID. The node ID recorded in the
+// state is used while the milestone keeps its recorded number.
+func (a *applier) syncMilestone(ctx context.Context, m types.Milestone, existing []*gogithub.Milestone) (state.Step, error) {
+	recorded, _ := a.state.Milestone(state.Key(m.ID, m.Title))
+	milestone := findMilestone(m, recorded.Number, existing)
+	if milestone == nil {
+		create := &gogithub.Milestone{
+			Title:       gogithub.String(m.Title),
+			Description: gogithub.String(withMarker(m.Description, m.ID)),
+		}
+		if m.DueOn != "" {
+			dueOn, err := ParseDueOn(m.DueOn)
+			if err != nil {
+				return state.Step{}, err
+			}
+			create.DueOn = &gogithub.Timestamp{Time: dueOn}
+		}
+		if m.State != "" {
+			create.State = gogithub.String(m.State)
+		}
+		var err error
+		milestone, err = a.client.CreateMilestone(ctx, a.owner, a.repo, create)
+		if err != nil {
+			return state.Step{}, fmt.Errorf("failed to create milestone: %w", err)
+		}
+		fmt.Printf("Created milestone: %s\n", m.Title)
+		a.report.MilestonesCreated++
+	} else if diffs := milestoneDiffs(m, milestone); len(diffs) > 0 {
+		if _, err := a.client.EditMilestone(ctx, a.owner, a.repo, milestone.GetNumber(), milestoneEdit(diffs)); err != nil {
+			return state.Step{}, fmt.Errorf("failed to update milestone %s: %w", m.Title, err)
+		}
+		for _, d := range diffs {
+			fmt.Printf("Updated milestone %s: %s %q -> %q\n", m.Title, d.Field, d.Current, d.Desired)
+			a.report.MilestoneUpdates = append(a.report.MilestoneUpdates, MilestoneUpdate{
+				Number: milestone.GetNumber(), Title: m.Title, Field: d.Field, From: d.Current, To: d.Desired,
+			})
+		}
+		a.report.MilestonesUpdated++
+	}
+
+	if recorded.NodeID != "" && recorded.Number == milestone.GetNumber() {
+		return state.Step{Number: recorded.Number, NodeID: recorded.NodeID}, nil
+	}
+	milestoneID, err := a.client.GetMilestoneID(ctx, a.owner, a.repo, milestone.GetNumber())
+	if err != nil {
+		return state.Step{}, fmt.Errorf("failed to get milestone id: %w", err)
+	}
+	return state.Step{Number: milestone.GetNumber(), NodeID: milestoneID}, nil
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goblinsan/gh-project-helper/pkg/state"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	gogithub "github.com/google/go-github/v66/github"
+)
+
+func TestParseDueOn(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"2026-04-01", "2026-04-01"},
+		{"2026-04-01T23:30:00-08:00", "2026-04-01"},
+		{"2026-04-01T01:00:00+09:00", "2026-04-01"},
+		{"2026-04-01T12:00:00Z", "2026-04-01"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDueOn(tt.value)
+		if err != nil {
+			t.Errorf("ParseDueOn(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if got.Format(time.RFC3339) != tt.want+"T00:00:00Z" {
+			t.Errorf("ParseDueOn(%q) = %v, want midnight UTC on %s", tt.value, got, tt.want)
+		}
+	}
+
+	if _, err := ParseDueOn("04/01/2026"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestApplyPlan_ReconcilesMilestones(t *testing.T) {
+	mock := newMockClient()
+	mock.milestones = []*gogithub.Milestone{
+		{Number: gogithub.Int(1), Title: gogithub.String("Phase 1"), Description: gogithub.String("Old text"), State: gogithub.String("closed"),
+			DueOn: &gogithub.Timestamp{Time: time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC)}},
+		{Number: gogithub.Int(2), Title: gogithub.String("Phase 2"), Description: gogithub.String("Second"), State: gogithub.String("open"),
+			DueOn: &gogithub.Timestamp{Time: time.Date(2026, 5, 1, 7, 0, 0, 0, time.UTC)}},
+		{Number: gogithub.Int(3), Title: gogithub.String("Old name"), State: gogithub.String("open")},
+	}
+	st := state.New("owner/repo", "Test Project")
+	st.SetMilestone(state.Key("", "Phase 3"), state.Milestone{Title: "Old name", Number: 3})
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Milestones: []types.Milestone{
+			{Title: "Phase 1", Description: "New text", DueOn: "2026-04-01T17:00:00-07:00", State: types.MilestoneOpen},
+			{Title: "Phase 2", DueOn: "2026-05-01"},
+			{Title: "Phase 3"},
+			{Title: "Phase 4", State: types.MilestoneClosed},
+		},
+	}
+
+	report, err := ApplyPlan(context.Background(), mock, plan, Options{State: st})
+	if err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+
+	expected := []string{
+		`edit #1 description="New text" due_on=2026-04-01T00:00:00Z state=open`,
+		`edit #3 title=Phase 3`,
+		`create Phase 4`,
+	}
+	if fmt.Sprint(mock.milestoneEdits) != fmt.Sprint(expected) {
+		t.Errorf("unexpected milestone changes:\n got %v\nwant %v", mock.milestoneEdits, expected)
+	}
+	if report.MilestonesCreated != 1 || report.MilestonesUpdated != 2 {
+		t.Errorf("expected 1 milestone created and 2 updated, got %d and %d", report.MilestonesCreated, report.MilestonesUpdated)
+	}
+	if len(report.MilestoneUpdates) != 4 {
+		t.Fatalf("expected 4 milestone field changes, got %+v", report.MilestoneUpdates)
+	}
+	if u := report.MilestoneUpdates[1]; u.Field != "due_on" || u.From != "2026-03-01" || u.To != "2026-04-01" {
+		t.Errorf("unexpected due date change: %+v", u)
+	}
+}
+
+// milestoneIDCountingClient counts GetMilestoneID calls.
+type milestoneIDCountingClient struct {
+	*mockClient
+	calls int
+}
+
+func (c *milestoneIDCountingClient) GetMilestoneID(ctx context.Context, owner, name string, number int) (string, error) {
+	c.calls++
+	return c.mockClient.GetMilestoneID(ctx, owner, name, number)
+}
+
+func TestApplyPlan_MilestoneIDFromState(t *testing.T) {
+	mock := newMockClient()
+	mock.milestones = []*gogithub.Milestone{
+		{Number: gogithub.Int(1), Title: gogithub.String("Phase 1"), State: gogithub.String("open")},
+		{Number: gogithub.Int(2), Title: gogithub.String("Phase 2"), State: gogithub.String("open")},
+	}
+	client := &milestoneIDCountingClient{mockClient: mock}
+	st := state.New("owner/repo", "Test Project")
+	st.SetMilestone(state.Key("", "Phase 1"), state.Milestone{Title: "Phase 1", Number: 1, NodeID: "recorded-node"})
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Milestones: []types.Milestone{{Title: "Phase 1"}, {Title: "Phase 2"}},
+	}
+
+	report, err := ApplyPlan(context.Background(), client, plan, Options{State: st})
+	if err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	if client.calls != 1 {
+		t.Errorf("expected only the unrecorded milestone to be looked up, made %d calls", client.calls)
+	}
+	if recorded, _ := st.Milestone(state.Key("", "Phase 1")); recorded.NodeID != "recorded-node" {
+		t.Errorf("expected the recorded node ID to be kept, got %+v", recorded)
+	}
+	if report.MilestonesCreated != 0 {
+		t.Errorf("expected no milestones to be created, got %d", report.MilestonesCreated)
+	}
+}
+
+func TestApplyPlan_ResumedMilestoneNotCounted(t *testing.T) {
+	path := state.JournalPath(filepath.Join(t.TempDir(), state.DefaultPath))
+	journal, err := state.OpenJournal(path, false)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	journal.Record(state.Step{Key: "milestone:title:Phase 1", Name: "sync", Number: 1, NodeID: "milestone-node-id"})
+	journal.Close()
+	journal, err = state.OpenJournal(path, true)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	defer journal.Close()
+
+	mock := newMockClient()
+	plan := types.Plan{
+		Project:    "Test Project",
+		Repository: "owner/repo",
+		Milestones: []types.Milestone{{Title: "Phase 1"}},
+	}
+	report, err := ApplyPlan(context.Background(), mock, plan, Options{Journal: journal})
+	if err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	if report.MilestonesCreated != 0 || len(mock.milestoneEdits) != 0 {
+		t.Errorf("expected the journaled milestone not to be created or counted, got %d and %v", report.MilestonesCreated, mock.milestoneEdits)
+	}
+}
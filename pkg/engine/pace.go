@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// paceReserve is the rate limit budget apply keeps in hand. Below it apply waits
+// for the budget to reset before the next issue, rather than running dry part
+// way through one and leaving it half applied. Small budgets, such as the 30
+// searches a minute, keep a tenth of their limit instead.
+const paceReserve = 100
+
+// sleep waits for d or until ctx is done. Tests replace it.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pace waits for the client's rate limit to reset when its remaining budget is
+// below the reserve.
+func pace(ctx context.Context, client GitHubClient) error {
+	limit := client.RateLimit()
+	if limit.Limit == 0 || limit.Remaining >= min(paceReserve, limit.Limit/10) {
+		return nil
+	}
+	wait := time.Until(limit.Reset)
+	if wait <= 0 {
+		return nil
+	}
+	wait = wait.Round(time.Second) + time.Second
+	fmt.Printf("Only %d of %d %s rate limit left, pausing %s until it resets\n", limit.Remaining, limit.Limit, limit.Resource, wait)
+	return sleep(ctx, wait)
+}
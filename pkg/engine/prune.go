@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/goblinsan/gh-project-helper/pkg/state"
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+	"github.com/shurcooL/githubv4"
+)
+
+// PrunePolicy decides what happens to managed issues that were dropped from the plan.
+type PrunePolicy string
+
+const (
+	// PruneNone disables pruning.
+	PruneNone PrunePolicy = ""
+	// PruneReport only lists the dropped issues.
+	PruneReport PrunePolicy = "report"
+	// PruneClose closes dropped issues as "not planned".
+	PruneClose PrunePolicy = "close"
+	// PruneRemove removes dropped issues from the Project V2 board but leaves them open.
+	PruneRemove PrunePolicy = "remove"
+)
+
+// ParsePrunePolicy validates a prune policy name.
+func ParsePrunePolicy(s string) (PrunePolicy, error) {
+	switch p := PrunePolicy(strings.ToLower(s)); p {
+	case PruneNone, PruneReport, PruneClose, PruneRemove:
+		return p, nil
+	}
+	return PruneNone, fmt.Errorf("unknown prune policy %q (expected report, close or remove)", s)
+}
+
+// PrunedIssue records a managed issue that is no longer in the plan.
+type PrunedIssue struct {
+	Number int         `json:"number"`
+	Title  string      `json:"title"`
+	Policy PrunePolicy `json:"policy"`
+}
+
+// pruneCandidate is a managed issue that no plan element refers to any more.
+type pruneCandidate struct {
+	Key    string
+	Number int
+	NodeID string
+	Title  string
+}
+
+// resolvedIssues holds the issues that plan elements resolved to in this run.
+// It is safe for concurrent use.
+type resolvedIssues struct {
+	mu      sync.Mutex
+	numbers map[int]bool
+	nodeIDs map[string]bool
+}
+
+func newResolvedIssues() *resolvedIssues {
+	return &resolvedIssues{numbers: make(map[int]bool), nodeIDs: make(map[string]bool)}
+}
+
+func (r *resolvedIssues) add(number int, nodeID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.numbers[number] = true
+	r.nodeIDs[nodeID] = true
+}
+
+// has reports whether the issue with the given number or node ID was resolved.
+func (r *resolvedIssues) has(number int, nodeID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.numbers[number] || nodeID != "" && r.nodeIDs[nodeID]
+}
+
+// findPruneCandidates returns issues previously managed by the tool, either
+// recorded in the state or carrying a body marker, that are no longer in the
+// plan. Issues that a plan element resolved to are never candidates, even when
+// recorded under an old key, and closed issues found only by marker are
+// ignored. The state may be nil.
+func findPruneCandidates(ctx context.Context, client GitHubClient, owner, repo string, plan types.Plan, st *state.State, resolved *resolvedIssues) ([]pruneCandidate, error) {
+	planKeys := make(map[string]bool)
+	planTitles := make(map[string]bool)
+	for _, epic := range plan.Epics {
+		planKeys[state.Key(epic.ID, epic.Title)] = true
+		planTitles[epic.Title] = true
+		forEachIssue(epic.Children, func(child types.Issue) {
+			planKeys[state.Key(child.ID, child.Title)] = true
+			planTitles[child.Title] = true
+		})
+	}
+
+	var candidates []pruneCandidate
+	seen := make(map[int]bool)
+	if st != nil {
+		for _, key := range st.IssueKeys() {
+			if planKeys[key] {
+				continue
+			}
+			recorded, _ := st.Issue(key)
+			if resolved.has(recorded.Number, recorded.NodeID) {
+				continue
+			}
+			candidates = append(candidates, pruneCandidate{Key: key, Number: recorded.Number, NodeID: recorded.NodeID, Title: recorded.Title})
+			seen[recorded.Number] = true
+		}
+	}
+
+	issues, err := client.ListIssues(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues: %w", err)
+	}
+	for _, issue := range issues {
+		id := parseMarker(issue.GetBody())
+		if id == "" || issue.GetState() != "open" || seen[issue.GetNumber()] || resolved.has(issue.GetNumber(), issue.GetNodeID()) {
+			continue
+		}
+		// An element whose ID was dropped from the plan still matches by title
+		key := state.Key(id, "")
+		if planKeys[key] || planTitles[issue.GetTitle()] {
+			continue
+		}
+		candidates = append(candidates, pruneCandidate{Key: key, Number: issue.GetNumber(), NodeID: issue.GetNodeID(), Title: issue.GetTitle()})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Number < candidates[j].Number })
+	return candidates, nil
+}
+
+// prune applies the configured policy to every managed issue dropped from the plan.
+func (a *applier) prune(ctx context.Context, plan types.Plan) error {
+	candidates, err := findPruneCandidates(ctx, a.client, a.owner, a.repo, plan, a.state, a.resolved)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range candidates {
+		switch a.opts.Prune {
+		case PruneReport:
+			fmt.Printf("Not in plan: #%d %s\n", c.Number, c.Title)
+		case PruneClose:
+			current, err := a.client.GetIssue(ctx, a.owner, a.repo, c.Number)
+			if err != nil {
+				return fmt.Errorf("failed to read issue #%d: %w", c.Number, err)
+			}
+			if current.State != string(githubv4.IssueStateClosed) {
+				if err := a.client.CloseIssue(ctx, githubv4.ID(current.ID), githubv4.IssueClosedStateReasonNotPlanned); err != nil {
+					return fmt.Errorf("failed to close issue #%d: %w", c.Number, err)
+				}
+			}
+			fmt.Printf("Closed as not planned: #%d %s\n", c.Number, c.Title)
+			a.state.DeleteIssue(c.Key)
+		case PruneRemove:
+			current, err := a.client.GetIssue(ctx, a.owner, a.repo, c.Number)
+			if err != nil {
+				return fmt.Errorf("failed to read issue #%d: %w", c.Number, err)
+			}
+			if item, ok := current.ProjectItem(a.projectID); ok {
+				if err := a.client.DeleteProjectV2Item(ctx, githubv4.ID(a.projectID), githubv4.ID(item.ID)); err != nil {
+					return fmt.Errorf("failed to remove issue #%d from project: %w", c.Number, err)
+				}
+			}
+			fmt.Printf("Removed from project: #%d %s\n", c.Number, c.Title)
+			a.state.DeleteIssue(c.Key)
+		}
+		a.report.Pruned = append(a.report.Pruned, PrunedIssue{Number: c.Number, Title: c.Title, Policy: a.opts.Prune})
+	}
+	return nil
+}
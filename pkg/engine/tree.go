@@ -0,0 +1,238 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/goblinsan/gh-project-helper/pkg/types"
+)
+
+// planNode is an epic or issue in the plan tree. base is its desired state with
+// everything inherited from its ancestors but without the references to other
+// issues in the body, which are only known once those issues exist.
+type planNode struct {
+	kind      string
+	issue     types.Issue
+	base      issueSpec
+	parent    *planNode
+	children  []*planNode
+	blockedBy []*planNode
+}
+
+// buildTree converts the plan's epics and their children into nodes and resolves
+// depends_on and blocks references. It returns the epics in plan order.
+func buildTree(plan types.Plan) ([]*planNode, error) {
+	var roots []*planNode
+	for _, epic := range plan.Epics {
+		root := &planNode{kind: KindEpic, issue: epicIssue(epic), base: epicSpec(epic)}
+		addChildren(root, epic.Children)
+		roots = append(roots, root)
+	}
+
+	// References match plan IDs first and titles second
+	byID := make(map[string]*planNode)
+	byTitle := make(map[string]*planNode)
+	nodes := preOrder(roots)
+	for _, n := range nodes {
+		if n.issue.ID != "" {
+			byID[n.issue.ID] = n
+		}
+		if _, ok := byTitle[n.issue.Title]; !ok {
+			byTitle[n.issue.Title] = n
+		}
+	}
+	resolve := func(n *planNode, field, ref string) (*planNode, error) {
+		if target, ok := byID[ref]; ok {
+			return target, nil
+		}
+		if target, ok := byTitle[ref]; ok {
+			return target, nil
+		}
+		return nil, fmt.Errorf("%s %q: %s %q does not match any id or title in the plan", n.kind, n.issue.Title, field, ref)
+	}
+
+	for _, n := range nodes {
+		for _, ref := range n.issue.DependsOn {
+			target, err := resolve(n, "depends_on", ref)
+			if err != nil {
+				return nil, err
+			}
+			n.addBlocker(target)
+		}
+		for _, ref := range n.issue.Blocks {
+			target, err := resolve(n, "blocks", ref)
+			if err != nil {
+				return nil, err
+			}
+			target.addBlocker(n)
+		}
+	}
+	return roots, nil
+}
+
+func addChildren(parent *planNode, children []types.Issue) {
+	for _, child := range children {
+		n := &planNode{kind: KindIssue, issue: child, base: childSpec(parent.base, child), parent: parent}
+		parent.children = append(parent.children, n)
+		addChildren(n, child.Children)
+	}
+}
+
+func (n *planNode) addBlocker(blocker *planNode) {
+	for _, b := range n.blockedBy {
+		if b == blocker {
+			return
+		}
+	}
+	n.blockedBy = append(n.blockedBy, blocker)
+}
+
+// epicIssue holds an epic in issue form so the tree has a single node type.
+func epicIssue(epic types.Epic) types.Issue {
+	return types.Issue{
+		ID:        epic.ID,
+		Title:     epic.Title,
+		Body:      epic.Body,
+		Milestone: epic.Milestone,
+		Status:    epic.Status,
+		Labels:    epic.Labels,
+		Assignees: &epic.Assignees,
+		Fields:    epic.Fields,
+		Iteration: epic.Iteration,
+		Children:  epic.Children,
+		DependsOn: epic.DependsOn,
+		Blocks:    epic.Blocks,
+	}
+}
+
+// spec returns the node's desired state. Epics, and issues with children, get
+// the tasklist of childRefs when the linking strategy uses tasklists; blockerRefs
+// become a "Blocked by" line when dependencies are kept in the body.
+func (n *planNode) spec(childRefs, blockerRefs []string, linking string) issueSpec {
+	spec := n.base
+	body := n.issue.Body
+	if usesTasklist(linking) && (n.kind == KindEpic || len(n.children) > 0) {
+		body = epicBody(body, childRefs)
+	}
+	if len(blockerRefs) > 0 {
+		body = blockedByBody(body, blockerRefs)
+	}
+	spec.Body = withMarker(body, n.issue.ID)
+	return spec
+}
+
+// preOrder returns every node below roots, parents before their children.
+func preOrder(roots []*planNode) []*planNode {
+	var nodes []*planNode
+	var visit func(n *planNode)
+	visit = func(n *planNode) {
+		nodes = append(nodes, n)
+		for _, c := range n.children {
+			visit(c)
+		}
+	}
+	for _, r := range roots {
+		visit(r)
+	}
+	return nodes
+}
+
+// creationOrder sorts every node so that each comes after its children, which
+// its tasklist references, and after the issues that block it. Without
+// dependencies this is the plan's leaves-first order. It fails on cycles.
+func creationOrder(roots []*planNode) ([]*planNode, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	marks := make(map[*planNode]int)
+	var order, path []*planNode
+
+	var visit func(n *planNode) error
+	visit = func(n *planNode) error {
+		switch marks[n] {
+		case done:
+			return nil
+		case visiting:
+			return cycleError(path, n)
+		}
+		marks[n] = visiting
+		path = append(path, n)
+		for _, c := range n.children {
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+		for _, b := range n.blockedBy {
+			if err := visit(b); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		marks[n] = done
+		order = append(order, n)
+		return nil
+	}
+
+	for _, r := range roots {
+		if err := visit(r); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// cycleError describes the cycle that closes when path reaches n again. Each
+// title in the message waits for the one after it.
+func cycleError(path []*planNode, n *planNode) error {
+	var titles []string
+	for i := len(path) - 1; i >= 0; i-- {
+		titles = append(titles, path[i].issue.Title)
+		if path[i] == n {
+			break
+		}
+	}
+	for i, j := 0, len(titles)-1; i < j; i, j = i+1, j-1 {
+		titles[i], titles[j] = titles[j], titles[i]
+	}
+	titles = append(titles, n.issue.Title)
+	return fmt.Errorf("dependency cycle: %s", strings.Join(titles, " -> "))
+}
+
+// ValidateDependencies checks that every depends_on and blocks reference in the
+// plan resolves and that the issues can be created in some order, i.e. that
+// neither dependencies nor parent/child nesting form a cycle.
+func ValidateDependencies(plan types.Plan) error {
+	roots, err := buildTree(plan)
+	if err != nil {
+		return err
+	}
+	_, err = creationOrder(roots)
+	return err
+}
+
+// nativeDependencies reports whether dependencies in the plan are recorded as
+// native GitHub issue dependencies. Servers without them, such as older GitHub
+// Enterprise Server versions, get a "Blocked by" line in the issue body instead.
+func nativeDependencies(ctx context.Context, client GitHubClient, nodes []*planNode) (bool, error) {
+	uses := false
+	for _, n := range nodes {
+		uses = uses || len(n.blockedBy) > 0
+	}
+	if !uses {
+		return false, nil
+	}
+	native, err := client.SupportsIssueDependencies(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for issue dependency support: %w", err)
+	}
+	return native, nil
+}
+
+// blockedByBody appends the issues blocking this one to its body, for servers
+// without native issue dependencies.
+func blockedByBody(body string, blockerRefs []string) string {
+	return body + "\n\nBlocked by " + strings.Join(blockerRefs, ", ")
+}
@@ -0,0 +1,163 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"golang.org/x/oauth2"
+)
+
+// AppAuth identifies a GitHub App installation to authenticate as.
+type AppAuth struct {
+	AppID int64
+	// PrivateKey is the App's private key in PEM format.
+	PrivateKey []byte
+	// InstallationID selects the installation. When it is zero, the
+	// installation on Owner, an organization or user, is looked up.
+	InstallationID int64
+	Owner          string
+	// Host is the host the App is registered on; empty is github.com. The key
+	// never signs requests for any other host.
+	Host string
+}
+
+// appTokenRefresh is how long before it expires an installation token is
+// replaced, so that a request in flight never carries an expired token.
+const appTokenRefresh = 5 * time.Minute
+
+// appTokenSource mints installation tokens for a GitHub App.
+type appTokenSource struct {
+	app  AppAuth
+	key  *rsa.PrivateKey
+	rest *github.Client
+	now  func() time.Time
+
+	mu sync.Mutex
+}
+
+// AppTokenSource returns a token source for the installation of app on host.
+// Installation tokens last an hour; a new one is minted shortly before the
+// current one expires, so long applies keep working.
+func AppTokenSource(host string, app AppAuth) (oauth2.TokenSource, error) {
+	if app.AppID == 0 {
+		return nil, errors.New("GitHub App ID is required")
+	}
+	if !SameHost(host, app.Host) {
+		return nil, fmt.Errorf("GitHub App %d is configured for %s, not %s", app.AppID, hostname(app.Host), hostname(host))
+	}
+	if app.InstallationID == 0 && app.Owner == "" {
+		return nil, errors.New("GitHub App installation ID is required when the repository owner is not known")
+	}
+	key, err := parsePrivateKey(app.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// App endpoints authenticate with a JWT instead of a token
+	src := &appTokenSource{app: app, key: key, now: time.Now}
+	jwt := &http.Client{Transport: &jwtTransport{source: src, base: NewTransport(nil)}}
+	src.rest, err = newRESTClient(host, jwt)
+	if err != nil {
+		return nil, err
+	}
+	return oauth2.ReuseTokenSource(nil, src), nil
+}
+
+// parsePrivateKey reads an RSA private key in PKCS #1 or PKCS #8 PEM format.
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("GitHub App private key is not in PEM format")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Token exchanges a new JWT for an installation token.
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ctx := context.Background()
+
+	if s.app.InstallationID == 0 {
+		installation, resp, err := s.rest.Apps.FindOrganizationInstallation(ctx, s.app.Owner)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			installation, _, err = s.rest.Apps.FindUserInstallation(ctx, s.app.Owner)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the GitHub App installation for %s: %w", s.app.Owner, err)
+		}
+		s.app.InstallationID = installation.GetID()
+	}
+
+	token, _, err := s.rest.Apps.CreateInstallationToken(ctx, s.app.InstallationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub App installation token: %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: token.GetToken(),
+		TokenType:   "token",
+		Expiry:      token.GetExpiresAt().Add(-appTokenRefresh),
+	}, nil
+}
+
+// jwt returns a JSON Web Token signed with the App's private key, valid for ten
+// minutes from a minute ago to allow for clock drift.
+func (s *appTokenSource) jwt() (string, error) {
+	now := s.now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": fmt.Sprint(s.app.AppID),
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// jwtTransport authenticates requests as the App itself.
+type jwtTransport struct {
+	source *appTokenSource
+	base   http.RoundTripper
+}
+
+func (t *jwtTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	jwt, err := t.source.jwt()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,113 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// verifyJWT checks the signature of a GitHub App JWT and returns its issuer.
+func verifyJWT(t *testing.T, key *rsa.PublicKey, jwt string) string {
+	t.Helper()
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed JWT %q", jwt)
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		t.Fatalf("JWT signature does not verify: %v", err)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Iss string
+		Iat int64
+		Exp int64
+	}
+	json.Unmarshal(payload, &claims)
+	if claims.Exp-claims.Iat > 10*60 {
+		t.Errorf("JWT is valid for more than ten minutes: %+v", claims)
+	}
+	return claims.Iss
+}
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var requests []string
+	minted := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if iss := verifyJWT(t, &key.PublicKey, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); iss != "123" {
+			t.Errorf("expected the App ID as issuer, got %q", iss)
+		}
+		switch r.URL.Path {
+		case "/api/v3/orgs/acme/installation":
+			http.NotFound(w, r)
+		case "/api/v3/users/acme/installation":
+			fmt.Fprint(w, `{"id":42}`)
+		case "/api/v3/app/installations/42/access_tokens":
+			minted++
+			// The second token is already within the refresh window
+			expires := time.Now().Add(time.Hour)
+			if minted == 1 {
+				expires = time.Now().Add(appTokenRefresh / 2)
+			}
+			fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":%q}`, minted, expires.Format(time.RFC3339))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ts, err := AppTokenSource(server.URL, AppAuth{AppID: 123, PrivateKey: pemKey, Owner: "acme", Host: server.URL})
+	if err != nil {
+		t.Fatalf("AppTokenSource failed: %v", err)
+	}
+	for _, want := range []string{"ghs_1", "ghs_2", "ghs_2"} {
+		token, err := ts.Token()
+		if err != nil {
+			t.Fatalf("Token failed: %v", err)
+		}
+		if token.AccessToken != want {
+			t.Errorf("expected %s, got %s", want, token.AccessToken)
+		}
+	}
+
+	expected := []string{
+		"GET /api/v3/orgs/acme/installation",
+		"GET /api/v3/users/acme/installation",
+		"POST /api/v3/app/installations/42/access_tokens",
+		"POST /api/v3/app/installations/42/access_tokens",
+	}
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("unexpected requests:\n got %v\nwant %v", requests, expected)
+	}
+}
+
+func TestAppTokenSource_InvalidConfig(t *testing.T) {
+	if _, err := AppTokenSource("", AppAuth{AppID: 1, Owner: "acme", PrivateKey: []byte("not a key")}); err == nil {
+		t.Error("expected an error for a private key that is not PEM")
+	}
+	if _, err := AppTokenSource("", AppAuth{AppID: 1, PrivateKey: []byte("x")}); err == nil {
+		t.Error("expected an error without an installation ID or owner")
+	}
+	if _, err := AppTokenSource("https://evil.example", AppAuth{AppID: 1, Owner: "acme", Host: "ghes.example.com"}); err == nil || !strings.Contains(err.Error(), "configured for ghes.example.com") {
+		t.Errorf("expected the App to be refused for another host, got %v", err)
+	}
+}
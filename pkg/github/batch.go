@@ -0,0 +1,219 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// defaultGraphQLURL is the GraphQL endpoint of github.com.
+const defaultGraphQLURL = "https://api.github.com/graphql"
+
+// maxBatchSize is the number of mutations sent in one GraphQL document. GitHub
+// caps the cost and node count of a single request, so longer batches are split.
+const maxBatchSize = 25
+
+// batchMutation is one field of an aliased mutation document.
+type batchMutation struct {
+	// field is the mutation name, e.g. "createIssue".
+	field string
+	// inputType is the GraphQL type of its input, e.g. "CreateIssueInput!".
+	inputType string
+	input     interface{}
+	// selection is the selection set of its payload, e.g. "{issue{id}}".
+	selection string
+}
+
+// BatchError is returned by the batch methods when some of the mutations in a
+// batch failed. The others took effect. Errors maps the index of each failed
+// mutation to its error.
+type BatchError struct {
+	Total  int
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	i, err := e.First()
+	return fmt.Sprintf("%d of %d mutations failed, first #%d: %v", len(e.Errors), e.Total, i, err)
+}
+
+// First returns the failed mutation with the lowest index.
+func (e *BatchError) First() (int, error) {
+	indexes := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes[0], e.Errors[indexes[0]]
+}
+
+// mutateBatch sends the mutations as aliased fields of as few GraphQL documents
+// as maxBatchSize allows, and returns the payload of each. A mutation GitHub
+// rejected has a nil payload and is reported in a *BatchError; a request that
+// failed as a whole fails every mutation in it.
+func (c *Client) mutateBatch(ctx context.Context, mutations []batchMutation) ([]json.RawMessage, error) {
+	payloads := make([]json.RawMessage, len(mutations))
+	failed := make(map[int]error)
+	for start := 0; start < len(mutations); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(mutations))
+		chunk, err := c.sendBatch(ctx, mutations[start:end])
+		for i := start; i < end; i++ {
+			switch {
+			case err != nil:
+				failed[i] = err
+			case chunk.errors[i-start] != nil:
+				failed[i] = chunk.errors[i-start]
+			default:
+				payloads[i] = chunk.payloads[i-start]
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return payloads, &BatchError{Total: len(mutations), Errors: failed}
+	}
+	return payloads, nil
+}
+
+type batchResult struct {
+	payloads []json.RawMessage
+	errors   []error
+}
+
+// sendBatch sends one aliased mutation document.
+func (c *Client) sendBatch(ctx context.Context, mutations []batchMutation) (batchResult, error) {
+	var params, fields []string
+	variables := make(map[string]interface{}, len(mutations))
+	for i, m := range mutations {
+		params = append(params, fmt.Sprintf("$input%d:%s", i, m.inputType))
+		fields = append(fields, fmt.Sprintf("m%d:%s(input:$input%d)%s", i, m.field, i, m.selection))
+		variables[fmt.Sprintf("input%d", i)] = m.input
+	}
+	query := fmt.Sprintf("mutation(%s){%s}", strings.Join(params, ","), strings.Join(fields, ""))
+
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return batchResult{}, err
+	}
+	url := c.graphqlURL
+	if url == "" {
+		url = defaultGraphQLURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return batchResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return batchResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return batchResult{}, fmt.Errorf("non-200 OK status code: %s", resp.Status)
+	}
+
+	var out struct {
+		Data   map[string]json.RawMessage
+		Errors []struct {
+			Message string
+			Path    []interface{}
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return batchResult{}, fmt.Errorf("failed to decode batch response: %w", err)
+	}
+
+	result := batchResult{payloads: make([]json.RawMessage, len(mutations)), errors: make([]error, len(mutations))}
+	for _, e := range out.Errors {
+		var i int
+		if len(e.Path) == 0 {
+			return batchResult{}, fmt.Errorf("%s", e.Message)
+		}
+		alias, _ := e.Path[0].(string)
+		if _, err := fmt.Sscanf(alias, "m%d", &i); err != nil || i >= len(mutations) {
+			return batchResult{}, fmt.Errorf("%s", e.Message)
+		}
+		result.errors[i] = fmt.Errorf("%s", e.Message)
+	}
+	for i := range mutations {
+		payload := out.Data[fmt.Sprintf("m%d", i)]
+		if result.errors[i] == nil && (payload == nil || string(payload) == "null") {
+			result.errors[i] = fmt.Errorf("no result for %s", mutations[i].field)
+		}
+		result.payloads[i] = payload
+	}
+	return result, nil
+}
+
+// CreateIssues creates issues in batches. The result for an input that failed
+// is nil, and the error is a *BatchError naming it.
+func (c *Client) CreateIssues(ctx context.Context, inputs []githubv4.CreateIssueInput) ([]*CreateIssueMutation, error) {
+	mutations := make([]batchMutation, len(inputs))
+	for i, input := range inputs {
+		mutations[i] = batchMutation{field: "createIssue", inputType: "CreateIssueInput!", input: input, selection: "{issue{id,number,url}}"}
+	}
+	payloads, err := c.mutateBatch(ctx, mutations)
+
+	results := make([]*CreateIssueMutation, len(inputs))
+	for i, payload := range payloads {
+		if payload == nil {
+			continue
+		}
+		var result CreateIssueMutation
+		if decodeErr := json.Unmarshal(payload, &result.CreateIssue); decodeErr != nil {
+			return nil, fmt.Errorf("failed to decode created issue: %w", decodeErr)
+		}
+		results[i] = &result
+	}
+	return results, err
+}
+
+// AddIssuesToProjectV2 adds issues to a project in batches and returns their
+// project item IDs. The item ID of an issue that failed is nil, and the error is
+// a *BatchError naming it.
+func (c *Client) AddIssuesToProjectV2(ctx context.Context, projectID githubv4.ID, contentIDs []githubv4.ID) ([]githubv4.ID, error) {
+	mutations := make([]batchMutation, len(contentIDs))
+	for i, contentID := range contentIDs {
+		input := githubv4.AddProjectV2ItemByIdInput{ProjectID: projectID, ContentID: contentID}
+		mutations[i] = batchMutation{field: "addProjectV2ItemById", inputType: "AddProjectV2ItemByIdInput!", input: input, selection: "{item{id}}"}
+	}
+	payloads, err := c.mutateBatch(ctx, mutations)
+
+	itemIDs := make([]githubv4.ID, len(contentIDs))
+	for i, payload := range payloads {
+		if payload == nil {
+			continue
+		}
+		var result struct {
+			Item struct {
+				ID string
+			}
+		}
+		if decodeErr := json.Unmarshal(payload, &result); decodeErr != nil {
+			return nil, fmt.Errorf("failed to decode project item: %w", decodeErr)
+		}
+		itemIDs[i] = githubv4.ID(result.Item.ID)
+	}
+	return itemIDs, err
+}
+
+// UpdateProjectV2ItemFieldValues sets project item fields in batches. If some
+// updates fail, the error is a *BatchError naming them.
+func (c *Client) UpdateProjectV2ItemFieldValues(ctx context.Context, inputs []githubv4.UpdateProjectV2ItemFieldValueInput) error {
+	mutations := make([]batchMutation, len(inputs))
+	for i, input := range inputs {
+		mutations[i] = batchMutation{field: "updateProjectV2ItemFieldValue", inputType: "UpdateProjectV2ItemFieldValueInput!", input: input, selection: "{clientMutationId}"}
+	}
+	_, err := c.mutateBatch(ctx, mutations)
+	return err
+}
@@ -0,0 +1,87 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/shurcooL/githubv4"
+)
+
+func TestCreateIssues_Batches(t *testing.T) {
+	var documents []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string
+			Variables map[string]githubv4.CreateIssueInput
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		documents = append(documents, req.Query)
+
+		data := make(map[string]interface{})
+		var errs []map[string]interface{}
+		for i := 0; i < len(req.Variables); i++ {
+			alias := fmt.Sprintf("m%d", i)
+			title := string(req.Variables[fmt.Sprintf("input%d", i)].Title)
+			if title == "Bad" {
+				data[alias] = nil
+				errs = append(errs, map[string]interface{}{"message": "title is invalid", "path": []string{alias}})
+				continue
+			}
+			data[alias] = map[string]interface{}{"issue": map[string]interface{}{
+				"id": "node-" + title, "number": len(documents)*100 + i, "url": "https://github.com/o/r/issues/" + title,
+			}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data, "errors": errs})
+	}))
+
+	var inputs []githubv4.CreateIssueInput
+	for i := 0; i < maxBatchSize+2; i++ {
+		title := fmt.Sprintf("Issue %d", i)
+		if i == 3 {
+			title = "Bad"
+		}
+		inputs = append(inputs, githubv4.CreateIssueInput{RepositoryID: "repo", Title: githubv4.String(title)})
+	}
+
+	results, err := client.CreateIssues(context.Background(), inputs)
+	if len(documents) != 2 {
+		t.Fatalf("expected %d mutations in 2 requests, got %d", len(inputs), len(documents))
+	}
+	if !strings.HasPrefix(documents[0], "mutation($input0:CreateIssueInput!,$input1:CreateIssueInput!,") ||
+		!strings.Contains(documents[0], "m1:createIssue(input:$input1){issue{id,number,url}}") {
+		t.Errorf("unexpected document %s", documents[0])
+	}
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 1 || batchErr.Errors[3] == nil {
+		t.Fatalf("expected only mutation 3 to fail, got %v", err)
+	}
+	if results[3] != nil {
+		t.Errorf("expected no result for the failed mutation, got %+v", results[3])
+	}
+	last := results[maxBatchSize+1].CreateIssue.Issue
+	if last.ID != "node-Issue 26" || last.Number != 201 || last.URL.String() != "https://github.com/o/r/issues/Issue%2026" {
+		t.Errorf("unexpected result from the second request: %+v", last)
+	}
+}
+
+func TestUpdateProjectV2ItemFieldValues_RequestFailure(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+
+	inputs := make([]githubv4.UpdateProjectV2ItemFieldValueInput, 2)
+	err := client.UpdateProjectV2ItemFieldValues(context.Background(), inputs)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 2 {
+		t.Fatalf("expected both updates to fail, got %v", err)
+	}
+	if i, _ := batchErr.First(); i != 0 {
+		t.Errorf("expected the first failure to be #0, got #%d", i)
+	}
+}
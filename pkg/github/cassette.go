@@ -0,0 +1,277 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// Cassette is a recording of the HTTP exchanges of a client with GitHub, which
+// a Replayer plays back in tests without network access.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and the response GitHub sent to it.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request without its headers, which carry nothing the
+// API responds to besides credentials.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   Body   `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response with only the headers the client reads.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Body is a request or response body. JSON bodies are stored as JSON so that
+// cassettes can be read and reviewed; anything else is stored as a string.
+type Body []byte
+
+// MarshalJSON implements json.Marshaler.
+func (b Body) MarshalJSON() ([]byte, error) {
+	if isJSONDocument(b) {
+		return compactJSON(b), nil
+	}
+	return json.Marshal(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = Body(text)
+		return nil
+	}
+	if !isJSONDocument(data) {
+		return fmt.Errorf("invalid cassette body %s", data)
+	}
+	*b = compactJSON(data)
+	return nil
+}
+
+// isJSONDocument reports whether data is a JSON object or array.
+func isJSONDocument(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed)
+}
+
+// compactJSON returns data, a JSON document, without insignificant space.
+func compactJSON(data []byte) []byte {
+	var compact bytes.Buffer
+	json.Compact(&compact, data)
+	return compact.Bytes()
+}
+
+// recordedHeaders are the response headers kept in cassettes: those the client
+// and the retry transport read. The rest, such as cookies and request IDs,
+// would only make recordings noisy or leak session data.
+var recordedHeaders = []string{
+	"Content-Type",
+	"Link",
+	"Retry-After",
+	"X-Oauth-Scopes",
+	"X-Ratelimit-Limit",
+	"X-Ratelimit-Remaining",
+	"X-Ratelimit-Reset",
+	"X-Ratelimit-Resource",
+}
+
+// tokenPattern matches GitHub personal access, OAuth, user-to-server,
+// installation and refresh tokens.
+var tokenPattern = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})`)
+
+// scrub replaces every GitHub token in data with REDACTED.
+func scrub(data []byte) []byte {
+	return tokenPattern.ReplaceAll(data, []byte("REDACTED"))
+}
+
+// Recorder is an http.RoundTripper that sends requests with Base and records
+// every exchange in a cassette, with tokens scrubbed. It is meant to sit above
+// the transport that adds credentials, so that requests are recorded without
+// their Authorization header; see NewClientWithTransport.
+type Recorder struct {
+	Base http.RoundTripper
+	// Scrub, if set, rewrites recorded URLs and bodies after tokens are
+	// scrubbed, for example to replace node IDs and names.
+	Scrub func([]byte) []byte
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates a Recorder that sends requests with base, or with
+// http.DefaultTransport if base is nil.
+func NewRecorder(base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{Base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	sent := req.Clone(req.Context())
+	if body != nil {
+		sent.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := r.Base.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	header := make(http.Header)
+	for _, name := range recordedHeaders {
+		if values := resp.Header.Values(name); len(values) > 0 {
+			header[name] = values
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:  RecordedRequest{Method: req.Method, URL: string(r.scrub([]byte(req.URL.String()))), Body: r.scrub(body)},
+		Response: RecordedResponse{Status: resp.StatusCode, Header: header, Body: r.scrub(respBody)},
+	})
+	return resp, nil
+}
+
+// scrub removes tokens from data, then applies Scrub. Empty data stays empty.
+func (r *Recorder) scrub(data []byte) []byte {
+	data = scrub(data)
+	if r.Scrub == nil || len(data) == 0 {
+		return data
+	}
+	return r.Scrub(data)
+}
+
+// Save writes the exchanges recorded so far to a cassette file.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Replayer is an http.RoundTripper that answers requests from a cassette
+// instead of the network. Each request gets the response of the first unused
+// interaction with the same method, URL and body, so a change to a query
+// fails with an error rather than silently reading a stale response.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer loads the cassette file at path.
+func NewReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &Replayer{
+		interactions: cassette.Interactions,
+		used:         make([]bool, len(cassette.Interactions)),
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	// Compare the body as it would have been stored
+	want := scrub(body)
+	if isJSONDocument(want) {
+		want = compactJSON(want)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.interactions {
+		recorded := interaction.Request
+		if r.used[i] || recorded.Method != req.Method || recorded.URL != req.URL.String() || !bytes.Equal(recorded.Body, want) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded response for %s %s with this body; record the cassette again", req.Method, req.URL)
+}
+
+// Unused returns the recorded interactions that no request has replayed yet.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+// readRequestBody reads and closes the body of req, which may be nil.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
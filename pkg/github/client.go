@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v66/github"
@@ -18,51 +20,207 @@ import (
 type Client struct {
 	REST    *github.Client
 	GraphQL *githubv4.Client
+
+	host      string
+	transport *Transport
+	// httpClient and graphqlURL send batched mutations, which the GraphQL
+	// client cannot express.
+	httpClient *http.Client
+	graphqlURL string
+
+	// subIssues caches SupportsSubIssues.
+	subIssuesMu sync.Mutex
+	subIssues   *bool
 }
 
+// DefaultHost is the host of github.com.
+const DefaultHost = "github.com"
+
 // NewClient creates a new GitHub client with both REST and GraphQL capabilities
 func NewClient() (*Client, error) {
-	token, err := GetToken()
+	return NewClientForHost(DefaultHost)
+}
+
+// NewClientForHost creates a client for a GitHub host, either github.com or a
+// GitHub Enterprise Server such as "github.example.com". See Endpoints.
+func NewClientForHost(host string) (*Client, error) {
+	if _, _, err := Endpoints(host); err != nil {
+		return nil, err
+	}
+	token, err := GetTokenForHost(host)
 	if err != nil {
 		return nil, err
 	}
-	var httpClient *http.Client
-
+	var ts oauth2.TokenSource
 	if token != "" {
 		// Create an OAuth2 token source
-		ts := oauth2.StaticTokenSource(
+		ts = oauth2.StaticTokenSource(
 			&oauth2.Token{AccessToken: token},
 		)
-		httpClient = oauth2.NewClient(context.Background(), ts)
-	} else {
-		httpClient = http.DefaultClient
 	}
+	return NewClientWithTokenSource(host, ts)
+}
+
+// NewClientWithTokenSource creates a client for a GitHub host that authenticates
+// with the tokens of ts, such as a GitHub App installation. A nil ts sends
+// unauthenticated requests.
+func NewClientWithTokenSource(host string, ts oauth2.TokenSource) (*Client, error) {
+	return NewClientWithTransport(host, TokenTransport(ts))
+}
 
+// TokenTransport returns a transport that authenticates requests with the
+// tokens of ts, or nil (http.DefaultTransport) if ts is nil.
+func TokenTransport(ts oauth2.TokenSource) http.RoundTripper {
+	if ts == nil {
+		return nil
+	}
+	return oauth2.NewClient(context.Background(), ts).Transport
+}
+
+// NewClientWithTransport creates a client for a GitHub host that sends its
+// requests with base, such as a Recorder wrapping TokenTransport, or a Replayer
+// in tests. Retries of rate limited and failed requests are added above base.
+func NewClientWithTransport(host string, base http.RoundTripper) (*Client, error) {
+	_, graphqlURL, err := Endpoints(host)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retry rate limited and failed requests instead of failing the whole plan
+	transport := NewTransport(base)
+	transport.OnWait = func(wait time.Duration, reason string) {
+		fmt.Fprintf(os.Stderr, "GitHub %s, retrying in %s\n", reason, wait.Round(time.Second))
+	}
+	httpClient := &http.Client{Transport: transport}
+
+	rest, err := newRESTClient(host, httpClient)
+	if err != nil {
+		return nil, err
+	}
 	return &Client{
-		REST:    github.NewClient(httpClient),
-		GraphQL: githubv4.NewClient(httpClient),
+		REST:       rest,
+		GraphQL:    githubv4.NewEnterpriseClient(graphqlURL, httpClient),
+		host:       host,
+		transport:  transport,
+		httpClient: httpClient,
+		graphqlURL: graphqlURL,
 	}, nil
 }
 
-// GetToken retrieves the GitHub token from the environment or `gh` CLI
-func GetToken() (string, error) {
-	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-		return token, nil
+// Host returns the GitHub host the client talks to.
+func (c *Client) Host() string {
+	if c.host == "" {
+		return DefaultHost
+	}
+	return c.host
+}
+
+// newRESTClient creates a go-github client for the REST API of host.
+func newRESTClient(host string, httpClient *http.Client) (*github.Client, error) {
+	restURL, graphqlURL, err := Endpoints(host)
+	if err != nil {
+		return nil, err
+	}
+	rest := github.NewClient(httpClient)
+	if graphqlURL == defaultGraphQLURL {
+		return rest, nil
+	}
+	rest, err = rest.WithEnterpriseURLs(restURL, strings.TrimSuffix(restURL, "v3/")+"uploads/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub host %q: %w", host, err)
 	}
+	return rest, nil
+}
 
-	cmd := exec.Command("gh", "auth", "token")
-	output, err := cmd.Output()
+// Endpoints returns the REST and GraphQL API URLs of a GitHub host. An empty
+// host is github.com. On any other host, a GitHub Enterprise Server, REST is
+// served under /api/v3 and GraphQL at /api/graphql. The host may include a
+// scheme, such as the URL of a local httptest server; it defaults to https.
+func Endpoints(host string) (restURL, graphqlURL string, err error) {
+	if host == "" || host == DefaultHost {
+		return "https://api.github.com/", defaultGraphQLURL, nil
+	}
+	base, err := hostURL(host)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
+	return base + "/api/v3/", base + "/api/graphql", nil
+}
 
-	return strings.TrimSpace(string(output)), nil
+// hostURL returns the base URL of a GitHub Enterprise Server host.
+func hostURL(host string) (string, error) {
+	raw := host
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid GitHub host %q", host)
+	}
+	return u.Scheme + "://" + u.Host + strings.TrimSuffix(u.Path, "/"), nil
 }
 
-// GetAuthenticatedUser returns information about the authenticated user
-func (c *Client) GetAuthenticatedUser(ctx context.Context) (*github.User, error) {
-	user, _, err := c.REST.Users.Get(ctx, "")
-	return user, err
+// SameHost reports whether a and b name the same GitHub host, ignoring scheme
+// and path. An empty host is github.com.
+func SameHost(a, b string) bool {
+	return strings.EqualFold(hostname(a), hostname(b))
+}
+
+// hostname returns the host name the gh CLI knows a host by, without scheme or path.
+func hostname(host string) string {
+	if host == "" {
+		return DefaultHost
+	}
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return host
+}
+
+// RateLimit returns the most depleted rate limit budget GitHub has reported to
+// this client, or the zero RateLimit if none is known.
+func (c *Client) RateLimit() RateLimit {
+	if c.transport == nil {
+		return RateLimit{}
+	}
+	return c.transport.RateLimit()
+}
+
+// GetToken retrieves the github.com token from the environment or `gh` CLI
+func GetToken() (string, error) {
+	return GetTokenForHost(DefaultHost)
+}
+
+// GetTokenForHost retrieves the token for a GitHub host from the environment,
+// the gh CLI or the OS keyring. See ResolveCredential; the caller chose host,
+// so it counts as the configured host.
+func GetTokenForHost(host string) (string, error) {
+	credential, err := ResolveCredential(CredentialOptions{Host: host, ConfiguredHost: host})
+	if err != nil {
+		return "", err
+	}
+	return credential.Token, nil
+}
+
+// GetAuthenticatedUser returns information about the authenticated user and the
+// OAuth scopes of the token. Scopes is nil for tokens without scopes, such as
+// fine-grained personal access tokens, whose permissions GitHub does not report.
+func (c *Client) GetAuthenticatedUser(ctx context.Context) (*github.User, []string, error) {
+	user, resp, err := c.REST.Users.Get(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	header, ok := resp.Header["X-Oauth-Scopes"]
+	if !ok {
+		return user, nil, nil
+	}
+	scopes := []string{}
+	for _, scope := range strings.Split(strings.Join(header, ","), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return user, scopes, nil
 }
 
 type RepositoryIDQuery struct {
@@ -84,107 +242,580 @@ func (c *Client) GetRepositoryID(ctx context.Context, owner, name string) (strin
 	return query.Repository.ID, nil
 }
 
+// projectV2Connection is a page of projects owned by a user or organization.
+type projectV2Connection struct {
+	Nodes []struct {
+		ID    string
+		Title string
+	}
+	PageInfo PageInfo
+}
+
+// find returns the ID of the project with the given title on this page, if any.
+func (conn projectV2Connection) find(title string) string {
+	for _, p := range conn.Nodes {
+		if p.Title == title {
+			return p.ID
+		}
+	}
+	return ""
+}
+
 type ProjectV2IDUserQuery struct {
 	User struct {
-		ProjectsV2 struct {
-			Nodes []struct {
-				ID    string
-				Title string
-			}
-		} `graphql:"projectsV2(first: 100)"`
+		ProjectsV2 projectV2Connection `graphql:"projectsV2(first: 100, after: $cursor)"`
 	} `graphql:"user(login: $owner)"`
 }
 
 type ProjectV2IDOrgQuery struct {
 	Organization struct {
-		ProjectsV2 struct {
-			Nodes []struct {
-				ID    string
-				Title string
-			}
-		} `graphql:"projectsV2(first: 100)"`
+		ProjectsV2 projectV2Connection `graphql:"projectsV2(first: 100, after: $cursor)"`
 	} `graphql:"organization(login: $owner)"`
 }
 
 func (c *Client) GetProjectV2ID(ctx context.Context, owner, title string) (string, error) {
-	// Try user first
-	var userQuery ProjectV2IDUserQuery
 	variables := map[string]interface{}{
 		"owner": githubv4.String(owner),
 	}
-	err := c.GraphQL.Query(ctx, &userQuery, variables)
-	if err == nil {
-		for _, p := range userQuery.User.ProjectsV2.Nodes {
-			if p.Title == title {
-				return p.ID, nil
-			}
+	var id string
+
+	// Try user first
+	err := paginate(ctx, c.GraphQL, variables, func(q *ProjectV2IDUserQuery) PageInfo {
+		if id = q.User.ProjectsV2.find(title); id != "" {
+			return PageInfo{}
 		}
+		return q.User.ProjectsV2.PageInfo
+	})
+	if err == nil && id != "" {
+		return id, nil
 	}
 
 	// Fall back to organization
-	var orgQuery ProjectV2IDOrgQuery
-	err = c.GraphQL.Query(ctx, &orgQuery, variables)
-	if err == nil {
-		for _, p := range orgQuery.Organization.ProjectsV2.Nodes {
-			if p.Title == title {
-				return p.ID, nil
-			}
+	err = paginate(ctx, c.GraphQL, variables, func(q *ProjectV2IDOrgQuery) PageInfo {
+		if id = q.Organization.ProjectsV2.find(title); id != "" {
+			return PageInfo{}
 		}
+		return q.Organization.ProjectsV2.PageInfo
+	})
+	if err == nil && id != "" {
+		return id, nil
 	}
 
 	return "", fmt.Errorf("project %q not found for user or organization %q", title, owner)
 }
 
-func (c *Client) GetOrCreateMilestone(ctx context.Context, owner, repo, title, description, dueOn string) (*github.Milestone, error) {
-	milestones, _, err := c.REST.Issues.ListMilestones(ctx, owner, repo, &github.MilestoneListOptions{})
+// ListMilestones returns every milestone (open and closed) of the given repo.
+func (c *Client) ListMilestones(ctx context.Context, owner, repo string) ([]*github.Milestone, error) {
+	var all []*github.Milestone
+	opts := &github.MilestoneListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: restPerPage},
+	}
+	err := paginateREST(func(page int) (*github.Response, error) {
+		opts.Page = page
+		milestones, resp, err := c.REST.Issues.ListMilestones(ctx, owner, repo, opts)
+		all = append(all, milestones...)
+		return resp, err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return all, nil
+}
 
-	for _, m := range milestones {
-		if m.GetTitle() == title {
-			return m, nil
-		}
+func (c *Client) CreateMilestone(ctx context.Context, owner, repo string, milestone *github.Milestone) (*github.Milestone, error) {
+	created, _, err := c.REST.Issues.CreateMilestone(ctx, owner, repo, milestone)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// EditMilestone updates the milestone with the given number. Fields of milestone
+// left nil are not changed.
+func (c *Client) EditMilestone(ctx context.Context, owner, repo string, number int, milestone *github.Milestone) (*github.Milestone, error) {
+	edited, _, err := c.REST.Issues.EditMilestone(ctx, owner, repo, number, milestone)
+	if err != nil {
+		return nil, err
 	}
+	return edited, nil
+}
 
-	var dueOnTimestamp *github.Timestamp
-	if dueOn != "" {
-		t, err := time.Parse("2006-01-02", dueOn)
+// FindIssueByTitle searches for an open issue with the exact title in the given repo.
+// Returns the issue number and node ID if found, or 0/"" if not found.
+// Every page of hits is checked, since a search for a short title can match many issues.
+func (c *Client) FindIssueByTitle(ctx context.Context, owner, repo, title string) (int, string, error) {
+	query := fmt.Sprintf("repo:%s/%s is:issue is:open in:title %q", owner, repo, title)
+	opts := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: restPerPage}}
+	var found *github.Issue
+	err := paginateREST(func(page int) (*github.Response, error) {
+		opts.Page = page
+		result, resp, err := c.REST.Search.Issues(ctx, query, opts)
 		if err != nil {
 			return nil, err
 		}
-		dueOnTimestamp = &github.Timestamp{Time: t}
+		for _, issue := range result.Issues {
+			if issue.GetTitle() == title {
+				found = issue
+				// Stop paging
+				return &github.Response{}, nil
+			}
+		}
+		return resp, nil
+	})
+	if err != nil || found == nil {
+		return 0, "", err
+	}
+	return found.GetNumber(), found.GetNodeID(), nil
+}
+
+// ListIssues returns every issue (open and closed) in the given repo, excluding pull requests.
+func (c *Client) ListIssues(ctx context.Context, owner, repo string) ([]*github.Issue, error) {
+	var all []*github.Issue
+	opts := &github.IssueListByRepoOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: restPerPage},
+	}
+	err := paginateREST(func(page int) (*github.Response, error) {
+		opts.Page = page
+		issues, resp, err := c.REST.Issues.ListByRepo(ctx, owner, repo, opts)
+		for _, issue := range issues {
+			if !issue.IsPullRequest() {
+				all = append(all, issue)
+			}
+		}
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+type IssueDetailsQuery struct {
+	Repository struct {
+		Issue struct {
+			ID        string
+			Number    int
+			Title     string
+			Body      string
+			State     string
+			Milestone struct {
+				Title string
+			}
+			Labels       issueLabelConnection       `graphql:"labels(first: 100)"`
+			Assignees    issueAssigneeConnection    `graphql:"assignees(first: 100)"`
+			ProjectItems issueProjectItemConnection `graphql:"projectItems(first: 100)"`
+		} `graphql:"issue(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+type issueLabelConnection struct {
+	Nodes []struct {
+		Name string
+	}
+	PageInfo PageInfo
+}
+
+// issueAssigneeConnection is read in one page, since GitHub allows at most 10
+// assignees. PageInfo is checked so a longer list is an error, not a silent cut.
+type issueAssigneeConnection struct {
+	Nodes []struct {
+		Login string
+	}
+	PageInfo PageInfo
+}
+
+// logins returns the assignees' logins, or an error if there is another page.
+func (conn issueAssigneeConnection) logins(number int) ([]string, error) {
+	if conn.PageInfo.HasNextPage {
+		return nil, fmt.Errorf("issue #%d has more than 100 assignees", number)
+	}
+	var logins []string
+	for _, a := range conn.Nodes {
+		logins = append(logins, a.Login)
+	}
+	return logins, nil
+}
+
+// projectV2ItemFieldValueConnection is read in one page, since a project has at
+// most 50 fields. Like issueAssigneeConnection, another page is an error.
+type projectV2ItemFieldValueConnection struct {
+	Nodes    []projectV2ItemFieldValue
+	PageInfo PageInfo
+}
+
+// values returns the field values by field name, or an error if there is
+// another page.
+func (conn projectV2ItemFieldValueConnection) values(itemID string) (map[string]string, error) {
+	if conn.PageInfo.HasNextPage {
+		return nil, fmt.Errorf("project item %s has more than 100 field values", itemID)
+	}
+	fields := make(map[string]string)
+	for _, v := range conn.Nodes {
+		if name, value := v.nameAndValue(); name != "" {
+			fields[name] = value
+		}
 	}
+	return fields, nil
+}
+
+type issueProjectItemConnection struct {
+	Nodes    []issueProjectItem
+	PageInfo PageInfo
+}
 
-	newMilestone := &github.Milestone{
-		Title:       github.String(title),
-		Description: github.String(description),
-		DueOn:       dueOnTimestamp,
+// issueProjectItem is a Project V2 item of an issue.
+type issueProjectItem struct {
+	ID      string
+	Project struct {
+		ID string
 	}
+	Status struct {
+		ProjectV2ItemFieldSingleSelectValue struct {
+			Name string
+		} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+	} `graphql:"fieldValueByName(name: \"Status\")"`
+	FieldValues projectV2ItemFieldValueConnection `graphql:"fieldValues(first: 100)"`
+}
 
-	createdMilestone, _, err := c.REST.Issues.CreateMilestone(ctx, owner, repo, newMilestone)
+// ref converts the item to a ProjectItemRef.
+func (item issueProjectItem) ref() (ProjectItemRef, error) {
+	fields, err := item.FieldValues.values(item.ID)
+	if err != nil {
+		return ProjectItemRef{}, err
+	}
+	return ProjectItemRef{
+		ID:        item.ID,
+		ProjectID: item.Project.ID,
+		Status:    item.Status.ProjectV2ItemFieldSingleSelectValue.Name,
+		Fields:    fields,
+	}, nil
+}
+
+// IssueLabelsQuery reads the labels of an issue after the first page.
+type IssueLabelsQuery struct {
+	Repository struct {
+		Issue struct {
+			Labels issueLabelConnection `graphql:"labels(first: 100, after: $cursor)"`
+		} `graphql:"issue(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+// IssueProjectItemsQuery reads the project items of an issue after the first page.
+type IssueProjectItemsQuery struct {
+	Repository struct {
+		Issue struct {
+			ProjectItems issueProjectItemConnection `graphql:"projectItems(first: 100, after: $cursor)"`
+		} `graphql:"issue(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+// IssueDetails is the current state of an issue as read from GitHub.
+type IssueDetails struct {
+	ID           string
+	Number       int
+	Title        string
+	Body         string
+	State        string
+	Milestone    string
+	Labels       []string
+	Assignees    []string
+	ProjectItems []ProjectItemRef
+	// ParentID and ParentNumber identify the issue's parent when it is a sub-issue.
+	ParentID     string
+	ParentNumber int
+}
+
+// projectV2FieldName selects the name of the field a value belongs to.
+type projectV2FieldName struct {
+	ProjectV2FieldCommon struct {
+		Name string
+	} `graphql:"... on ProjectV2FieldCommon"`
+}
+
+// projectV2ItemFieldValue is one field value of a Project V2 item. The GraphQL
+// client decodes shared fields such as Field into every fragment, so Typename
+// tells which fragment the value really is.
+type projectV2ItemFieldValue struct {
+	Typename string `graphql:"__typename"`
+	Text     struct {
+		Text  string
+		Field projectV2FieldName
+	} `graphql:"... on ProjectV2ItemFieldTextValue"`
+	Number struct {
+		Number float64
+		Field  projectV2FieldName
+	} `graphql:"... on ProjectV2ItemFieldNumberValue"`
+	Date struct {
+		Date  string
+		Field projectV2FieldName
+	} `graphql:"... on ProjectV2ItemFieldDateValue"`
+	SingleSelect struct {
+		Name  string
+		Field projectV2FieldName
+	} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+	Iteration struct {
+		Title string
+		Field projectV2FieldName
+	} `graphql:"... on ProjectV2ItemFieldIterationValue"`
+}
+
+// nameAndValue returns the field name and the value formatted as FormatFieldValue
+// would, or "" for value types the tool does not manage.
+func (v projectV2ItemFieldValue) nameAndValue() (string, string) {
+	switch v.Typename {
+	case "ProjectV2ItemFieldTextValue":
+		return v.Text.Field.ProjectV2FieldCommon.Name, v.Text.Text
+	case "ProjectV2ItemFieldNumberValue":
+		return v.Number.Field.ProjectV2FieldCommon.Name, strconv.FormatFloat(v.Number.Number, 'f', -1, 64)
+	case "ProjectV2ItemFieldDateValue":
+		date := v.Date.Date
+		if len(date) > len("2006-01-02") {
+			date = date[:len("2006-01-02")]
+		}
+		return v.Date.Field.ProjectV2FieldCommon.Name, date
+	case "ProjectV2ItemFieldSingleSelectValue":
+		return v.SingleSelect.Field.ProjectV2FieldCommon.Name, v.SingleSelect.Name
+	case "ProjectV2ItemFieldIterationValue":
+		return v.Iteration.Field.ProjectV2FieldCommon.Name, v.Iteration.Title
+	}
+	return "", ""
+}
+
+// ProjectItemRef is a Project V2 item that an issue belongs to, along with its status.
+// Fields holds the item's text, number, date, single-select and iteration values by field name.
+type ProjectItemRef struct {
+	ID        string
+	ProjectID string
+	Status    string
+	Fields    map[string]string
+}
+
+// ProjectItem returns the item linking the issue to the given project, if any.
+func (d *IssueDetails) ProjectItem(projectID string) (ProjectItemRef, bool) {
+	for _, item := range d.ProjectItems {
+		if item.ProjectID == projectID {
+			return item, true
+		}
+	}
+	return ProjectItemRef{}, false
+}
+
+// GetIssue reads the body, labels, assignees, milestone, parent and project items of an issue.
+func (c *Client) GetIssue(ctx context.Context, owner, repo string, number int) (*IssueDetails, error) {
+	var query IssueDetailsQuery
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"name":   githubv4.String(repo),
+		"number": githubv4.Int(number),
+	}
+	err := c.GraphQL.Query(ctx, &query, variables)
 	if err != nil {
 		return nil, err
 	}
 
-	return createdMilestone, nil
+	issue := query.Repository.Issue
+	details := &IssueDetails{
+		ID:        issue.ID,
+		Number:    issue.Number,
+		Title:     issue.Title,
+		Body:      issue.Body,
+		State:     issue.State,
+		Milestone: issue.Milestone.Title,
+	}
+	if details.Assignees, err = issue.Assignees.logins(number); err != nil {
+		return nil, err
+	}
+	if details.ParentID, details.ParentNumber, err = c.getParent(ctx, owner, repo, number); err != nil {
+		return nil, err
+	}
+
+	// Labels and project items continue where the first page ended
+	addLabels := func(conn issueLabelConnection) PageInfo {
+		for _, l := range conn.Nodes {
+			details.Labels = append(details.Labels, l.Name)
+		}
+		return conn.PageInfo
+	}
+	if page := addLabels(issue.Labels); page.HasNextPage {
+		variables["cursor"] = githubv4.NewString(page.EndCursor)
+		err := paginate(ctx, c.GraphQL, variables, func(q *IssueLabelsQuery) PageInfo {
+			return addLabels(q.Repository.Issue.Labels)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	// A truncated item stops the paging and is reported afterwards
+	var refErr error
+	addProjectItems := func(conn issueProjectItemConnection) PageInfo {
+		for _, item := range conn.Nodes {
+			ref, err := item.ref()
+			if err != nil {
+				refErr = err
+				return PageInfo{}
+			}
+			details.ProjectItems = append(details.ProjectItems, ref)
+		}
+		return conn.PageInfo
+	}
+	if page := addProjectItems(issue.ProjectItems); page.HasNextPage {
+		variables["cursor"] = githubv4.NewString(page.EndCursor)
+		err := paginate(ctx, c.GraphQL, variables, func(q *IssueProjectItemsQuery) PageInfo {
+			return addProjectItems(q.Repository.Issue.ProjectItems)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if refErr != nil {
+		return nil, refErr
+	}
+	return details, nil
 }
 
-// FindIssueByTitle searches for an open issue with the exact title in the given repo.
-// Returns the issue number and node ID if found, or 0/"" if not found.
-func (c *Client) FindIssueByTitle(ctx context.Context, owner, repo, title string) (int, string, error) {
-	issues, _, err := c.REST.Search.Issues(ctx, fmt.Sprintf("repo:%s/%s is:issue is:open in:title %q", owner, repo, title), &github.SearchOptions{
-		ListOptions: github.ListOptions{PerPage: 10},
+type ProjectV2ItemsQuery struct {
+	Node struct {
+		ProjectV2 struct {
+			Items struct {
+				Nodes []struct {
+					ID     string
+					Status struct {
+						ProjectV2ItemFieldSingleSelectValue struct {
+							Name string
+						} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+					} `graphql:"fieldValueByName(name: \"Status\")"`
+					FieldValues projectV2ItemFieldValueConnection `graphql:"fieldValues(first: 100)"`
+					Content     struct {
+						Issue struct {
+							ID         string
+							Number     int
+							Title      string
+							Body       string
+							State      string
+							Repository struct {
+								NameWithOwner string
+							}
+							Milestone struct {
+								Title       string
+								Description string
+								DueOn       *githubv4.DateTime
+							}
+							Labels    issueLabelConnection    `graphql:"labels(first: 100)"`
+							Assignees issueAssigneeConnection `graphql:"assignees(first: 100)"`
+						} `graphql:"... on Issue"`
+					}
+				}
+				PageInfo PageInfo
+			} `graphql:"items(first: 100, after: $cursor)"`
+		} `graphql:"... on ProjectV2"`
+	} `graphql:"node(id: $projectID)"`
+}
+
+// ProjectV2Item is an issue on a Project V2 board. Issue.ProjectItems holds just
+// this item. The milestone's description and due date (YYYY-MM-DD, empty if
+// unset) are included so the milestone can be described without another query.
+type ProjectV2Item struct {
+	Repository           string
+	Issue                IssueDetails
+	MilestoneDescription string
+	MilestoneDueOn       string
+}
+
+// ListProjectV2Items reads every issue on a project, following pagination,
+// including labels past the first page. Draft issues and pull requests are skipped.
+func (c *Client) ListProjectV2Items(ctx context.Context, projectID githubv4.ID) ([]ProjectV2Item, error) {
+	var items []ProjectV2Item
+	variables := map[string]interface{}{
+		"projectID": projectID,
+	}
+	// moreLabels maps an index into items to the cursor after its first page of labels
+	moreLabels := make(map[int]githubv4.String)
+	var itemErr error
+	err := paginate(ctx, c.GraphQL, variables, func(query *ProjectV2ItemsQuery) PageInfo {
+		page := query.Node.ProjectV2.Items
+		for _, node := range page.Nodes {
+			issue := node.Content.Issue
+			if issue.Number == 0 {
+				continue
+			}
+			item := ProjectV2Item{
+				Repository: issue.Repository.NameWithOwner,
+				Issue: IssueDetails{
+					ID:        issue.ID,
+					Number:    issue.Number,
+					Title:     issue.Title,
+					Body:      issue.Body,
+					State:     issue.State,
+					Milestone: issue.Milestone.Title,
+				},
+				MilestoneDescription: issue.Milestone.Description,
+			}
+			if issue.Milestone.DueOn != nil {
+				item.MilestoneDueOn = issue.Milestone.DueOn.UTC().Format("2006-01-02")
+			}
+			for _, l := range issue.Labels.Nodes {
+				item.Issue.Labels = append(item.Issue.Labels, l.Name)
+			}
+			if issue.Labels.PageInfo.HasNextPage {
+				moreLabels[len(items)] = issue.Labels.PageInfo.EndCursor
+			}
+			var err error
+			if item.Issue.Assignees, err = issue.Assignees.logins(issue.Number); err != nil {
+				itemErr = err
+				return PageInfo{}
+			}
+			fields, err := node.FieldValues.values(node.ID)
+			if err != nil {
+				itemErr = err
+				return PageInfo{}
+			}
+			item.Issue.ProjectItems = []ProjectItemRef{{
+				ID:        node.ID,
+				ProjectID: fmt.Sprint(projectID),
+				Status:    node.Status.ProjectV2ItemFieldSingleSelectValue.Name,
+				Fields:    fields,
+			}}
+			items = append(items, item)
+		}
+		return page.PageInfo
 	})
 	if err != nil {
-		return 0, "", err
+		return nil, err
+	}
+	if itemErr != nil {
+		return nil, itemErr
 	}
-	for _, issue := range issues.Issues {
-		if issue.GetTitle() == title {
-			return issue.GetNumber(), issue.GetNodeID(), nil
+	for i, cursor := range moreLabels {
+		if err := c.addLabels(ctx, &items[i], cursor); err != nil {
+			return nil, err
 		}
 	}
-	return 0, "", nil
+	if err := c.setParents(ctx, projectID, items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// addLabels reads the labels of a board item's issue after cursor.
+func (c *Client) addLabels(ctx context.Context, item *ProjectV2Item, cursor githubv4.String) error {
+	owner, repo, ok := strings.Cut(item.Repository, "/")
+	if !ok {
+		return fmt.Errorf("invalid repository %q", item.Repository)
+	}
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"name":   githubv4.String(repo),
+		"number": githubv4.Int(item.Issue.Number),
+		"cursor": githubv4.NewString(cursor),
+	}
+	return paginate(ctx, c.GraphQL, variables, func(q *IssueLabelsQuery) PageInfo {
+		for _, l := range q.Repository.Issue.Labels.Nodes {
+			item.Issue.Labels = append(item.Issue.Labels, l.Name)
+		}
+		return q.Repository.Issue.Labels.PageInfo
+	})
 }
 
 type CreateIssueMutation struct {
@@ -206,6 +837,249 @@ func (c *Client) CreateIssue(ctx context.Context, input githubv4.CreateIssueInpu
 	return &mutation, nil
 }
 
+type UpdateIssueMutation struct {
+	UpdateIssue struct {
+		Issue struct {
+			ID githubv4.ID
+		}
+	} `graphql:"updateIssue(input: $input)"`
+}
+
+// UpdateIssue changes the fields of an existing issue that are set in input.
+func (c *Client) UpdateIssue(ctx context.Context, input githubv4.UpdateIssueInput) error {
+	var mutation UpdateIssueMutation
+	return c.GraphQL.Mutate(ctx, &mutation, input, nil)
+}
+
+type CloseIssueMutation struct {
+	CloseIssue struct {
+		Issue struct {
+			ID githubv4.ID
+		}
+	} `graphql:"closeIssue(input: $input)"`
+}
+
+// CloseIssue closes an issue with the given state reason (e.g. NOT_PLANNED).
+func (c *Client) CloseIssue(ctx context.Context, issueID githubv4.ID, reason githubv4.IssueClosedStateReason) error {
+	var mutation CloseIssueMutation
+	input := githubv4.CloseIssueInput{
+		IssueID:     issueID,
+		StateReason: &reason,
+	}
+	return c.GraphQL.Mutate(ctx, &mutation, input, nil)
+}
+
+// AddSubIssueInput is the input to the addSubIssue mutation, which githubv4 does not define.
+type AddSubIssueInput struct {
+	IssueID       githubv4.ID       `json:"issueId"`
+	SubIssueID    githubv4.ID       `json:"subIssueId"`
+	ReplaceParent *githubv4.Boolean `json:"replaceParent,omitempty"`
+}
+
+type AddSubIssueMutation struct {
+	AddSubIssue struct {
+		Issue struct {
+			ID githubv4.ID
+		}
+	} `graphql:"addSubIssue(input: $input)"`
+}
+
+// AddSubIssue makes subIssueID a sub-issue of issueID. With replaceParent set, a
+// sub-issue that already has a different parent is moved instead of rejected.
+func (c *Client) AddSubIssue(ctx context.Context, issueID, subIssueID githubv4.ID, replaceParent bool) error {
+	var mutation AddSubIssueMutation
+	input := AddSubIssueInput{
+		IssueID:    issueID,
+		SubIssueID: subIssueID,
+	}
+	if replaceParent {
+		replace := githubv4.Boolean(true)
+		input.ReplaceParent = &replace
+	}
+	return c.GraphQL.Mutate(ctx, &mutation, input, nil)
+}
+
+// SubIssuesQuery checks whether the server's schema has sub-issues, which
+// GitHub Enterprise Server may not.
+type SubIssuesQuery struct {
+	Type *struct {
+		Name string
+	} `graphql:"__type(name: \"AddSubIssueInput\")"`
+}
+
+// SupportsSubIssues reports whether the server supports sub-issues (the
+// addSubIssue mutation and Issue.parent). The answer is cached on the client.
+func (c *Client) SupportsSubIssues(ctx context.Context) (bool, error) {
+	c.subIssuesMu.Lock()
+	defer c.subIssuesMu.Unlock()
+	if c.subIssues != nil {
+		return *c.subIssues, nil
+	}
+	var query SubIssuesQuery
+	if err := c.GraphQL.Query(ctx, &query, nil); err != nil {
+		return false, err
+	}
+	supported := query.Type != nil
+	c.subIssues = &supported
+	return supported, nil
+}
+
+// IssueParentQuery is kept separate from IssueDetailsQuery so that GetIssue works
+// on servers without sub-issues.
+type IssueParentQuery struct {
+	Repository struct {
+		Issue struct {
+			Parent struct {
+				ID     string
+				Number int
+			}
+		} `graphql:"issue(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+// getParent returns the ID and number of an issue's parent, or zero values if
+// it has none or the server has no sub-issues.
+func (c *Client) getParent(ctx context.Context, owner, repo string, number int) (string, int, error) {
+	if ok, err := c.SupportsSubIssues(ctx); err != nil || !ok {
+		return "", 0, err
+	}
+	var query IssueParentQuery
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"name":   githubv4.String(repo),
+		"number": githubv4.Int(number),
+	}
+	if err := c.GraphQL.Query(ctx, &query, variables); err != nil {
+		return "", 0, err
+	}
+	parent := query.Repository.Issue.Parent
+	return parent.ID, parent.Number, nil
+}
+
+// ProjectV2ItemParentsQuery reads the parent of every issue on a project. Like
+// IssueParentQuery it is only sent to servers with sub-issues.
+type ProjectV2ItemParentsQuery struct {
+	Node struct {
+		ProjectV2 struct {
+			Items struct {
+				Nodes []struct {
+					ID      string
+					Content struct {
+						Issue struct {
+							Parent struct {
+								ID     string
+								Number int
+							}
+						} `graphql:"... on Issue"`
+					}
+				}
+				PageInfo PageInfo
+			} `graphql:"items(first: 100, after: $cursor)"`
+		} `graphql:"... on ProjectV2"`
+	} `graphql:"node(id: $projectID)"`
+}
+
+// setParents fills in the parents of items, keyed by project item ID.
+func (c *Client) setParents(ctx context.Context, projectID githubv4.ID, items []ProjectV2Item) error {
+	if ok, err := c.SupportsSubIssues(ctx); err != nil || !ok {
+		return err
+	}
+	byItem := make(map[string]*IssueDetails, len(items))
+	for i := range items {
+		byItem[items[i].Issue.ProjectItems[0].ID] = &items[i].Issue
+	}
+	variables := map[string]interface{}{
+		"projectID": projectID,
+	}
+	return paginate(ctx, c.GraphQL, variables, func(query *ProjectV2ItemParentsQuery) PageInfo {
+		page := query.Node.ProjectV2.Items
+		for _, node := range page.Nodes {
+			if issue, ok := byItem[node.ID]; ok {
+				issue.ParentID = node.Content.Issue.Parent.ID
+				issue.ParentNumber = node.Content.Issue.Parent.Number
+			}
+		}
+		return page.PageInfo
+	})
+}
+
+// IssueDependenciesQuery checks whether the server's schema has native issue
+// dependencies, which GitHub Enterprise Server may not.
+type IssueDependenciesQuery struct {
+	Type *struct {
+		Name string
+	} `graphql:"__type(name: \"AddBlockedByInput\")"`
+}
+
+// SupportsIssueDependencies reports whether the server supports native issue
+// dependencies (the addBlockedBy mutation).
+func (c *Client) SupportsIssueDependencies(ctx context.Context) (bool, error) {
+	var query IssueDependenciesQuery
+	if err := c.GraphQL.Query(ctx, &query, nil); err != nil {
+		return false, err
+	}
+	return query.Type != nil, nil
+}
+
+// BlockedByQuery is kept separate from IssueDetailsQuery so that GetIssue works
+// on servers without issue dependencies.
+type BlockedByQuery struct {
+	Repository struct {
+		Issue struct {
+			BlockedBy struct {
+				Nodes []struct {
+					Number int
+				}
+				PageInfo PageInfo
+			} `graphql:"blockedBy(first: 100, after: $cursor)"`
+		} `graphql:"issue(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+// GetBlockedBy returns the numbers of the issues blocking an issue.
+func (c *Client) GetBlockedBy(ctx context.Context, owner, repo string, number int) ([]int, error) {
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"name":   githubv4.String(repo),
+		"number": githubv4.Int(number),
+	}
+	var numbers []int
+	err := paginate(ctx, c.GraphQL, variables, func(query *BlockedByQuery) PageInfo {
+		for _, n := range query.Repository.Issue.BlockedBy.Nodes {
+			numbers = append(numbers, n.Number)
+		}
+		return query.Repository.Issue.BlockedBy.PageInfo
+	})
+	if err != nil {
+		return nil, err
+	}
+	return numbers, nil
+}
+
+// AddBlockedByInput is the input to the addBlockedBy mutation, which githubv4 does not define.
+type AddBlockedByInput struct {
+	IssueID         githubv4.ID `json:"issueId"`
+	BlockingIssueID githubv4.ID `json:"blockingIssueId"`
+}
+
+type AddBlockedByMutation struct {
+	AddBlockedBy struct {
+		Issue struct {
+			ID githubv4.ID
+		}
+	} `graphql:"addBlockedBy(input: $input)"`
+}
+
+// AddBlockedBy records that issueID is blocked by blockingIssueID.
+func (c *Client) AddBlockedBy(ctx context.Context, issueID, blockingIssueID githubv4.ID) error {
+	var mutation AddBlockedByMutation
+	input := AddBlockedByInput{
+		IssueID:         issueID,
+		BlockingIssueID: blockingIssueID,
+	}
+	return c.GraphQL.Mutate(ctx, &mutation, input, nil)
+}
+
 type AddProjectV2ItemMutation struct {
 	AddProjectV2ItemById struct {
 		Item struct {
@@ -227,6 +1101,22 @@ func (c *Client) AddIssueToProjectV2(ctx context.Context, projectID, contentID g
 	return &mutation, nil
 }
 
+type DeleteProjectV2ItemMutation struct {
+	DeleteProjectV2Item struct {
+		DeletedItemID githubv4.ID `graphql:"deletedItemId"`
+	} `graphql:"deleteProjectV2Item(input: $input)"`
+}
+
+// DeleteProjectV2Item removes an item from a project without touching the underlying issue.
+func (c *Client) DeleteProjectV2Item(ctx context.Context, projectID, itemID githubv4.ID) error {
+	var mutation DeleteProjectV2ItemMutation
+	input := githubv4.DeleteProjectV2ItemInput{
+		ProjectID: projectID,
+		ItemID:    itemID,
+	}
+	return c.GraphQL.Mutate(ctx, &mutation, input, nil)
+}
+
 type MilestoneIDQuery struct {
 	Repository struct {
 		Milestone struct {
@@ -249,7 +1139,6 @@ func (c *Client) GetMilestoneID(ctx context.Context, owner, name string, number
 	return query.Repository.Milestone.ID, nil
 }
 
-
 func (c *Client) GetOrCreateLabel(ctx context.Context, owner, repo, labelName string) (githubv4.ID, error) {
 	label, resp, err := c.REST.Issues.GetLabel(ctx, owner, repo, labelName)
 	if err != nil {
@@ -268,6 +1157,40 @@ func (c *Client) GetOrCreateLabel(ctx context.Context, owner, repo, labelName st
 	return label.GetNodeID(), nil
 }
 
+// ListLabels returns every label of the repository.
+func (c *Client) ListLabels(ctx context.Context, owner, repo string) ([]*github.Label, error) {
+	var all []*github.Label
+	opts := &github.ListOptions{PerPage: restPerPage}
+	err := paginateREST(func(page int) (*github.Response, error) {
+		opts.Page = page
+		labels, resp, err := c.REST.Issues.ListLabels(ctx, owner, repo, opts)
+		all = append(all, labels...)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+func (c *Client) CreateLabel(ctx context.Context, owner, repo string, label *github.Label) (*github.Label, error) {
+	created, _, err := c.REST.Issues.CreateLabel(ctx, owner, repo, label)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create label %s: %w", label.GetName(), err)
+	}
+	return created, nil
+}
+
+// EditLabel updates the label called name. Fields of label left nil are not
+// changed; setting its Name renames the label.
+func (c *Client) EditLabel(ctx context.Context, owner, repo, name string, label *github.Label) (*github.Label, error) {
+	edited, _, err := c.REST.Issues.EditLabel(ctx, owner, repo, name, label)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit label %s: %w", name, err)
+	}
+	return edited, nil
+}
+
 type UserIDQuery struct {
 	User struct {
 		ID githubv4.ID
@@ -300,33 +1223,161 @@ type ProjectV2FieldQuery struct {
 						} `graphql:"options"`
 					} `graphql:"... on ProjectV2SingleSelectField"`
 				}
-			} `graphql:"fields(first: 20)"`
+				PageInfo PageInfo
+			} `graphql:"fields(first: 100, after: $cursor)"`
 		} `graphql:"... on ProjectV2"`
 	} `graphql:"node(id: $projectID)"`
 }
 
 func (c *Client) GetProjectV2StatusFieldOptions(ctx context.Context, projectID githubv4.ID) (githubv4.ID, map[string]string, error) {
-	var query ProjectV2FieldQuery
 	variables := map[string]interface{}{
 		"projectID": projectID,
 	}
-	err := c.GraphQL.Query(ctx, &query, variables)
+	var fieldID githubv4.ID
+	var statusOptions map[string]string
+	err := paginate(ctx, c.GraphQL, variables, func(query *ProjectV2FieldQuery) PageInfo {
+		for _, field := range query.Node.ProjectV2.Fields.Nodes {
+			f := field.ProjectV2SingleSelectField
+			if f.Name == "Status" {
+				fieldID = githubv4.ID(f.ID)
+				statusOptions = make(map[string]string)
+				for _, option := range f.Options {
+					statusOptions[option.Name] = option.ID
+				}
+				return PageInfo{}
+			}
+		}
+		return query.Node.ProjectV2.Fields.PageInfo
+	})
 	if err != nil {
 		return nil, nil, err
 	}
+	if statusOptions == nil {
+		return nil, nil, fmt.Errorf("status field not found on project")
+	}
+	return fieldID, statusOptions, nil
+}
 
-	for _, field := range query.Node.ProjectV2.Fields.Nodes {
-		f := field.ProjectV2SingleSelectField
-		if f.Name == "Status" {
-			statusOptions := make(map[string]string)
+// Project V2 field data types that can be set on an item.
+const (
+	FieldTypeText         = "TEXT"
+	FieldTypeNumber       = "NUMBER"
+	FieldTypeDate         = "DATE"
+	FieldTypeSingleSelect = "SINGLE_SELECT"
+	FieldTypeIteration    = "ITERATION"
+)
+
+// ProjectV2Field is a field of a Project V2 board.
+// Options maps single-select option names to IDs; Iterations lists the
+// iterations of an iteration field.
+type ProjectV2Field struct {
+	ID         string
+	Name       string
+	DataType   string
+	Options    map[string]string
+	Iterations []ProjectV2Iteration
+}
+
+// ProjectV2Iteration is one iteration of an iteration field. StartDate is
+// YYYY-MM-DD and Duration is in days.
+type ProjectV2Iteration struct {
+	ID        string
+	Title     string
+	StartDate string
+	Duration  int
+	Completed bool
+}
+
+// projectV2IterationNode is an iteration as returned by the API.
+type projectV2IterationNode struct {
+	ID        string
+	Title     string
+	StartDate string
+	Duration  int
+}
+
+type ProjectV2FieldsQuery struct {
+	Node struct {
+		ProjectV2 struct {
+			Fields struct {
+				Nodes []struct {
+					ProjectV2Field struct {
+						ID       string
+						Name     string
+						DataType string
+					} `graphql:"... on ProjectV2Field"`
+					ProjectV2SingleSelectField struct {
+						ID       string
+						Name     string
+						DataType string
+						Options  []struct {
+							ID   string
+							Name string
+						}
+					} `graphql:"... on ProjectV2SingleSelectField"`
+					ProjectV2IterationField struct {
+						ID            string
+						Name          string
+						DataType      string
+						Configuration struct {
+							Iterations          []projectV2IterationNode
+							CompletedIterations []projectV2IterationNode
+						}
+					} `graphql:"... on ProjectV2IterationField"`
+				}
+				PageInfo PageInfo
+			} `graphql:"fields(first: 100, after: $cursor)"`
+		} `graphql:"... on ProjectV2"`
+	} `graphql:"node(id: $projectID)"`
+}
+
+// GetProjectV2Fields returns every field of a project, including single-select
+// options and both active and completed iterations.
+func (c *Client) GetProjectV2Fields(ctx context.Context, projectID githubv4.ID) ([]ProjectV2Field, error) {
+	variables := map[string]interface{}{
+		"projectID": projectID,
+	}
+	var fields []ProjectV2Field
+	err := paginate(ctx, c.GraphQL, variables, func(query *ProjectV2FieldsQuery) PageInfo {
+		fields = append(fields, query.fields()...)
+		return query.Node.ProjectV2.Fields.PageInfo
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// fields converts one page of the query to ProjectV2Fields. The GraphQL client
+// decodes id, name and dataType into every fragment, so the data type picks the
+// fragment that holds the rest.
+func (query *ProjectV2FieldsQuery) fields() []ProjectV2Field {
+	var fields []ProjectV2Field
+	for _, node := range query.Node.ProjectV2.Fields.Nodes {
+		switch node.ProjectV2Field.DataType {
+		case FieldTypeSingleSelect:
+			f := node.ProjectV2SingleSelectField
+			field := ProjectV2Field{ID: f.ID, Name: f.Name, DataType: f.DataType, Options: make(map[string]string)}
 			for _, option := range f.Options {
-				statusOptions[option.Name] = option.ID
+				field.Options[option.Name] = option.ID
+			}
+			fields = append(fields, field)
+		case FieldTypeIteration:
+			f := node.ProjectV2IterationField
+			field := ProjectV2Field{ID: f.ID, Name: f.Name, DataType: f.DataType}
+			for _, it := range f.Configuration.CompletedIterations {
+				field.Iterations = append(field.Iterations, ProjectV2Iteration{ID: it.ID, Title: it.Title, StartDate: it.StartDate, Duration: it.Duration, Completed: true})
+			}
+			for _, it := range f.Configuration.Iterations {
+				field.Iterations = append(field.Iterations, ProjectV2Iteration{ID: it.ID, Title: it.Title, StartDate: it.StartDate, Duration: it.Duration})
 			}
-			return githubv4.ID(f.ID), statusOptions, nil
+			fields = append(fields, field)
+		default:
+			f := node.ProjectV2Field
+			fields = append(fields, ProjectV2Field{ID: f.ID, Name: f.Name, DataType: f.DataType})
 		}
 	}
-
-	return nil, nil, fmt.Errorf("status field not found on project")
+	return fields
 }
 
 type UpdateProjectV2ItemFieldValueMutation struct {
@@ -349,3 +1400,15 @@ func (c *Client) UpdateProjectV2ItemStatus(ctx context.Context, projectID, itemI
 	err := c.GraphQL.Mutate(ctx, &mutation, input, nil)
 	return err
 }
+
+// UpdateProjectV2ItemFieldValue sets any field of a project item to value.
+func (c *Client) UpdateProjectV2ItemFieldValue(ctx context.Context, projectID, itemID, fieldID githubv4.ID, value githubv4.ProjectV2FieldValue) error {
+	var mutation UpdateProjectV2ItemFieldValueMutation
+	input := githubv4.UpdateProjectV2ItemFieldValueInput{
+		ProjectID: projectID,
+		ItemID:    itemID,
+		FieldID:   fieldID,
+		Value:     value,
+	}
+	return c.GraphQL.Mutate(ctx, &mutation, input, nil)
+}
@@ -0,0 +1,151 @@
+package github
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringService is the service name tokens are stored under in the OS keyring.
+const keyringService = "gh-project-helper"
+
+// runCommand runs a credential helper and returns its output. Tests replace it.
+var runCommand = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+// Credential is a token and the source it was found in, for reporting.
+type Credential struct {
+	Token  string
+	Source string
+}
+
+// Profile is a named token from the config file for a host and, optionally,
+// one organization or user on it. An empty Host is github.com.
+type Profile struct {
+	Name  string
+	Host  string
+	Owner string
+	Token string
+}
+
+// CredentialOptions are the places to look for a token besides the
+// environment, the gh CLI and the keyring.
+type CredentialOptions struct {
+	// Host and Owner select the profile; Owner may be empty.
+	Host  string
+	Owner string
+	// ConfiguredHost is the host set by --host, GH_HOST or the config file;
+	// empty is github.com. Environment tokens name no host, so they are only
+	// used for this one.
+	ConfiguredHost string
+	// FlagToken is the value of --token.
+	FlagToken string
+	// Profile names the profile to use instead of picking one by host and owner.
+	Profile  string
+	Profiles []Profile
+}
+
+// ResolveCredential finds the token for opts.Host, trying in order the --token
+// flag, the environment (GH_PROJECT_HELPER_TOKEN, then GITHUB_TOKEN or GH_TOKEN
+// for github.com and GH_ENTERPRISE_TOKEN or GITHUB_ENTERPRISE_TOKEN for other
+// hosts) when opts.Host is the configured host, the config file profiles,
+// `gh auth token --hostname` and the OS keyring.
+func ResolveCredential(opts CredentialOptions) (Credential, error) {
+	name := hostname(opts.Host)
+	if opts.FlagToken != "" {
+		return Credential{Token: opts.FlagToken, Source: "--token flag"}, nil
+	}
+
+	envs := []string{"GH_PROJECT_HELPER_TOKEN", "GITHUB_TOKEN", "GH_TOKEN"}
+	if name != DefaultHost {
+		envs = []string{"GH_PROJECT_HELPER_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+	if !SameHost(opts.Host, opts.ConfiguredHost) {
+		envs = nil
+	}
+	for _, env := range envs {
+		if token := os.Getenv(env); token != "" {
+			return Credential{Token: token, Source: env + " environment variable"}, nil
+		}
+	}
+
+	profile, err := matchProfile(opts)
+	if err != nil {
+		return Credential{}, err
+	}
+	if profile != nil && profile.Token != "" {
+		source := fmt.Sprintf("profile %q in config file", profile.Name)
+		if profile.Name == "" {
+			source = "token in config file"
+		}
+		return Credential{Token: profile.Token, Source: source}, nil
+	}
+
+	if output, err := runCommand("gh", "auth", "token", "--hostname", name); err == nil {
+		if token := strings.TrimSpace(string(output)); token != "" {
+			return Credential{Token: token, Source: "gh CLI"}, nil
+		}
+	}
+	if token := keyringToken(name); token != "" {
+		return Credential{Token: token, Source: "OS keyring"}, nil
+	}
+
+	return Credential{}, fmt.Errorf("no GitHub token for %s: pass --token, set GITHUB_TOKEN, add a profile to the config file or run gh auth login --hostname %s", name, name)
+}
+
+// matchProfile returns the profile named by opts.Profile, or else the profile
+// for opts.Host whose owner is opts.Owner, or else the one for opts.Host
+// without an owner. A named profile for another host is an error, so that a
+// token is never sent to the wrong host.
+func matchProfile(opts CredentialOptions) (*Profile, error) {
+	name := hostname(opts.Host)
+	if opts.Profile != "" {
+		for i, p := range opts.Profiles {
+			if p.Name != opts.Profile {
+				continue
+			}
+			if hostname(p.Host) != name {
+				return nil, fmt.Errorf("profile %q is for %s, not %s", p.Name, hostname(p.Host), name)
+			}
+			return &opts.Profiles[i], nil
+		}
+		return nil, fmt.Errorf("profile %q is not defined in the config file", opts.Profile)
+	}
+
+	var match *Profile
+	for i, p := range opts.Profiles {
+		if hostname(p.Host) != name {
+			continue
+		}
+		switch {
+		case p.Owner != "" && opts.Owner != "" && strings.EqualFold(p.Owner, opts.Owner):
+			return &opts.Profiles[i], nil
+		case p.Owner == "" && match == nil:
+			match = &opts.Profiles[i]
+		}
+	}
+	return match, nil
+}
+
+// keyringToken returns the token stored in the OS keyring for host, with the
+// service gh-project-helper and the host name as account, or "" if there is
+// none. It uses `security` on macOS and `secret-tool` on Linux.
+func keyringToken(host string) string {
+	var output []byte
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		output, err = runCommand("security", "find-generic-password", "-s", keyringService, "-a", host, "-w")
+	case "linux":
+		output, err = runCommand("secret-tool", "lookup", "service", keyringService, "host", host)
+	default:
+		return ""
+	}
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,119 @@
+package github
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// clearTokenEnv unsets every environment variable ResolveCredential reads and
+// stubs the gh CLI and keyring with the given tokens ("" for none).
+func clearTokenEnv(t *testing.T, ghToken, keyringToken string) *[]string {
+	t.Helper()
+	for _, env := range []string{"GH_PROJECT_HELPER_TOKEN", "GITHUB_TOKEN", "GH_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"} {
+		t.Setenv(env, "")
+	}
+	var commands []string
+	saved := runCommand
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		commands = append(commands, name+" "+strings.Join(args, " "))
+		token := keyringToken
+		if name == "gh" {
+			token = ghToken
+		}
+		if token == "" {
+			return nil, errors.New("not found")
+		}
+		return []byte(token + "\n"), nil
+	}
+	t.Cleanup(func() { runCommand = saved })
+	return &commands
+}
+
+func TestResolveCredential_Order(t *testing.T) {
+	profiles := []Profile{
+		{Name: "public", Token: "public-token"},
+		{Name: "work", Host: "ghes.example.com", Token: "work-token"},
+		{Name: "acme", Host: "ghes.example.com", Owner: "Acme", Token: "acme-token"},
+	}
+	clearTokenEnv(t, "gh-token", "")
+
+	tests := []struct {
+		name   string
+		opts   CredentialOptions
+		env    map[string]string
+		token  string
+		source string
+	}{
+		{"flag first", CredentialOptions{FlagToken: "flag-token", Profiles: profiles}, map[string]string{"GITHUB_TOKEN": "env-token"}, "flag-token", "--token flag"},
+		{"env before profile", CredentialOptions{Profiles: profiles}, map[string]string{"GITHUB_TOKEN": "env-token"}, "env-token", "GITHUB_TOKEN environment variable"},
+		{"enterprise env", CredentialOptions{Host: "ghes.example.com", ConfiguredHost: "ghes.example.com", Profiles: profiles}, map[string]string{"GITHUB_TOKEN": "env-token", "GH_ENTERPRISE_TOKEN": "ghes-token"}, "ghes-token", "GH_ENTERPRISE_TOKEN environment variable"},
+		{"env only for the configured host", CredentialOptions{Host: "ghes.example.com", Profiles: profiles}, map[string]string{"GH_PROJECT_HELPER_TOKEN": "env-token", "GH_ENTERPRISE_TOKEN": "ghes-token"}, "work-token", `profile "work" in config file`},
+		{"profile by host", CredentialOptions{Host: "ghes.example.com", Owner: "other", Profiles: profiles}, nil, "work-token", `profile "work" in config file`},
+		{"profile by owner", CredentialOptions{Host: "ghes.example.com", Owner: "acme", Profiles: profiles}, nil, "acme-token", `profile "acme" in config file`},
+		{"profile by name", CredentialOptions{Host: "ghes.example.com", Profile: "acme", Profiles: profiles}, nil, "acme-token", `profile "acme" in config file`},
+		{"gh CLI", CredentialOptions{Host: "other.example.com", Profiles: profiles}, nil, "gh-token", "gh CLI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for env, value := range tt.env {
+				t.Setenv(env, value)
+			}
+			credential, err := ResolveCredential(tt.opts)
+			if err != nil {
+				t.Fatalf("ResolveCredential failed: %v", err)
+			}
+			if credential.Token != tt.token || credential.Source != tt.source {
+				t.Errorf("got %q from %q, want %q from %q", credential.Token, credential.Source, tt.token, tt.source)
+			}
+		})
+	}
+}
+
+func TestResolveCredential_EnvTokenForOtherHost(t *testing.T) {
+	clearTokenEnv(t, "", "")
+	t.Setenv("GH_PROJECT_HELPER_TOKEN", "env-token")
+	credential, err := ResolveCredential(CredentialOptions{Host: "https://evil.example"})
+	if err == nil {
+		t.Errorf("expected no token for a host other than the configured one, got %+v", credential)
+	}
+	credential, err = ResolveCredential(CredentialOptions{Host: "https://evil.example", ConfiguredHost: "evil.example"})
+	if err != nil || credential.Token != "env-token" {
+		t.Errorf("expected the environment token once the host is configured, got %+v, %v", credential, err)
+	}
+}
+
+func TestResolveCredential_ProfileForOtherHost(t *testing.T) {
+	clearTokenEnv(t, "", "")
+	_, err := ResolveCredential(CredentialOptions{
+		Profile:  "work",
+		Profiles: []Profile{{Name: "work", Host: "ghes.example.com", Token: "work-token"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "is for ghes.example.com") {
+		t.Errorf("expected the profile to be refused for github.com, got %v", err)
+	}
+}
+
+func TestResolveCredential_GhCLIAndKeyring(t *testing.T) {
+	commands := clearTokenEnv(t, "", "keyring-token")
+	credential, err := ResolveCredential(CredentialOptions{Host: "http://127.0.0.1:8080"})
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		// No keyring helper on this platform
+		if err == nil {
+			t.Fatalf("expected an error without any token, got %+v", credential)
+		}
+		return
+	}
+	if err != nil || credential.Token != "keyring-token" || credential.Source != "OS keyring" {
+		t.Fatalf("expected the keyring token, got %+v, %v", credential, err)
+	}
+	if (*commands)[0] != "gh auth token --hostname 127.0.0.1:8080" {
+		t.Errorf("expected gh to be asked for the host name, got %v", *commands)
+	}
+
+	clearTokenEnv(t, "", "")
+	if _, err := ResolveCredential(CredentialOptions{}); err == nil || !strings.Contains(err.Error(), "no GitHub token for github.com") {
+		t.Errorf("expected an error without any token, got %v", err)
+	}
+}
@@ -0,0 +1,417 @@
+package fakegithub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// selection is a field or inline fragment of a GraphQL selection set.
+type selection struct {
+	// alias is the response key; it is the field name unless aliased.
+	alias string
+	name  string
+	args  map[string]interface{}
+	// on is the type condition of an inline fragment, whose selections apply
+	// only to objects of that type.
+	on         string
+	selections []selection
+}
+
+// variable is a reference to an operation variable in an argument.
+type variable string
+
+// operation is a parsed GraphQL document with a single operation.
+type operation struct {
+	mutation   bool
+	selections []selection
+}
+
+// parser parses the GraphQL documents the client sends: one query or mutation
+// with variable definitions, fields with arguments and aliases, and inline
+// fragments. Named fragments and directives are not supported.
+type parser struct {
+	src string
+	pos int
+}
+
+// parseOperation parses a GraphQL document.
+func parseOperation(src string) (*operation, error) {
+	p := &parser{src: src}
+	op := &operation{}
+	switch p.peek() {
+	case "query":
+		p.next()
+	case "mutation":
+		p.next()
+		op.mutation = true
+	}
+	if p.peek() != "{" {
+		if p.peek() != "(" {
+			// An operation name
+			p.next()
+		}
+		if err := p.skipVariableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token != "" {
+		return nil, fmt.Errorf("unexpected %q after the operation", token)
+	}
+	op.selections = selections
+	return op, nil
+}
+
+// skipVariableDefinitions skips "($a:Type!,...)": values arrive as JSON
+// variables and their types are not checked.
+func (p *parser) skipVariableDefinitions() error {
+	if p.peek() != "(" {
+		return nil
+	}
+	for {
+		switch token := p.next(); token {
+		case ")":
+			return nil
+		case "":
+			return fmt.Errorf("unterminated variable definitions")
+		}
+	}
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for p.peek() != "}" {
+		if p.peek() == "" {
+			return nil, fmt.Errorf("unterminated selection set")
+		}
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+	p.next()
+	return selections, nil
+}
+
+func (p *parser) selection() (selection, error) {
+	if p.peek() == "..." {
+		p.next()
+		if p.next() != "on" {
+			return selection{}, fmt.Errorf("only inline fragments with a type condition are supported")
+		}
+		s := selection{on: p.next()}
+		var err error
+		s.selections, err = p.selectionSet()
+		return s, err
+	}
+
+	s := selection{name: p.next()}
+	if !isName(s.name) {
+		return s, fmt.Errorf("expected a field name, got %q", s.name)
+	}
+	s.alias = s.name
+	if p.peek() == ":" {
+		p.next()
+		s.name = p.next()
+	}
+	if p.peek() == "(" {
+		p.next()
+		s.args = make(map[string]interface{})
+		for p.peek() != ")" {
+			name := p.next()
+			if err := p.expect(":"); err != nil {
+				return s, err
+			}
+			value, err := p.value()
+			if err != nil {
+				return s, err
+			}
+			s.args[name] = value
+		}
+		p.next()
+	}
+	if p.peek() == "{" {
+		var err error
+		s.selections, err = p.selectionSet()
+		if err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) value() (interface{}, error) {
+	token := p.next()
+	switch {
+	case token == "$":
+		return variable(p.next()), nil
+	case strings.HasPrefix(token, `"`):
+		return strconv.Unquote(token)
+	case token == "true" || token == "false":
+		return token == "true", nil
+	case token == "null":
+		return nil, nil
+	case token == "[":
+		var list []interface{}
+		for p.peek() != "]" {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		p.next()
+		return list, nil
+	case token == "{":
+		object := make(map[string]interface{})
+		for p.peek() != "}" {
+			name := p.next()
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			object[name] = v
+		}
+		p.next()
+		return object, nil
+	case token != "" && (token[0] == '-' || token[0] >= '0' && token[0] <= '9'):
+		if n, err := strconv.Atoi(token); err == nil {
+			return n, nil
+		}
+		return strconv.ParseFloat(token, 64)
+	case isName(token):
+		// An enum value
+		return token, nil
+	}
+	return nil, fmt.Errorf("unexpected %q in arguments", token)
+}
+
+func (p *parser) expect(want string) error {
+	if token := p.next(); token != want {
+		return fmt.Errorf("expected %q, got %q", want, token)
+	}
+	return nil
+}
+
+func (p *parser) peek() string {
+	pos := p.pos
+	token := p.next()
+	p.pos = pos
+	return token
+}
+
+// next returns the next token, or "" at the end. Commas are insignificant in
+// GraphQL and skipped like white space.
+func (p *parser) next() string {
+	for p.pos < len(p.src) && strings.ContainsRune(" \t\r\n,", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		return ""
+	}
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+	case c == '"':
+		p.pos++
+		for p.pos < len(p.src) && p.src[p.pos] != '"' {
+			if p.src[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		p.pos++
+	case c == '-' || c >= '0' && c <= '9':
+		p.pos++
+		for p.pos < len(p.src) && strings.ContainsRune("0123456789.eE+-", rune(p.src[p.pos])) {
+			p.pos++
+		}
+	case isNameByte(c):
+		p.pos++
+		for p.pos < len(p.src) && isNameByte(p.src[p.pos]) {
+			p.pos++
+		}
+	default:
+		p.pos++
+	}
+	if p.pos > len(p.src) {
+		p.pos = len(p.src)
+	}
+	return p.src[start:p.pos]
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isName(token string) bool {
+	return token != "" && isNameByte(token[0]) && (token[0] < '0' || token[0] > '9')
+}
+
+// object is a GraphQL object: its type name and a resolver for its fields.
+type object struct {
+	typename string
+	fields   map[string]resolver
+}
+
+// resolver returns the value of a field for its arguments: nil, a scalar, an
+// object, or a slice of either.
+type resolver func(args map[string]interface{}) (interface{}, error)
+
+// constant returns a resolver for a field without arguments.
+func constant(v interface{}) resolver {
+	return func(map[string]interface{}) (interface{}, error) { return v, nil }
+}
+
+// interfaces lists the object types implementing each interface that appears in
+// a type condition.
+var interfaces = map[string][]string{
+	"ProjectV2FieldCommon": {"ProjectV2Field", "ProjectV2SingleSelectField", "ProjectV2IterationField"},
+}
+
+// matches reports whether an object of type typename satisfies the type
+// condition on.
+func matches(typename, on string) bool {
+	if typename == on {
+		return true
+	}
+	for _, t := range interfaces[on] {
+		if t == typename {
+			return true
+		}
+	}
+	return false
+}
+
+// gqlError is an entry of the errors list of a GraphQL response.
+type gqlError struct {
+	Type    string        `json:"type,omitempty"`
+	Path    []interface{} `json:"path"`
+	Message string        `json:"message"`
+}
+
+// notFound is returned by resolvers for objects that do not exist, which GitHub
+// reports as a NOT_FOUND error and a null field.
+type notFound string
+
+func (e notFound) Error() string { return string(e) }
+
+// executor runs an operation against a root object.
+type executor struct {
+	variables map[string]interface{}
+	errors    []gqlError
+}
+
+// selectionSet resolves selections on obj into a response object. The fields
+// of fragments that apply are merged into it.
+func (e *executor) selectionSet(obj *object, selections []selection, path []interface{}) map[string]interface{} {
+	out := make(map[string]interface{})
+	for _, s := range selections {
+		if s.on != "" {
+			if matches(obj.typename, s.on) {
+				merge(out, e.selectionSet(obj, s.selections, path))
+			}
+			continue
+		}
+		fieldPath := extend(path, s.alias)
+		if s.name == "__typename" {
+			out[s.alias] = obj.typename
+			continue
+		}
+		resolve, ok := obj.fields[s.name]
+		if !ok {
+			e.errors = append(e.errors, gqlError{Path: fieldPath, Message: fmt.Sprintf("Field '%s' doesn't exist on type '%s'", s.name, obj.typename)})
+			out[s.alias] = nil
+			continue
+		}
+		v, err := resolve(e.arguments(s.args))
+		if err != nil {
+			gerr := gqlError{Path: fieldPath, Message: err.Error()}
+			if _, ok := err.(notFound); ok {
+				gerr.Type = "NOT_FOUND"
+			}
+			e.errors = append(e.errors, gerr)
+			out[s.alias] = nil
+			continue
+		}
+		out[s.alias] = e.complete(v, s.selections, fieldPath)
+	}
+	return out
+}
+
+// complete converts a resolved value to its response form.
+func (e *executor) complete(v interface{}, selections []selection, path []interface{}) interface{} {
+	switch v := v.(type) {
+	case *object:
+		if v == nil {
+			return nil
+		}
+		return e.selectionSet(v, selections, path)
+	case []*object:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = e.complete(item, selections, extend(path, i))
+		}
+		return list
+	}
+	return v
+}
+
+// extend returns a copy of path with elem appended.
+func extend(path []interface{}, elem interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(path)+1), path...), elem)
+}
+
+// arguments replaces variable references in args with their values.
+func (e *executor) arguments(args map[string]interface{}) map[string]interface{} {
+	resolved := make(map[string]interface{}, len(args))
+	for name, v := range args {
+		resolved[name] = e.resolveVariables(v)
+	}
+	return resolved
+}
+
+func (e *executor) resolveVariables(v interface{}) interface{} {
+	switch v := v.(type) {
+	case variable:
+		return e.variables[string(v)]
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = e.resolveVariables(item)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			object[name] = e.resolveVariables(item)
+		}
+		return object
+	}
+	return v
+}
+
+// merge adds the fields of src to dst, merging objects selected more than once.
+func merge(dst, src map[string]interface{}) {
+	for key, v := range src {
+		existing, ok := dst[key].(map[string]interface{})
+		if incoming, isObject := v.(map[string]interface{}); ok && isObject {
+			merge(existing, incoming)
+			continue
+		}
+		dst[key] = v
+	}
+}
@@ -0,0 +1,472 @@
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// restError writes a REST error response.
+func restError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+// alreadyExists writes the validation error GitHub returns for a duplicate.
+func alreadyExists(w http.ResponseWriter, resource, field string) {
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"message": "Validation Failed",
+		"errors":  []map[string]string{{"resource": resource, "code": "already_exists", "field": field}},
+	})
+}
+
+// repo returns the repository of a request's path, writing a 404 if it does
+// not exist.
+func (s *Server) repo(w http.ResponseWriter, r *http.Request) *Repository {
+	repo := s.repository(r.PathValue("owner"), r.PathValue("repo"))
+	if repo == nil {
+		restError(w, http.StatusNotFound, "Not Found")
+	}
+	return repo
+}
+
+// writePage writes the page of items a request asks for with per_page and page,
+// with a Link header to the next and last pages.
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if perPage <= 0 {
+		perPage = 30
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+	last := max(1, (len(items)+perPage-1)/perPage)
+	if page < last {
+		link := func(n int) string {
+			u := *r.URL
+			q := u.Query()
+			q.Set("page", strconv.Itoa(n))
+			q.Set("per_page", strconv.Itoa(perPage))
+			u.RawQuery = q.Encode()
+			return fmt.Sprintf(`<%s%s>; rel=%q`, serverURL(r), u.RequestURI(), map[bool]string{true: "next", false: "last"}[n == page+1])
+		}
+		w.Header().Set("Link", link(page+1)+", "+link(last))
+	}
+	if items == nil {
+		items = []T{}
+	}
+	start := min(len(items), (page-1)*perPage)
+	end := min(len(items), start+perPage)
+	writeJSON(w, http.StatusOK, items[start:end])
+}
+
+// serverURL returns the scheme and host a request was sent to.
+func serverURL(r *http.Request) string {
+	return "http://" + r.Host
+}
+
+func (s *Server) getViewer(w http.ResponseWriter, r *http.Request) {
+	viewer := s.owner(s.Viewer)
+	if viewer == nil {
+		restError(w, http.StatusUnauthorized, "Bad credentials")
+		return
+	}
+	w.Header().Set("X-OAuth-Scopes", "project, repo")
+	writeJSON(w, http.StatusOK, ownerJSON(viewer))
+}
+
+func (s *Server) listIssues(w http.ResponseWriter, r *http.Request) {
+	repo := s.repo(w, r)
+	if repo == nil {
+		return
+	}
+	state := r.URL.Query().Get("state")
+	var issues []map[string]interface{}
+	// Newest first, as GitHub sorts them by default
+	for i := len(repo.Issues) - 1; i >= 0; i-- {
+		if issue := repo.Issues[i]; state == "all" || strings.EqualFold(issue.State, stateOrOpen(state)) {
+			issues = append(issues, issueJSON(r, issue))
+		}
+	}
+	writePage(w, r, issues)
+}
+
+// stateOrOpen returns the state filter of a list request, which defaults to open.
+func stateOrOpen(state string) string {
+	if state == "" {
+		return "open"
+	}
+	return state
+}
+
+var (
+	repoQualifier = regexp.MustCompile(`\brepo:([^/\s]+)/(\S+)`)
+	quotedPhrase  = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+)
+
+// searchIssues supports the qualifiers repo:, is:issue, is:open, is:closed and
+// in:title; every other word, quoted or not, must appear in the title.
+func (s *Server) searchIssues(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	var words []string
+	state := ""
+	var repo *Repository
+	if m := repoQualifier.FindStringSubmatch(q); m != nil {
+		repo = s.repository(m[1], m[2])
+		q = strings.Replace(q, m[0], "", 1)
+	}
+	for _, quoted := range quotedPhrase.FindAllString(q, -1) {
+		if phrase, err := strconv.Unquote(quoted); err == nil {
+			words = append(words, strings.Fields(strings.ToLower(phrase))...)
+		}
+	}
+	for _, word := range strings.Fields(quotedPhrase.ReplaceAllString(q, " ")) {
+		switch word {
+		case "is:issue", "in:title":
+		case "is:open", "is:closed":
+			state = strings.TrimPrefix(word, "is:")
+		default:
+			words = append(words, strings.ToLower(word))
+		}
+	}
+
+	items := []map[string]interface{}{}
+	for _, candidate := range s.repos {
+		if repo != nil && candidate != repo {
+			continue
+		}
+		for _, issue := range candidate.Issues {
+			if state != "" && !strings.EqualFold(issue.State, state) {
+				continue
+			}
+			title := strings.ToLower(issue.Title)
+			found := true
+			for _, word := range words {
+				if !strings.Contains(title, word) {
+					found = false
+				}
+			}
+			if found {
+				items = append(items, issueJSON(r, issue))
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total_count":        len(items),
+		"incomplete_results": false,
+		"items":              items,
+	})
+}
+
+func (s *Server) listMilestones(w http.ResponseWriter, r *http.Request) {
+	repo := s.repo(w, r)
+	if repo == nil {
+		return
+	}
+	state := r.URL.Query().Get("state")
+	var milestones []map[string]interface{}
+	for _, m := range repo.Milestones {
+		if state == "all" || m.State == stateOrOpen(state) {
+			milestones = append(milestones, milestoneJSON(r, repo, m))
+		}
+	}
+	writePage(w, r, milestones)
+}
+
+// milestoneRequest is the body of a request that creates or edits a milestone.
+type milestoneRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	State       *string `json:"state"`
+	DueOn       *string `json:"due_on"`
+}
+
+// apply sets the fields of m that the request sets, reporting invalid values.
+func (req milestoneRequest) apply(m *Milestone) error {
+	if req.Title != nil {
+		m.Title = *req.Title
+	}
+	if req.Description != nil {
+		m.Description = *req.Description
+	}
+	if req.State != nil {
+		if *req.State != "open" && *req.State != "closed" {
+			return fmt.Errorf("invalid state %q", *req.State)
+		}
+		m.State = *req.State
+	}
+	if req.DueOn != nil {
+		due, err := time.Parse(time.RFC3339, *req.DueOn)
+		if err != nil {
+			return fmt.Errorf("invalid due_on %q", *req.DueOn)
+		}
+		m.DueOn = &due
+	}
+	return nil
+}
+
+func (s *Server) createMilestone(w http.ResponseWriter, r *http.Request) {
+	repo := s.repo(w, r)
+	if repo == nil {
+		return
+	}
+	var req milestoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Title == nil || *req.Title == "" {
+		restError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	if repo.Milestone(*req.Title) != nil {
+		alreadyExists(w, "Milestone", "title")
+		return
+	}
+	m := &Milestone{State: "open"}
+	if err := req.apply(m); err != nil {
+		restError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	created := repo.addMilestone(m.Title, m.Description)
+	created.State, created.DueOn = m.State, m.DueOn
+	writeJSON(w, http.StatusCreated, milestoneJSON(r, repo, created))
+}
+
+func (s *Server) editMilestone(w http.ResponseWriter, r *http.Request) {
+	repo := s.repo(w, r)
+	if repo == nil {
+		return
+	}
+	number, _ := strconv.Atoi(r.PathValue("number"))
+	var m *Milestone
+	for _, candidate := range repo.Milestones {
+		if candidate.Number == number {
+			m = candidate
+		}
+	}
+	if m == nil {
+		restError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	var req milestoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		restError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	if req.Title != nil && *req.Title != m.Title && repo.Milestone(*req.Title) != nil {
+		alreadyExists(w, "Milestone", "title")
+		return
+	}
+	edited := *m
+	if err := req.apply(&edited); err != nil {
+		restError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	*m = edited
+	writeJSON(w, http.StatusOK, milestoneJSON(r, repo, m))
+}
+
+func (s *Server) listLabels(w http.ResponseWriter, r *http.Request) {
+	repo := s.repo(w, r)
+	if repo == nil {
+		return
+	}
+	labels := make([]map[string]interface{}, 0, len(repo.Labels))
+	for _, l := range repo.Labels {
+		labels = append(labels, labelJSON(l))
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return strings.ToLower(labels[i]["name"].(string)) < strings.ToLower(labels[j]["name"].(string))
+	})
+	writePage(w, r, labels)
+}
+
+func (s *Server) getLabel(w http.ResponseWriter, r *http.Request) {
+	repo := s.repo(w, r)
+	if repo == nil {
+		return
+	}
+	l := repo.Label(r.PathValue("name"))
+	if l == nil {
+		restError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, labelJSON(l))
+}
+
+// labelRequest is the body of a request that creates or edits a label. Edits
+// rename the label with either name or new_name.
+type labelRequest struct {
+	Name        *string `json:"name"`
+	NewName     *string `json:"new_name"`
+	Color       *string `json:"color"`
+	Description *string `json:"description"`
+}
+
+var labelColor = regexp.MustCompile(`^[0-9a-fA-F]{6}$`)
+
+// apply sets the fields of l that the request sets, reporting invalid values.
+func (req labelRequest) apply(l *Label) error {
+	if req.NewName != nil {
+		req.Name = req.NewName
+	}
+	if req.Name != nil {
+		l.Name = *req.Name
+	}
+	if req.Color != nil {
+		if !labelColor.MatchString(*req.Color) {
+			return fmt.Errorf("invalid color %q", *req.Color)
+		}
+		l.Color = strings.ToLower(*req.Color)
+	}
+	if req.Description != nil {
+		l.Description = *req.Description
+	}
+	return nil
+}
+
+func (s *Server) createLabel(w http.ResponseWriter, r *http.Request) {
+	repo := s.repo(w, r)
+	if repo == nil {
+		return
+	}
+	var req labelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == nil || *req.Name == "" {
+		restError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	if repo.Label(*req.Name) != nil {
+		alreadyExists(w, "Label", "name")
+		return
+	}
+	l := &Label{Color: "ededed"}
+	if err := req.apply(l); err != nil {
+		restError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	created := repo.addLabel(l.Name, l.Color, l.Description)
+	writeJSON(w, http.StatusCreated, labelJSON(created))
+}
+
+func (s *Server) editLabel(w http.ResponseWriter, r *http.Request) {
+	repo := s.repo(w, r)
+	if repo == nil {
+		return
+	}
+	l := repo.Label(r.PathValue("name"))
+	if l == nil {
+		restError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	var req labelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		restError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	edited := *l
+	if err := req.apply(&edited); err != nil {
+		restError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if other := repo.Label(edited.Name); other != nil && other != l {
+		alreadyExists(w, "Label", "name")
+		return
+	}
+	*l = edited
+	writeJSON(w, http.StatusOK, labelJSON(l))
+}
+
+func ownerJSON(o *Owner) map[string]interface{} {
+	kind := "User"
+	if o.Organization {
+		kind = "Organization"
+	}
+	return map[string]interface{}{"login": o.Login, "id": nodeNumber(o.ID), "node_id": o.ID, "type": kind}
+}
+
+func labelJSON(l *Label) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          nodeNumber(l.ID),
+		"node_id":     l.ID,
+		"name":        l.Name,
+		"color":       l.Color,
+		"description": l.Description,
+	}
+}
+
+func milestoneJSON(r *http.Request, repo *Repository, m *Milestone) map[string]interface{} {
+	open, closed := 0, 0
+	for _, issue := range repo.Issues {
+		if issue.Milestone != m {
+			continue
+		}
+		if issue.State == "OPEN" {
+			open++
+		} else {
+			closed++
+		}
+	}
+	var dueOn interface{}
+	if m.DueOn != nil {
+		dueOn = m.DueOn.UTC().Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"id":            nodeNumber(m.ID),
+		"node_id":       m.ID,
+		"number":        m.Number,
+		"title":         m.Title,
+		"description":   m.Description,
+		"state":         m.State,
+		"due_on":        dueOn,
+		"open_issues":   open,
+		"closed_issues": closed,
+		"html_url":      fmt.Sprintf("%s/%s/%s/milestone/%d", serverURL(r), repo.Owner.Login, repo.Name, m.Number),
+	}
+}
+
+func issueJSON(r *http.Request, i *Issue) map[string]interface{} {
+	labels := []map[string]interface{}{}
+	for _, l := range i.Labels {
+		labels = append(labels, labelJSON(l))
+	}
+	assignees := []map[string]interface{}{}
+	for _, a := range i.Assignees {
+		assignees = append(assignees, ownerJSON(a))
+	}
+	var milestone interface{}
+	if i.Milestone != nil {
+		milestone = milestoneJSON(r, i.Repository, i.Milestone)
+	}
+	var stateReason interface{}
+	if i.StateReason != "" {
+		stateReason = strings.ToLower(i.StateReason)
+	}
+	return map[string]interface{}{
+		"id":             nodeNumber(i.ID),
+		"node_id":        i.ID,
+		"number":         i.Number,
+		"title":          i.Title,
+		"body":           i.Body,
+		"state":          strings.ToLower(i.State),
+		"state_reason":   stateReason,
+		"labels":         labels,
+		"assignees":      assignees,
+		"milestone":      milestone,
+		"html_url":       issueURL(serverURL(r), i),
+		"repository_url": fmt.Sprintf("%s/api/v3/repos/%s/%s", serverURL(r), url.PathEscape(i.Repository.Owner.Login), url.PathEscape(i.Repository.Name)),
+	}
+}
+
+// issueURL returns the web URL of an issue on the server at base.
+func issueURL(base string, i *Issue) string {
+	return fmt.Sprintf("%s/%s/%s/issues/%d", base, i.Repository.Owner.Login, i.Repository.Name, i.Number)
+}
+
+// nodeNumber returns the numeric database ID of a node ID such as "I_12".
+func nodeNumber(id string) int64 {
+	n, _ := strconv.ParseInt(id[strings.LastIndex(id, "_")+1:], 10, 64)
+	return n
+}
@@ -0,0 +1,826 @@
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (s *Server) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		restError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	op, err := parseOperation(req.Query)
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"errors": []gqlError{{Message: fmt.Sprintf("Parse error: %v", err)}}})
+		return
+	}
+
+	root := s.queryRoot()
+	if op.mutation {
+		root = s.mutationRoot()
+	}
+	e := &executor{variables: req.Variables}
+	response := map[string]interface{}{"data": e.selectionSet(root, op.selections, nil)}
+	if len(e.errors) > 0 {
+		response["errors"] = e.errors
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Argument helpers. Arguments arrive as decoded JSON or parsed literals.
+
+func stringArg(args map[string]interface{}, name string) string {
+	v, _ := args[name].(string)
+	return v
+}
+
+func intArg(args map[string]interface{}, name string) int {
+	switch v := args[name].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+func stringsArg(args map[string]interface{}, name string) []string {
+	list, _ := args[name].([]interface{})
+	var values []string
+	for _, v := range list {
+		if str, ok := v.(string); ok {
+			values = append(values, str)
+		}
+	}
+	return values
+}
+
+func inputArg(args map[string]interface{}) map[string]interface{} {
+	input, _ := args["input"].(map[string]interface{})
+	return input
+}
+
+// connection returns a page of nodes as a connection object, for the first and
+// after arguments of a connection field. Cursors are node offsets.
+func connection(typename string, nodes []*object, args map[string]interface{}) *object {
+	start := 0
+	if after := stringArg(args, "after"); after != "" {
+		start, _ = strconv.Atoi(after)
+	}
+	start = min(start, len(nodes))
+	end := len(nodes)
+	if first := intArg(args, "first"); first > 0 {
+		end = min(end, start+first)
+	}
+	var endCursor interface{}
+	if end > start {
+		endCursor = strconv.Itoa(end)
+	}
+	return &object{typename: typename, fields: map[string]resolver{
+		"nodes":      constant(nodes[start:end]),
+		"totalCount": constant(len(nodes)),
+		"pageInfo": constant(&object{typename: "PageInfo", fields: map[string]resolver{
+			"hasNextPage": constant(end < len(nodes)),
+			"endCursor":   constant(endCursor),
+		}}),
+	}}
+}
+
+// connectionField returns a resolver for a connection of the nodes that list
+// returns when the field is resolved.
+func connectionField(typename string, list func() []*object) resolver {
+	return func(args map[string]interface{}) (interface{}, error) {
+		return connection(typename, list(), args), nil
+	}
+}
+
+// queryRoot is the Query type.
+func (s *Server) queryRoot() *object {
+	return &object{typename: "Query", fields: map[string]resolver{
+		"repository": func(args map[string]interface{}) (interface{}, error) {
+			owner, name := stringArg(args, "owner"), stringArg(args, "name")
+			repo := s.repository(owner, name)
+			if repo == nil {
+				return nil, notFound(fmt.Sprintf("Could not resolve to a Repository with the name '%s/%s'.", owner, name))
+			}
+			return s.repositoryObject(repo), nil
+		},
+		"user": func(args map[string]interface{}) (interface{}, error) {
+			login := stringArg(args, "login")
+			if o := s.owner(login); o != nil && !o.Organization {
+				return s.ownerObject(o), nil
+			}
+			return nil, notFound(fmt.Sprintf("Could not resolve to a User with the login of '%s'.", login))
+		},
+		"organization": func(args map[string]interface{}) (interface{}, error) {
+			login := stringArg(args, "login")
+			if o := s.owner(login); o != nil && o.Organization {
+				return s.ownerObject(o), nil
+			}
+			return nil, notFound(fmt.Sprintf("Could not resolve to an Organization with the login of '%s'.", login))
+		},
+		"node": func(args map[string]interface{}) (interface{}, error) {
+			id := stringArg(args, "id")
+			if obj := s.nodeObject(id); obj != nil {
+				return obj, nil
+			}
+			return nil, notFound(fmt.Sprintf("Could not resolve to a node with the global id of '%s'", id))
+		},
+		"__type": func(args map[string]interface{}) (interface{}, error) {
+			name := stringArg(args, "name")
+			if name == "AddBlockedByInput" && !s.IssueDependencies || name == "AddSubIssueInput" && !s.SubIssues {
+				return nil, nil
+			}
+			return &object{typename: "__Type", fields: map[string]resolver{"name": constant(name)}}, nil
+		},
+	}}
+}
+
+// nodeObject returns the object with the given node ID, or nil.
+func (s *Server) nodeObject(id string) *object {
+	switch node := s.nodes[id].(type) {
+	case *Owner:
+		return s.ownerObject(node)
+	case *Repository:
+		return s.repositoryObject(node)
+	case *Issue:
+		return s.issueObject(node)
+	case *Milestone:
+		return milestoneObject(node)
+	case *Label:
+		return labelObject(node)
+	case *Project:
+		return s.projectObject(node)
+	case *Item:
+		if p := s.itemProject(node); p != nil {
+			return s.itemObject(p, node)
+		}
+	}
+	return nil
+}
+
+func (s *Server) ownerObject(o *Owner) *object {
+	typename := "User"
+	if o.Organization {
+		typename = "Organization"
+	}
+	return &object{typename: typename, fields: map[string]resolver{
+		"id":    constant(o.ID),
+		"login": constant(o.Login),
+		"projectsV2": connectionField("ProjectV2Connection", func() []*object {
+			var nodes []*object
+			for _, p := range o.Projects {
+				nodes = append(nodes, s.projectObject(p))
+			}
+			return nodes
+		}),
+	}}
+}
+
+func (s *Server) repositoryObject(r *Repository) *object {
+	return &object{typename: "Repository", fields: map[string]resolver{
+		"id":            constant(r.ID),
+		"name":          constant(r.Name),
+		"nameWithOwner": constant(r.Owner.Login + "/" + r.Name),
+		"owner":         constant(s.ownerObject(r.Owner)),
+		"issue": func(args map[string]interface{}) (interface{}, error) {
+			number := intArg(args, "number")
+			for _, i := range r.Issues {
+				if i.Number == number {
+					return s.issueObject(i), nil
+				}
+			}
+			return nil, notFound(fmt.Sprintf("Could not resolve to an Issue with the number of %d.", number))
+		},
+		"milestone": func(args map[string]interface{}) (interface{}, error) {
+			number := intArg(args, "number")
+			for _, m := range r.Milestones {
+				if m.Number == number {
+					return milestoneObject(m), nil
+				}
+			}
+			return nil, nil
+		},
+	}}
+}
+
+func (s *Server) issueObject(i *Issue) *object {
+	fields := map[string]resolver{
+		"id":     constant(i.ID),
+		"number": constant(i.Number),
+		"title":  constant(i.Title),
+		"body":   constant(i.Body),
+		"state":  constant(i.State),
+		"stateReason": func(map[string]interface{}) (interface{}, error) {
+			if i.StateReason == "" {
+				return nil, nil
+			}
+			return i.StateReason, nil
+		},
+		"url":        constant(issueURL(s.URL, i)),
+		"repository": constant(s.repositoryObject(i.Repository)),
+		"milestone": func(map[string]interface{}) (interface{}, error) {
+			if i.Milestone == nil {
+				return nil, nil
+			}
+			return milestoneObject(i.Milestone), nil
+		},
+		"labels": connectionField("LabelConnection", func() []*object {
+			var nodes []*object
+			for _, l := range i.Labels {
+				nodes = append(nodes, labelObject(l))
+			}
+			return nodes
+		}),
+		"assignees": connectionField("UserConnection", func() []*object {
+			var nodes []*object
+			for _, a := range i.Assignees {
+				nodes = append(nodes, s.ownerObject(a))
+			}
+			return nodes
+		}),
+		"projectItems": connectionField("ProjectV2ItemConnection", func() []*object {
+			var nodes []*object
+			for _, o := range s.owners {
+				for _, p := range o.Projects {
+					for _, it := range p.Items {
+						if it.Issue == i {
+							nodes = append(nodes, s.itemObject(p, it))
+						}
+					}
+				}
+			}
+			return nodes
+		}),
+	}
+	if s.SubIssues {
+		fields["parent"] = func(map[string]interface{}) (interface{}, error) {
+			if i.Parent == nil {
+				return nil, nil
+			}
+			return s.issueObject(i.Parent), nil
+		}
+	}
+	if s.IssueDependencies {
+		fields["blockedBy"] = connectionField("IssueConnection", func() []*object {
+			var nodes []*object
+			for _, blocking := range i.BlockedBy {
+				nodes = append(nodes, s.issueObject(blocking))
+			}
+			return nodes
+		})
+	}
+	return &object{typename: "Issue", fields: fields}
+}
+
+func milestoneObject(m *Milestone) *object {
+	return &object{typename: "Milestone", fields: map[string]resolver{
+		"id":          constant(m.ID),
+		"number":      constant(m.Number),
+		"title":       constant(m.Title),
+		"description": constant(m.Description),
+		"state":       constant(map[string]string{"open": "OPEN", "closed": "CLOSED"}[m.State]),
+		"dueOn": func(map[string]interface{}) (interface{}, error) {
+			if m.DueOn == nil {
+				return nil, nil
+			}
+			return m.DueOn.UTC().Format(time.RFC3339), nil
+		},
+	}}
+}
+
+func labelObject(l *Label) *object {
+	return &object{typename: "Label", fields: map[string]resolver{
+		"id":          constant(l.ID),
+		"name":        constant(l.Name),
+		"color":       constant(l.Color),
+		"description": constant(l.Description),
+	}}
+}
+
+func (s *Server) projectObject(p *Project) *object {
+	return &object{typename: "ProjectV2", fields: map[string]resolver{
+		"id":     constant(p.ID),
+		"number": constant(p.Number),
+		"title":  constant(p.Title),
+		"fields": connectionField("ProjectV2FieldConfigurationConnection", func() []*object {
+			var nodes []*object
+			for _, f := range p.Fields {
+				nodes = append(nodes, fieldObject(f))
+			}
+			return nodes
+		}),
+		"items": connectionField("ProjectV2ItemConnection", func() []*object {
+			var nodes []*object
+			for _, it := range p.Items {
+				nodes = append(nodes, s.itemObject(p, it))
+			}
+			return nodes
+		}),
+	}}
+}
+
+// fieldObject returns a field as the GraphQL type of its data type.
+func fieldObject(f *Field) *object {
+	obj := &object{typename: "ProjectV2Field", fields: map[string]resolver{
+		"id":       constant(f.ID),
+		"name":     constant(f.Name),
+		"dataType": constant(f.DataType),
+	}}
+	switch f.DataType {
+	case "SINGLE_SELECT":
+		obj.typename = "ProjectV2SingleSelectField"
+		var options []*object
+		for _, o := range f.Options {
+			options = append(options, &object{typename: "ProjectV2SingleSelectFieldOption", fields: map[string]resolver{
+				"id":   constant(o.ID),
+				"name": constant(o.Name),
+			}})
+		}
+		obj.fields["options"] = constant(options)
+	case "ITERATION":
+		obj.typename = "ProjectV2IterationField"
+		iterations := func(completed bool) []*object {
+			nodes := []*object{}
+			for _, it := range f.Iterations {
+				if it.Completed == completed {
+					nodes = append(nodes, iterationObject(it))
+				}
+			}
+			return nodes
+		}
+		obj.fields["configuration"] = constant(&object{typename: "ProjectV2IterationFieldConfiguration", fields: map[string]resolver{
+			"iterations":          constant(iterations(false)),
+			"completedIterations": constant(iterations(true)),
+		}})
+	}
+	return obj
+}
+
+func iterationObject(it *Iteration) *object {
+	return &object{typename: "ProjectV2IterationFieldIteration", fields: map[string]resolver{
+		"id":        constant(it.ID),
+		"title":     constant(it.Title),
+		"startDate": constant(it.StartDate),
+		"duration":  constant(it.Duration),
+	}}
+}
+
+// itemProject returns the project an item is on.
+func (s *Server) itemProject(it *Item) *Project {
+	for _, o := range s.owners {
+		for _, p := range o.Projects {
+			for _, candidate := range p.Items {
+				if candidate == it {
+					return p
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) itemObject(p *Project, it *Item) *object {
+	kind := "ISSUE"
+	var content *object
+	if it.Issue != nil {
+		content = s.issueObject(it.Issue)
+	} else {
+		kind = "DRAFT_ISSUE"
+		content = &object{typename: "DraftIssue", fields: map[string]resolver{
+			"title": constant(it.Draft),
+			"body":  constant(""),
+		}}
+	}
+	return &object{typename: "ProjectV2Item", fields: map[string]resolver{
+		"id":      constant(it.ID),
+		"type":    constant(kind),
+		"project": constant(s.projectObject(p)),
+		"content": constant(content),
+		"fieldValueByName": func(args map[string]interface{}) (interface{}, error) {
+			f := p.Field(stringArg(args, "name"))
+			if f == nil {
+				return nil, nil
+			}
+			return fieldValueObject(f, it), nil
+		},
+		"fieldValues": connectionField("ProjectV2ItemFieldValueConnection", func() []*object {
+			var nodes []*object
+			for _, f := range p.Fields {
+				if v := fieldValueObject(f, it); v != nil {
+					nodes = append(nodes, v)
+				}
+			}
+			return nodes
+		}),
+	}}
+}
+
+// fieldValueObject returns the value of a field on an item, or nil if it has
+// none. Like GitHub, the Title field has the issue's title as a text value and
+// the built-in fields have values of their own types.
+func fieldValueObject(f *Field, it *Item) *object {
+	obj := &object{fields: map[string]resolver{"field": constant(fieldObject(f))}}
+	v, ok := it.Values[f.Name]
+	switch f.DataType {
+	case "TITLE":
+		obj.typename = "ProjectV2ItemFieldTextValue"
+		obj.fields["text"] = constant(it.title())
+	case "ASSIGNEES", "LABELS", "MILESTONE", "REPOSITORY":
+		if it.Issue == nil || f.DataType == "ASSIGNEES" && len(it.Issue.Assignees) == 0 || f.DataType == "LABELS" && len(it.Issue.Labels) == 0 || f.DataType == "MILESTONE" && it.Issue.Milestone == nil {
+			return nil
+		}
+		obj.typename = map[string]string{
+			"ASSIGNEES":  "ProjectV2ItemFieldUserValue",
+			"LABELS":     "ProjectV2ItemFieldLabelValue",
+			"MILESTONE":  "ProjectV2ItemFieldMilestoneValue",
+			"REPOSITORY": "ProjectV2ItemFieldRepositoryValue",
+		}[f.DataType]
+	case "TEXT":
+		if !ok {
+			return nil
+		}
+		obj.typename = "ProjectV2ItemFieldTextValue"
+		obj.fields["text"] = constant(v)
+	case "NUMBER":
+		n, err := strconv.ParseFloat(v, 64)
+		if !ok || err != nil {
+			return nil
+		}
+		obj.typename = "ProjectV2ItemFieldNumberValue"
+		obj.fields["number"] = constant(n)
+	case "DATE":
+		if !ok {
+			return nil
+		}
+		obj.typename = "ProjectV2ItemFieldDateValue"
+		obj.fields["date"] = constant(v)
+	case "SINGLE_SELECT":
+		option := f.option(v)
+		if !ok || option == nil {
+			return nil
+		}
+		obj.typename = "ProjectV2ItemFieldSingleSelectValue"
+		obj.fields["name"] = constant(option.Name)
+		obj.fields["optionId"] = constant(option.ID)
+	case "ITERATION":
+		iteration := f.iteration(v)
+		if !ok || iteration == nil {
+			return nil
+		}
+		obj.typename = "ProjectV2ItemFieldIterationValue"
+		obj.fields["title"] = constant(iteration.Title)
+		obj.fields["iterationId"] = constant(iteration.ID)
+		obj.fields["startDate"] = constant(iteration.StartDate)
+		obj.fields["duration"] = constant(iteration.Duration)
+	default:
+		return nil
+	}
+	return obj
+}
+
+// option returns the option of a single-select field with the given name or ID.
+func (f *Field) option(nameOrID string) *Option {
+	for _, o := range f.Options {
+		if o.Name == nameOrID || o.ID == nameOrID {
+			return o
+		}
+	}
+	return nil
+}
+
+// iteration returns the iteration of an iteration field with the given title or ID.
+func (f *Field) iteration(titleOrID string) *Iteration {
+	for _, it := range f.Iterations {
+		if it.Title == titleOrID || it.ID == titleOrID {
+			return it
+		}
+	}
+	return nil
+}
+
+// payload returns a mutation payload with the given fields and a null
+// clientMutationId.
+func payload(fields map[string]resolver) *object {
+	fields["clientMutationId"] = constant(nil)
+	return &object{typename: "Payload", fields: fields}
+}
+
+// mutationRoot is the Mutation type.
+func (s *Server) mutationRoot() *object {
+	fields := map[string]resolver{
+		"createIssue":                   s.createIssue,
+		"updateIssue":                   s.updateIssue,
+		"closeIssue":                    s.closeIssue,
+		"addProjectV2ItemById":          s.addProjectV2ItemByID,
+		"deleteProjectV2Item":           s.deleteProjectV2Item,
+		"updateProjectV2ItemFieldValue": s.updateProjectV2ItemFieldValue,
+	}
+	if s.SubIssues {
+		fields["addSubIssue"] = s.addSubIssue
+	}
+	if s.IssueDependencies {
+		fields["addBlockedBy"] = s.addBlockedBy
+	}
+	return &object{typename: "Mutation", fields: fields}
+}
+
+// issueNode returns the issue with the given node ID.
+func (s *Server) issueNode(id string) (*Issue, error) {
+	if i, ok := s.nodes[id].(*Issue); ok {
+		return i, nil
+	}
+	return nil, notFound(fmt.Sprintf("Could not resolve to Issue node with the global id of '%s'.", id))
+}
+
+// setIssueFields sets the title, body, assignees, labels and milestone of an
+// issue from the keys present in a createIssue or updateIssue input.
+func (s *Server) setIssueFields(i *Issue, input map[string]interface{}) error {
+	if title, ok := input["title"].(string); ok {
+		if title == "" {
+			return fmt.Errorf("Title can't be blank")
+		}
+		i.Title = title
+	}
+	if body, ok := input["body"].(string); ok {
+		i.Body = body
+	}
+	if _, ok := input["assigneeIds"]; ok {
+		var assignees []*Owner
+		for _, id := range stringsArg(input, "assigneeIds") {
+			o, ok := s.nodes[id].(*Owner)
+			if !ok || o.Organization {
+				return notFound(fmt.Sprintf("Could not resolve to User node with the global id of '%s'.", id))
+			}
+			assignees = append(assignees, o)
+		}
+		i.Assignees = assignees
+	}
+	if _, ok := input["labelIds"]; ok {
+		var labels []*Label
+		for _, id := range stringsArg(input, "labelIds") {
+			l, ok := s.nodes[id].(*Label)
+			if !ok {
+				return notFound(fmt.Sprintf("Could not resolve to Label node with the global id of '%s'.", id))
+			}
+			labels = append(labels, l)
+		}
+		i.Labels = labels
+	}
+	if v, ok := input["milestoneId"]; ok {
+		i.Milestone = nil
+		if id, _ := v.(string); id != "" {
+			m, ok := s.nodes[id].(*Milestone)
+			if !ok {
+				return notFound(fmt.Sprintf("Could not resolve to Milestone node with the global id of '%s'.", id))
+			}
+			i.Milestone = m
+		}
+	}
+	return nil
+}
+
+func (s *Server) createIssue(args map[string]interface{}) (interface{}, error) {
+	input := inputArg(args)
+	repo, ok := s.nodes[stringArg(input, "repositoryId")].(*Repository)
+	if !ok {
+		return nil, notFound(fmt.Sprintf("Could not resolve to Repository node with the global id of '%s'.", stringArg(input, "repositoryId")))
+	}
+	if stringArg(input, "title") == "" {
+		return nil, fmt.Errorf("Title can't be blank")
+	}
+	// Validate everything before the issue exists
+	draft := &Issue{}
+	if err := s.setIssueFields(draft, input); err != nil {
+		return nil, err
+	}
+	var parent *Issue
+	if id := stringArg(input, "parentIssueId"); id != "" {
+		var err error
+		if parent, err = s.issueNode(id); err != nil {
+			return nil, err
+		}
+	}
+	i := repo.addIssue(draft.Title, draft.Body)
+	i.Assignees, i.Labels, i.Milestone, i.Parent = draft.Assignees, draft.Labels, draft.Milestone, parent
+	return payload(map[string]resolver{"issue": constant(s.issueObject(i))}), nil
+}
+
+func (s *Server) updateIssue(args map[string]interface{}) (interface{}, error) {
+	input := inputArg(args)
+	i, err := s.issueNode(stringArg(input, "id"))
+	if err != nil {
+		return nil, err
+	}
+	updated := *i
+	if err := s.setIssueFields(&updated, input); err != nil {
+		return nil, err
+	}
+	switch state := stringArg(input, "state"); state {
+	case "":
+	case "OPEN":
+		updated.State, updated.StateReason = "OPEN", ""
+	case "CLOSED":
+		updated.State, updated.StateReason = "CLOSED", "COMPLETED"
+	default:
+		return nil, fmt.Errorf("invalid state %q", state)
+	}
+	*i = updated
+	return payload(map[string]resolver{"issue": constant(s.issueObject(i))}), nil
+}
+
+func (s *Server) closeIssue(args map[string]interface{}) (interface{}, error) {
+	input := inputArg(args)
+	i, err := s.issueNode(stringArg(input, "issueId"))
+	if err != nil {
+		return nil, err
+	}
+	i.State, i.StateReason = "CLOSED", "COMPLETED"
+	if reason := stringArg(input, "stateReason"); reason != "" {
+		i.StateReason = reason
+	}
+	return payload(map[string]resolver{"issue": constant(s.issueObject(i))}), nil
+}
+
+func (s *Server) addSubIssue(args map[string]interface{}) (interface{}, error) {
+	input := inputArg(args)
+	parent, err := s.issueNode(stringArg(input, "issueId"))
+	if err != nil {
+		return nil, err
+	}
+	child, err := s.issueNode(stringArg(input, "subIssueId"))
+	if err != nil {
+		return nil, err
+	}
+	replace, _ := input["replaceParent"].(bool)
+	switch {
+	case child == parent:
+		return nil, fmt.Errorf("An issue cannot be a sub-issue of itself")
+	case child.Parent == parent:
+		return nil, fmt.Errorf("Issue may not contain duplicate sub-issues")
+	case child.Parent != nil && !replace:
+		return nil, fmt.Errorf("Sub issue may only have one parent")
+	}
+	for ancestor := parent; ancestor != nil; ancestor = ancestor.Parent {
+		if ancestor == child {
+			return nil, fmt.Errorf("Sub-issues cannot form a cycle")
+		}
+	}
+	child.Parent = parent
+	return payload(map[string]resolver{
+		"issue":    constant(s.issueObject(parent)),
+		"subIssue": constant(s.issueObject(child)),
+	}), nil
+}
+
+func (s *Server) addBlockedBy(args map[string]interface{}) (interface{}, error) {
+	input := inputArg(args)
+	i, err := s.issueNode(stringArg(input, "issueId"))
+	if err != nil {
+		return nil, err
+	}
+	blocking, err := s.issueNode(stringArg(input, "blockingIssueId"))
+	if err != nil {
+		return nil, err
+	}
+	if blocking == i {
+		return nil, fmt.Errorf("An issue cannot block itself")
+	}
+	for _, existing := range i.BlockedBy {
+		if existing == blocking {
+			return nil, fmt.Errorf("Issue is already blocked by this issue")
+		}
+	}
+	i.BlockedBy = append(i.BlockedBy, blocking)
+	return payload(map[string]resolver{
+		"issue":         constant(s.issueObject(i)),
+		"blockingIssue": constant(s.issueObject(blocking)),
+	}), nil
+}
+
+// projectNode returns the project with the given node ID.
+func (s *Server) projectNode(id string) (*Project, error) {
+	if p, ok := s.nodes[id].(*Project); ok {
+		return p, nil
+	}
+	return nil, notFound(fmt.Sprintf("Could not resolve to ProjectV2 node with the global id of '%s'.", id))
+}
+
+// itemNode returns the item of project p with the given node ID.
+func (s *Server) itemNode(p *Project, id string) (*Item, error) {
+	for _, it := range p.Items {
+		if it.ID == id {
+			return it, nil
+		}
+	}
+	return nil, notFound(fmt.Sprintf("Could not resolve to ProjectV2Item node with the global id of '%s'.", id))
+}
+
+// addProjectV2ItemByID adds an issue to a project. Adding an issue that is
+// already on the project returns its existing item, as GitHub does.
+func (s *Server) addProjectV2ItemByID(args map[string]interface{}) (interface{}, error) {
+	input := inputArg(args)
+	p, err := s.projectNode(stringArg(input, "projectId"))
+	if err != nil {
+		return nil, err
+	}
+	i, err := s.issueNode(stringArg(input, "contentId"))
+	if err != nil {
+		return nil, err
+	}
+	return payload(map[string]resolver{"item": constant(s.itemObject(p, p.addItem(i)))}), nil
+}
+
+func (s *Server) deleteProjectV2Item(args map[string]interface{}) (interface{}, error) {
+	input := inputArg(args)
+	p, err := s.projectNode(stringArg(input, "projectId"))
+	if err != nil {
+		return nil, err
+	}
+	it, err := s.itemNode(p, stringArg(input, "itemId"))
+	if err != nil {
+		return nil, err
+	}
+	for n, candidate := range p.Items {
+		if candidate == it {
+			p.Items = append(p.Items[:n], p.Items[n+1:]...)
+			break
+		}
+	}
+	return payload(map[string]resolver{"deletedItemId": constant(it.ID)}), nil
+}
+
+// updateProjectV2ItemFieldValue sets a field of an item to the one value of
+// the input's value object that matches the field's data type.
+func (s *Server) updateProjectV2ItemFieldValue(args map[string]interface{}) (interface{}, error) {
+	input := inputArg(args)
+	p, err := s.projectNode(stringArg(input, "projectId"))
+	if err != nil {
+		return nil, err
+	}
+	it, err := s.itemNode(p, stringArg(input, "itemId"))
+	if err != nil {
+		return nil, err
+	}
+	var f *Field
+	for _, candidate := range p.Fields {
+		if candidate.ID == stringArg(input, "fieldId") {
+			f = candidate
+		}
+	}
+	if f == nil {
+		return nil, notFound(fmt.Sprintf("Could not resolve to ProjectV2Field node with the global id of '%s'.", stringArg(input, "fieldId")))
+	}
+	value, _ := input["value"].(map[string]interface{})
+
+	var v string
+	switch f.DataType {
+	case "TEXT":
+		text, ok := value["text"].(string)
+		if !ok {
+			return nil, fmt.Errorf("A text value is required for the field %s", f.Name)
+		}
+		v = text
+	case "NUMBER":
+		n, ok := value["number"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("A number value is required for the field %s", f.Name)
+		}
+		v = strconv.FormatFloat(n, 'f', -1, 64)
+	case "DATE":
+		date, _ := value["date"].(string)
+		t, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", date); err != nil {
+				return nil, fmt.Errorf("A date value is required for the field %s", f.Name)
+			}
+		}
+		v = t.Format("2006-01-02")
+	case "SINGLE_SELECT":
+		id, _ := value["singleSelectOptionId"].(string)
+		option := f.option(id)
+		if option == nil || option.ID != id {
+			return nil, fmt.Errorf("The single select option Id does not belong to the field %s", f.Name)
+		}
+		v = option.Name
+	case "ITERATION":
+		id, _ := value["iterationId"].(string)
+		iteration := f.iteration(id)
+		if iteration == nil || iteration.ID != id {
+			return nil, fmt.Errorf("The iteration Id does not belong to the field %s", f.Name)
+		}
+		v = iteration.Title
+	default:
+		return nil, fmt.Errorf("The field %s cannot be updated with updateProjectV2ItemFieldValue", f.Name)
+	}
+	it.Values[f.Name] = v
+	return payload(map[string]resolver{"projectV2Item": constant(s.itemObject(p, it))}), nil
+}
@@ -0,0 +1,510 @@
+// Package fakegithub is an in-memory GitHub for end-to-end tests. It serves the
+// subset of the REST and GraphQL APIs that pkg/github uses the way a GitHub
+// Enterprise Server at the server's URL would, so a client reaches it with
+// github.NewClientForHost(server.URL) or a plan whose host is server.URL.
+//
+// Tests add the owners, repositories and projects a plan needs, run the code
+// under test, and then assert on the repositories and boards. Read them only
+// while no requests are in flight.
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake GitHub serving REST under /api/v3 and GraphQL at
+// /api/graphql. Any bearer token is accepted, but requests without one are
+// rejected.
+type Server struct {
+	*httptest.Server
+
+	// Viewer is the login of the authenticated user.
+	Viewer string
+	// IssueDependencies is whether the schema has native issue dependencies
+	// (addBlockedBy and Issue.blockedBy), which GitHub Enterprise Server may
+	// not. It is true unless a test turns it off.
+	IssueDependencies bool
+	// SubIssues is whether the schema has sub-issues (addSubIssue and
+	// Issue.parent), which older GitHub Enterprise Server versions do not. It is
+	// true unless a test turns it off.
+	SubIssues bool
+
+	mu     sync.Mutex
+	owners []*Owner
+	repos  []*Repository
+	nodes  map[string]interface{}
+	nextID int
+	// used counts the requests made against each rate limit resource until reset.
+	used  map[string]int
+	reset time.Time
+}
+
+// rateLimits is the budget of each rate limit resource per hour.
+var rateLimits = map[string]int{"core": 5000, "graphql": 5000, "search": 30}
+
+// Owner is a user or organization.
+type Owner struct {
+	ID           string
+	Login        string
+	Organization bool
+	Projects     []*Project
+}
+
+// Repository is a repository with its issues, milestones and labels.
+type Repository struct {
+	ID         string
+	Owner      *Owner
+	Name       string
+	Issues     []*Issue
+	Milestones []*Milestone
+	Labels     []*Label
+
+	server *Server
+}
+
+// Issue is an issue. State is OPEN or CLOSED and StateReason, for closed issues,
+// COMPLETED or NOT_PLANNED.
+type Issue struct {
+	ID          string
+	Number      int
+	Repository  *Repository
+	Title       string
+	Body        string
+	State       string
+	StateReason string
+	Milestone   *Milestone
+	Labels      []*Label
+	Assignees   []*Owner
+	Parent      *Issue
+	BlockedBy   []*Issue
+}
+
+// Milestone is a milestone. State is open or closed, as in the REST API.
+type Milestone struct {
+	ID          string
+	Number      int
+	Title       string
+	Description string
+	State       string
+	DueOn       *time.Time
+}
+
+// Label is a label. Color is six hex digits without #.
+type Label struct {
+	ID          string
+	Name        string
+	Color       string
+	Description string
+}
+
+// Project is a Project V2 board.
+type Project struct {
+	ID     string
+	Number int
+	Owner  *Owner
+	Title  string
+	Fields []*Field
+	Items  []*Item
+
+	server *Server
+}
+
+// Field is a field of a board. DataType is a Projects V2 data type, such as
+// TEXT or SINGLE_SELECT.
+type Field struct {
+	ID         string
+	Name       string
+	DataType   string
+	Options    []*Option
+	Iterations []*Iteration
+}
+
+// Option is an option of a single-select field.
+type Option struct {
+	ID   string
+	Name string
+}
+
+// Iteration is an iteration of an iteration field. StartDate is YYYY-MM-DD and
+// Duration is in days.
+type Iteration struct {
+	ID        string
+	Title     string
+	StartDate string
+	Duration  int
+	Completed bool
+}
+
+// Item is an issue on a board, or a draft issue when Issue is nil. Values maps
+// field names to the values set on the item, formatted as export reads them:
+// text, numbers, YYYY-MM-DD dates, option names and iteration titles.
+type Item struct {
+	ID     string
+	Issue  *Issue
+	Draft  string
+	Values map[string]string
+}
+
+// title returns the title of the item's issue or draft.
+func (it *Item) title() string {
+	if it.Issue == nil {
+		return it.Draft
+	}
+	return it.Issue.Title
+}
+
+// New starts a fake GitHub with the user octocat as viewer. Close it when done.
+func New() *Server {
+	s := &Server{
+		Viewer:            "octocat",
+		IssueDependencies: true,
+		SubIssues:         true,
+		nodes:             make(map[string]interface{}),
+		used:              make(map[string]int),
+		reset:             time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+	s.AddUser("octocat")
+	s.Server = httptest.NewServer(s.handler())
+	return s
+}
+
+// newID returns a new node ID with the given prefix and registers node under it.
+func (s *Server) newID(prefix string, node interface{}) string {
+	s.nextID++
+	id := fmt.Sprintf("%s_%d", prefix, s.nextID)
+	s.nodes[id] = node
+	return id
+}
+
+// AddUser adds a user, who can own repositories and projects and be assigned.
+func (s *Server) AddUser(login string) *Owner {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addOwner(login, false)
+}
+
+// AddOrganization adds an organization.
+func (s *Server) AddOrganization(login string) *Owner {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addOwner(login, true)
+}
+
+func (s *Server) addOwner(login string, organization bool) *Owner {
+	if o := s.owner(login); o != nil {
+		return o
+	}
+	o := &Owner{Login: login, Organization: organization}
+	prefix := "U"
+	if organization {
+		prefix = "O"
+	}
+	o.ID = s.newID(prefix, o)
+	s.owners = append(s.owners, o)
+	return o
+}
+
+// owner returns the user or organization with the given login, or nil.
+func (s *Server) owner(login string) *Owner {
+	for _, o := range s.owners {
+		if strings.EqualFold(o.Login, login) {
+			return o
+		}
+	}
+	return nil
+}
+
+// AddRepository adds a repository. An owner that does not exist yet is added
+// as an organization.
+func (s *Server) AddRepository(owner, name string) *Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := &Repository{Owner: s.addOwner(owner, true), Name: name, server: s}
+	r.ID = s.newID("R", r)
+	s.repos = append(s.repos, r)
+	return r
+}
+
+// Repository returns the repository owner/name, or nil.
+func (s *Server) Repository(owner, name string) *Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.repository(owner, name)
+}
+
+func (s *Server) repository(owner, name string) *Repository {
+	for _, r := range s.repos {
+		if strings.EqualFold(r.Owner.Login, owner) && strings.EqualFold(r.Name, name) {
+			return r
+		}
+	}
+	return nil
+}
+
+// AddProject adds a board with the fields GitHub creates by default: Title,
+// Assignees, Status (Todo, In Progress and Done), Labels, Milestone and
+// Repository. An owner that does not exist yet is added as an organization.
+func (s *Server) AddProject(owner, title string) *Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.addOwner(owner, true)
+	p := &Project{Owner: o, Title: title, Number: len(o.Projects) + 1, server: s}
+	p.ID = s.newID("PVT", p)
+	o.Projects = append(o.Projects, p)
+	p.addField("Title", "TITLE")
+	p.addField("Assignees", "ASSIGNEES")
+	p.addField("Status", "SINGLE_SELECT", "Todo", "In Progress", "Done")
+	p.addField("Labels", "LABELS")
+	p.addField("Milestone", "MILESTONE")
+	p.addField("Repository", "REPOSITORY")
+	return p
+}
+
+// Project returns the board of owner with the given title, or nil.
+func (s *Server) Project(owner, title string) *Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o := s.owner(owner); o != nil {
+		for _, p := range o.Projects {
+			if p.Title == title {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// AddField adds a custom field, such as a TEXT, NUMBER, DATE or SINGLE_SELECT
+// field with the given options.
+func (p *Project) AddField(name, dataType string, options ...string) *Field {
+	p.server.mu.Lock()
+	defer p.server.mu.Unlock()
+	return p.addField(name, dataType, options...)
+}
+
+func (p *Project) addField(name, dataType string, options ...string) *Field {
+	f := &Field{Name: name, DataType: dataType}
+	prefix := "PVTF"
+	switch dataType {
+	case "SINGLE_SELECT":
+		prefix = "PVTSSF"
+	case "ITERATION":
+		prefix = "PVTIF"
+	}
+	f.ID = p.server.newID(prefix, f)
+	for _, name := range options {
+		f.Options = append(f.Options, &Option{ID: p.server.newID("OPT", nil), Name: name})
+	}
+	p.Fields = append(p.Fields, f)
+	return f
+}
+
+// AddIterationField adds an iteration field with the given iterations.
+func (p *Project) AddIterationField(name string, iterations ...Iteration) *Field {
+	p.server.mu.Lock()
+	defer p.server.mu.Unlock()
+	f := p.addField(name, "ITERATION")
+	for _, it := range iterations {
+		it.ID = p.server.newID("IT", nil)
+		f.Iterations = append(f.Iterations, &it)
+	}
+	return f
+}
+
+// Field returns the field with the given name, or nil.
+func (p *Project) Field(name string) *Field {
+	for _, f := range p.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// AddItem adds an issue to the board with the given field values; see Item.
+func (p *Project) AddItem(issue *Issue, values map[string]string) *Item {
+	p.server.mu.Lock()
+	defer p.server.mu.Unlock()
+	it := p.addItem(issue)
+	for name, v := range values {
+		it.Values[name] = v
+	}
+	return it
+}
+
+// addItem adds issue to the board unless it is already on it, and returns its item.
+func (p *Project) addItem(issue *Issue) *Item {
+	for _, it := range p.Items {
+		if it.Issue == issue {
+			return it
+		}
+	}
+	it := &Item{Issue: issue, Values: make(map[string]string)}
+	it.ID = p.server.newID("PVTI", it)
+	p.Items = append(p.Items, it)
+	return it
+}
+
+// AddDraftItem adds a draft issue, which the client skips, to the board.
+func (p *Project) AddDraftItem(title string) *Item {
+	p.server.mu.Lock()
+	defer p.server.mu.Unlock()
+	it := &Item{Draft: title, Values: make(map[string]string)}
+	it.ID = p.server.newID("PVTI", it)
+	p.Items = append(p.Items, it)
+	return it
+}
+
+// Item returns the item of the issue or draft with the given title, or nil.
+func (p *Project) Item(title string) *Item {
+	for _, it := range p.Items {
+		if it.title() == title {
+			return it
+		}
+	}
+	return nil
+}
+
+// AddLabel adds a label.
+func (r *Repository) AddLabel(name, color, description string) *Label {
+	r.server.mu.Lock()
+	defer r.server.mu.Unlock()
+	return r.addLabel(name, color, description)
+}
+
+func (r *Repository) addLabel(name, color, description string) *Label {
+	l := &Label{Name: name, Color: color, Description: description}
+	l.ID = r.server.newID("LA", l)
+	r.Labels = append(r.Labels, l)
+	return l
+}
+
+// Label returns the label with the given name, ignoring case, or nil.
+func (r *Repository) Label(name string) *Label {
+	for _, l := range r.Labels {
+		if strings.EqualFold(l.Name, name) {
+			return l
+		}
+	}
+	return nil
+}
+
+// AddMilestone adds an open milestone.
+func (r *Repository) AddMilestone(title, description string) *Milestone {
+	r.server.mu.Lock()
+	defer r.server.mu.Unlock()
+	return r.addMilestone(title, description)
+}
+
+func (r *Repository) addMilestone(title, description string) *Milestone {
+	m := &Milestone{Number: len(r.Milestones) + 1, Title: title, Description: description, State: "open"}
+	m.ID = r.server.newID("MI", m)
+	r.Milestones = append(r.Milestones, m)
+	return m
+}
+
+// Milestone returns the milestone with the given title, or nil.
+func (r *Repository) Milestone(title string) *Milestone {
+	for _, m := range r.Milestones {
+		if m.Title == title {
+			return m
+		}
+	}
+	return nil
+}
+
+// AddIssue adds an open issue.
+func (r *Repository) AddIssue(title, body string) *Issue {
+	r.server.mu.Lock()
+	defer r.server.mu.Unlock()
+	return r.addIssue(title, body)
+}
+
+func (r *Repository) addIssue(title, body string) *Issue {
+	i := &Issue{Number: len(r.Issues) + 1, Repository: r, Title: title, Body: body, State: "OPEN"}
+	i.ID = r.server.newID("I", i)
+	r.Issues = append(r.Issues, i)
+	return i
+}
+
+// Issue returns the first issue with the given title, or nil.
+func (r *Repository) Issue(title string) *Issue {
+	for _, i := range r.Issues {
+		if i.Title == title {
+			return i
+		}
+	}
+	return nil
+}
+
+// LabelNames returns the names of the issue's labels.
+func (i *Issue) LabelNames() []string {
+	var names []string
+	for _, l := range i.Labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// AssigneeLogins returns the logins of the issue's assignees.
+func (i *Issue) AssigneeLogins() []string {
+	var logins []string
+	for _, a := range i.Assignees {
+		logins = append(logins, a.Login)
+	}
+	return logins
+}
+
+// handler routes the REST and GraphQL endpoints. Every request is served with
+// the server locked, so state changes are atomic.
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/graphql", s.serveGraphQL)
+	mux.HandleFunc("GET /api/v3/user", s.getViewer)
+	mux.HandleFunc("GET /api/v3/search/issues", s.searchIssues)
+	mux.HandleFunc("GET /api/v3/repos/{owner}/{repo}/issues", s.listIssues)
+	mux.HandleFunc("GET /api/v3/repos/{owner}/{repo}/milestones", s.listMilestones)
+	mux.HandleFunc("POST /api/v3/repos/{owner}/{repo}/milestones", s.createMilestone)
+	mux.HandleFunc("PATCH /api/v3/repos/{owner}/{repo}/milestones/{number}", s.editMilestone)
+	mux.HandleFunc("GET /api/v3/repos/{owner}/{repo}/labels", s.listLabels)
+	mux.HandleFunc("POST /api/v3/repos/{owner}/{repo}/labels", s.createLabel)
+	mux.HandleFunc("GET /api/v3/repos/{owner}/{repo}/labels/{name}", s.getLabel)
+	mux.HandleFunc("PATCH /api/v3/repos/{owner}/{repo}/labels/{name}", s.editLabel)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") && !strings.HasPrefix(r.Header.Get("Authorization"), "token ") {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Requires authentication"})
+			return
+		}
+		resource := "core"
+		switch {
+		case r.URL.Path == "/api/graphql":
+			resource = "graphql"
+		case strings.HasPrefix(r.URL.Path, "/api/v3/search/"):
+			resource = "search"
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.used[resource]++
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rateLimits[resource]))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(rateLimits[resource]-s.used[resource], 0)))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(s.reset.Unix(), 10))
+		w.Header().Set("X-RateLimit-Resource", resource)
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// writeJSON writes v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,330 @@
+package fakegithub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goblinsan/gh-project-helper/pkg/github"
+	gogithub "github.com/google/go-github/v66/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// newClient returns a client for s authenticated with a test token.
+func newClient(t *testing.T, s *Server) *github.Client {
+	t.Helper()
+	t.Setenv("GH_PROJECT_HELPER_TOKEN", "test-token")
+	client, err := github.NewClientForHost(s.URL)
+	if err != nil {
+		t.Fatalf("NewClientForHost failed: %v", err)
+	}
+	return client
+}
+
+func TestServer_Lookups(t *testing.T) {
+	s := New()
+	defer s.Close()
+	repo := s.AddRepository("acme", "roadmap")
+	project := s.AddProject("acme", "Roadmap")
+	project.AddField("Priority", "SINGLE_SELECT", "P0", "P1")
+	project.AddIterationField("Sprint", Iteration{Title: "Sprint 1", StartDate: "2026-10-05", Duration: 14, Completed: true}, Iteration{Title: "Sprint 2", StartDate: "2026-10-19", Duration: 14})
+	s.AddUser("hubot")
+	client := newClient(t, s)
+	ctx := context.Background()
+
+	if id, err := client.GetRepositoryID(ctx, "acme", "roadmap"); err != nil || id != repo.ID {
+		t.Errorf("GetRepositoryID = %q, %v", id, err)
+	}
+	if _, err := client.GetRepositoryID(ctx, "acme", "missing"); err == nil {
+		t.Error("expected an error for a missing repository")
+	}
+	// acme is an organization, so this falls back from user to organization
+	if id, err := client.GetProjectV2ID(ctx, "acme", "Roadmap"); err != nil || id != project.ID {
+		t.Errorf("GetProjectV2ID = %q, %v", id, err)
+	}
+
+	fields, err := client.GetProjectV2Fields(ctx, githubv4.ID(project.ID))
+	if err != nil {
+		t.Fatalf("GetProjectV2Fields failed: %v", err)
+	}
+	var summary []string
+	for _, f := range fields {
+		summary = append(summary, fmt.Sprintf("%s %s %d %d", f.Name, f.DataType, len(f.Options), len(f.Iterations)))
+	}
+	expected := "[Title TITLE 0 0 Assignees ASSIGNEES 0 0 Status SINGLE_SELECT 3 0 Labels LABELS 0 0 Milestone MILESTONE 0 0 Repository REPOSITORY 0 0 Priority SINGLE_SELECT 2 0 Sprint ITERATION 0 2]"
+	if fmt.Sprint(summary) != expected {
+		t.Errorf("unexpected fields:\n got %v\nwant %s", summary, expected)
+	}
+	statusID, options, err := client.GetProjectV2StatusFieldOptions(ctx, githubv4.ID(project.ID))
+	if err != nil || statusID != project.Field("Status").ID || len(options) != 3 {
+		t.Errorf("GetProjectV2StatusFieldOptions = %v, %v, %v", statusID, options, err)
+	}
+
+	if id, err := client.GetUserID(ctx, "hubot"); err != nil || id == "" {
+		t.Errorf("GetUserID = %v, %v", id, err)
+	}
+	if supported, err := client.SupportsIssueDependencies(ctx); err != nil || !supported {
+		t.Errorf("SupportsIssueDependencies = %v, %v", supported, err)
+	}
+	s.IssueDependencies = false
+	if supported, err := client.SupportsIssueDependencies(ctx); err != nil || supported {
+		t.Errorf("expected no issue dependencies once turned off, got %v, %v", supported, err)
+	}
+	if user, scopes, err := client.GetAuthenticatedUser(ctx); err != nil || user.GetLogin() != "octocat" || len(scopes) != 2 {
+		t.Errorf("GetAuthenticatedUser = %v, %v, %v", user, scopes, err)
+	}
+}
+
+func TestServer_MilestonesAndLabels(t *testing.T) {
+	s := New()
+	defer s.Close()
+	repo := s.AddRepository("acme", "roadmap")
+	repo.AddLabel("bug", "d73a4a", "Something isn't working")
+	client := newClient(t, s)
+	ctx := context.Background()
+
+	created, err := client.CreateMilestone(ctx, "acme", "roadmap", &gogithub.Milestone{Title: gogithub.String("Phase 1"), Description: gogithub.String("First")})
+	if err != nil || created.GetNumber() != 1 {
+		t.Fatalf("CreateMilestone = %v, %v", created, err)
+	}
+	if _, err := client.CreateMilestone(ctx, "acme", "roadmap", &gogithub.Milestone{Title: gogithub.String("Phase 1")}); err == nil {
+		t.Error("expected an error for a duplicate milestone")
+	}
+	if _, err := client.EditMilestone(ctx, "acme", "roadmap", 1, &gogithub.Milestone{State: gogithub.String("closed")}); err != nil {
+		t.Fatalf("EditMilestone failed: %v", err)
+	}
+	milestones, err := client.ListMilestones(ctx, "acme", "roadmap")
+	if err != nil || len(milestones) != 1 || milestones[0].GetState() != "closed" || milestones[0].GetDescription() != "First" {
+		t.Errorf("ListMilestones = %v, %v", milestones, err)
+	}
+	if id, err := client.GetMilestoneID(ctx, "acme", "roadmap", 1); err != nil || id != repo.Milestone("Phase 1").ID {
+		t.Errorf("GetMilestoneID = %q, %v", id, err)
+	}
+
+	if id, err := client.GetOrCreateLabel(ctx, "acme", "roadmap", "bug"); err != nil || id != repo.Label("bug").ID {
+		t.Errorf("GetOrCreateLabel(bug) = %v, %v", id, err)
+	}
+	if _, err := client.GetOrCreateLabel(ctx, "acme", "roadmap", "area/engine"); err != nil {
+		t.Errorf("GetOrCreateLabel(area/engine) failed: %v", err)
+	}
+	if _, err := client.CreateLabel(ctx, "acme", "roadmap", &gogithub.Label{Name: gogithub.String("BUG")}); err == nil {
+		t.Error("expected an error for a label that differs only in case")
+	}
+	if _, err := client.EditLabel(ctx, "acme", "roadmap", "bug", &gogithub.Label{Name: gogithub.String("defect"), Color: gogithub.String("ff0000")}); err != nil {
+		t.Fatalf("EditLabel failed: %v", err)
+	}
+	labels, err := client.ListLabels(ctx, "acme", "roadmap")
+	if err != nil {
+		t.Fatalf("ListLabels failed: %v", err)
+	}
+	var names []string
+	for _, l := range labels {
+		names = append(names, l.GetName()+" "+l.GetColor())
+	}
+	if fmt.Sprint(names) != "[area/engine ededed defect ff0000]" {
+		t.Errorf("unexpected labels %v", names)
+	}
+}
+
+func TestServer_IssuesAndBoard(t *testing.T) {
+	s := New()
+	defer s.Close()
+	repo := s.AddRepository("acme", "roadmap")
+	milestone := repo.AddMilestone("Phase 1", "")
+	label := repo.AddLabel("enhancement", "a2eeef", "")
+	project := s.AddProject("acme", "Roadmap")
+	project.AddField("Estimate", "NUMBER")
+	project.AddField("Due", "DATE")
+	sprint := project.AddIterationField("Sprint", Iteration{Title: "Sprint 1", StartDate: "2026-10-05", Duration: 14})
+	client := newClient(t, s)
+	ctx := context.Background()
+
+	userID, _ := client.GetUserID(ctx, "octocat")
+	epic, err := client.CreateIssue(ctx, githubv4.CreateIssueInput{
+		RepositoryID: githubv4.ID(repo.ID),
+		Title:        "Epic",
+		Body:         githubv4.NewString("The epic"),
+		MilestoneID:  githubv4.NewID(githubv4.ID(milestone.ID)),
+		LabelIDs:     &[]githubv4.ID{githubv4.ID(label.ID)},
+		AssigneeIDs:  &[]githubv4.ID{userID},
+	})
+	if err != nil || epic.CreateIssue.Issue.Number != 1 {
+		t.Fatalf("CreateIssue = %+v, %v", epic, err)
+	}
+	children, err := client.CreateIssues(ctx, []githubv4.CreateIssueInput{
+		{RepositoryID: githubv4.ID(repo.ID), Title: "Child one"},
+		{RepositoryID: githubv4.ID(repo.ID), Title: "Child two"},
+	})
+	if err != nil || len(children) != 2 || children[1].CreateIssue.Issue.Number != 3 {
+		t.Fatalf("CreateIssues = %+v, %v", children, err)
+	}
+	one, two := repo.Issue("Child one"), repo.Issue("Child two")
+
+	if number, id, err := client.FindIssueByTitle(ctx, "acme", "roadmap", "Child two"); err != nil || number != 3 || id != two.ID {
+		t.Errorf("FindIssueByTitle = %d, %q, %v", number, id, err)
+	}
+	if number, _, err := client.FindIssueByTitle(ctx, "acme", "roadmap", "Nothing like it"); err != nil || number != 0 {
+		t.Errorf("expected no issue found, got %d, %v", number, err)
+	}
+
+	item, err := client.AddIssueToProjectV2(ctx, githubv4.ID(project.ID), epic.CreateIssue.Issue.ID)
+	if err != nil {
+		t.Fatalf("AddIssueToProjectV2 failed: %v", err)
+	}
+	itemIDs, err := client.AddIssuesToProjectV2(ctx, githubv4.ID(project.ID), []githubv4.ID{githubv4.ID(one.ID), githubv4.ID(two.ID)})
+	if err != nil || len(itemIDs) != 2 {
+		t.Fatalf("AddIssuesToProjectV2 = %v, %v", itemIDs, err)
+	}
+	status := project.Field("Status")
+	if err := client.UpdateProjectV2ItemStatus(ctx, githubv4.ID(project.ID), item.AddProjectV2ItemById.Item.ID, githubv4.ID(status.ID), status.Options[1].ID); err != nil {
+		t.Fatalf("UpdateProjectV2ItemStatus failed: %v", err)
+	}
+	estimate := githubv4.Float(3)
+	due := githubv4.Date{Time: mustDate(t, "2026-10-30")}
+	sprintID := githubv4.String(sprint.Iterations[0].ID)
+	err = client.UpdateProjectV2ItemFieldValues(ctx, []githubv4.UpdateProjectV2ItemFieldValueInput{
+		{ProjectID: githubv4.ID(project.ID), ItemID: itemIDs[0], FieldID: githubv4.ID(project.Field("Estimate").ID), Value: githubv4.ProjectV2FieldValue{Number: &estimate}},
+		{ProjectID: githubv4.ID(project.ID), ItemID: itemIDs[0], FieldID: githubv4.ID(project.Field("Due").ID), Value: githubv4.ProjectV2FieldValue{Date: &due}},
+		{ProjectID: githubv4.ID(project.ID), ItemID: itemIDs[0], FieldID: githubv4.ID(sprint.ID), Value: githubv4.ProjectV2FieldValue{IterationID: &sprintID}},
+		{ProjectID: githubv4.ID(project.ID), ItemID: itemIDs[1], FieldID: githubv4.ID(status.ID), Value: githubv4.ProjectV2FieldValue{SingleSelectOptionID: githubv4.NewString("no-such-option")}},
+	})
+	var batchErr *github.BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 1 || batchErr.Errors[3] == nil {
+		t.Errorf("expected only the unknown option to fail, got %v", err)
+	}
+
+	if err := client.AddSubIssue(ctx, epic.CreateIssue.Issue.ID, githubv4.ID(one.ID), false); err != nil {
+		t.Fatalf("AddSubIssue failed: %v", err)
+	}
+	if err := client.AddSubIssue(ctx, githubv4.ID(two.ID), githubv4.ID(one.ID), false); err == nil {
+		t.Error("expected an error when moving a sub-issue without replaceParent")
+	}
+	if err := client.AddBlockedBy(ctx, githubv4.ID(two.ID), githubv4.ID(one.ID)); err != nil {
+		t.Fatalf("AddBlockedBy failed: %v", err)
+	}
+	if blockers, err := client.GetBlockedBy(ctx, "acme", "roadmap", 3); err != nil || fmt.Sprint(blockers) != "[2]" {
+		t.Errorf("GetBlockedBy = %v, %v", blockers, err)
+	}
+
+	details, err := client.GetIssue(ctx, "acme", "roadmap", 2)
+	if err != nil {
+		t.Fatalf("GetIssue failed: %v", err)
+	}
+	ref, ok := details.ProjectItem(project.ID)
+	if !ok || details.ParentNumber != 1 {
+		t.Fatalf("unexpected issue details %+v", details)
+	}
+	if want := "map[Due:2026-10-30 Estimate:3 Sprint:Sprint 1 Title:Child one]"; fmt.Sprint(ref.Fields) != want {
+		t.Errorf("unexpected field values %v, want %s", ref.Fields, want)
+	}
+
+	items, err := client.ListProjectV2Items(ctx, githubv4.ID(project.ID))
+	if err != nil || len(items) != 3 {
+		t.Fatalf("ListProjectV2Items = %v, %v", items, err)
+	}
+	first := items[0]
+	if first.Issue.Milestone != "Phase 1" || fmt.Sprint(first.Issue.Labels, first.Issue.Assignees) != "[enhancement] [octocat]" || first.Issue.ProjectItems[0].Status != "In Progress" {
+		t.Errorf("unexpected first item %+v", first)
+	}
+
+	if err := client.UpdateIssue(ctx, githubv4.UpdateIssueInput{ID: githubv4.ID(two.ID), Title: githubv4.NewString("Child 2")}); err != nil {
+		t.Fatalf("UpdateIssue failed: %v", err)
+	}
+	if err := client.CloseIssue(ctx, githubv4.ID(two.ID), githubv4.IssueClosedStateReasonNotPlanned); err != nil {
+		t.Fatalf("CloseIssue failed: %v", err)
+	}
+	if err := client.DeleteProjectV2Item(ctx, githubv4.ID(project.ID), itemIDs[1]); err != nil {
+		t.Fatalf("DeleteProjectV2Item failed: %v", err)
+	}
+	issues, err := client.ListIssues(ctx, "acme", "roadmap")
+	if err != nil || len(issues) != 3 || issues[0].GetTitle() != "Child 2" || issues[0].GetState() != "closed" {
+		t.Errorf("ListIssues = %v, %v", issues, err)
+	}
+	if two.StateReason != "NOT_PLANNED" || project.Item("Child 2") != nil || len(project.Items) != 2 {
+		t.Errorf("unexpected board after closing and removing: %q, %d items", two.StateReason, len(project.Items))
+	}
+}
+
+func TestServer_WithoutSubIssues(t *testing.T) {
+	s := New()
+	defer s.Close()
+	s.SubIssues = false
+	repo := s.AddRepository("acme", "roadmap")
+	epic, child := repo.AddIssue("Epic", ""), repo.AddIssue("Child", "")
+	child.Parent = epic
+	project := s.AddProject("acme", "Roadmap")
+	project.AddItem(child, nil)
+	client := newClient(t, s)
+	ctx := context.Background()
+
+	if supported, err := client.SupportsSubIssues(ctx); err != nil || supported {
+		t.Errorf("expected no sub-issues, got %v, %v", supported, err)
+	}
+	issue, err := client.GetIssue(ctx, "acme", "roadmap", child.Number)
+	if err != nil || issue.Title != "Child" || issue.ParentNumber != 0 {
+		t.Errorf("GetIssue = %+v, %v", issue, err)
+	}
+	items, err := client.ListProjectV2Items(ctx, githubv4.ID(project.ID))
+	if err != nil || len(items) != 1 || items[0].Issue.ParentNumber != 0 {
+		t.Errorf("ListProjectV2Items = %+v, %v", items, err)
+	}
+}
+
+func TestServer_ItemsWithLongLists(t *testing.T) {
+	s := New()
+	defer s.Close()
+	repo := s.AddRepository("acme", "roadmap")
+	issue := repo.AddIssue("Busy", "")
+	for i := 0; i < 105; i++ {
+		issue.Labels = append(issue.Labels, repo.AddLabel(fmt.Sprintf("label-%d", i), "ededed", ""))
+	}
+	project := s.AddProject("acme", "Roadmap")
+	project.AddItem(issue, nil)
+	client := newClient(t, s)
+	ctx := context.Background()
+
+	items, err := client.ListProjectV2Items(ctx, githubv4.ID(project.ID))
+	if err != nil || len(items) != 1 || len(items[0].Issue.Labels) != 105 {
+		t.Fatalf("expected every label to be read, got %+v, %v", items, err)
+	}
+	if last := items[0].Issue.Labels[104]; last != "label-104" {
+		t.Errorf("unexpected last label %q", last)
+	}
+
+	// More assignees than one page is an error rather than a partial list
+	for i := 0; i < 101; i++ {
+		issue.Assignees = append(issue.Assignees, s.AddUser(fmt.Sprintf("user-%d", i)))
+	}
+	if _, err := client.ListProjectV2Items(ctx, githubv4.ID(project.ID)); err == nil || !strings.Contains(err.Error(), "more than 100 assignees") {
+		t.Errorf("expected a truncated assignee list to fail, got %v", err)
+	}
+	if _, err := client.GetIssue(ctx, "acme", "roadmap", issue.Number); err == nil || !strings.Contains(err.Error(), "more than 100 assignees") {
+		t.Errorf("expected GetIssue to fail on a truncated assignee list, got %v", err)
+	}
+}
+
+func TestServer_RequiresToken(t *testing.T) {
+	s := New()
+	defer s.Close()
+	resp, err := http.Post(s.URL+"/api/graphql", "application/json", strings.NewReader(`{"query":"{viewer{login}}"}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %s", resp.Status)
+	}
+}
+
+func mustDate(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
@@ -0,0 +1,74 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEndpoints(t *testing.T) {
+	tests := []struct {
+		host, rest, graphql string
+	}{
+		{"", "https://api.github.com/", "https://api.github.com/graphql"},
+		{"github.com", "https://api.github.com/", "https://api.github.com/graphql"},
+		{"github.example.com", "https://github.example.com/api/v3/", "https://github.example.com/api/graphql"},
+		{"http://127.0.0.1:8080/", "http://127.0.0.1:8080/api/v3/", "http://127.0.0.1:8080/api/graphql"},
+	}
+	for _, tt := range tests {
+		rest, graphql, err := Endpoints(tt.host)
+		if err != nil {
+			t.Errorf("Endpoints(%q) failed: %v", tt.host, err)
+			continue
+		}
+		if rest != tt.rest || graphql != tt.graphql {
+			t.Errorf("Endpoints(%q) = %s, %s; want %s, %s", tt.host, rest, graphql, tt.rest, tt.graphql)
+		}
+	}
+
+	if _, _, err := Endpoints("http://"); err == nil {
+		t.Error("expected an error for a host without a name")
+	}
+}
+
+func TestNewClientForHost_Enterprise(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, r.Header.Get("Authorization")))
+		switch r.URL.Path {
+		case "/api/graphql":
+			fmt.Fprint(w, `{"data":{"repository":{"id":"R_1"}}}`)
+		case "/api/v3/repos/owner/repo/milestones":
+			fmt.Fprint(w, `[{"number":1,"title":"Phase 1"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("GH_PROJECT_HELPER_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "public-token")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "enterprise-token")
+
+	client, err := NewClientForHost(server.URL)
+	if err != nil {
+		t.Fatalf("NewClientForHost failed: %v", err)
+	}
+	repoID, err := client.GetRepositoryID(context.Background(), "owner", "repo")
+	if err != nil || repoID != "R_1" {
+		t.Fatalf("GetRepositoryID = %q, %v", repoID, err)
+	}
+	milestones, err := client.ListMilestones(context.Background(), "owner", "repo")
+	if err != nil || len(milestones) != 1 {
+		t.Fatalf("ListMilestones = %v, %v", milestones, err)
+	}
+
+	expected := []string{
+		"POST /api/graphql Bearer enterprise-token",
+		"GET /api/v3/repos/owner/repo/milestones Bearer enterprise-token",
+	}
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("unexpected requests:\n got %v\nwant %v", requests, expected)
+	}
+}
@@ -0,0 +1,59 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// PageInfo is the pagination state of a GraphQL connection. Paginated queries
+// select it next to the connection's nodes and take `after: $cursor`.
+type PageInfo struct {
+	HasNextPage bool
+	EndCursor   githubv4.String
+}
+
+// paginate runs a GraphQL query of type Q once per page of a connection. The
+// $cursor variable starts at the first page unless variables already set it;
+// page is called with each result and returns the connection's PageInfo.
+// Returning a zero PageInfo stops early.
+func paginate[Q any](ctx context.Context, client *githubv4.Client, variables map[string]interface{}, page func(*Q) PageInfo) error {
+	vars := make(map[string]interface{}, len(variables)+1)
+	for k, v := range variables {
+		vars[k] = v
+	}
+	if _, ok := vars["cursor"]; !ok {
+		vars["cursor"] = (*githubv4.String)(nil)
+	}
+	for {
+		var query Q
+		if err := client.Query(ctx, &query, vars); err != nil {
+			return err
+		}
+		info := page(&query)
+		if !info.HasNextPage {
+			return nil
+		}
+		vars["cursor"] = githubv4.NewString(info.EndCursor)
+	}
+}
+
+// restPerPage is the page size for paginated REST calls, the API's maximum.
+const restPerPage = 100
+
+// paginateREST calls fetch for each page of a REST list, starting with the first
+// (page 0), until the response has no next page.
+func paginateREST(fetch func(page int) (*github.Response, error)) error {
+	page := 0
+	for {
+		resp, err := fetch(page)
+		if err != nil {
+			return err
+		}
+		if resp.NextPage == 0 {
+			return nil
+		}
+		page = resp.NextPage
+	}
+}
@@ -0,0 +1,104 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// newTestClient returns a Client whose REST and GraphQL calls go to handler.
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	base, _ := url.Parse(server.URL + "/")
+	rest := github.NewClient(server.Client())
+	rest.BaseURL = base
+	return &Client{
+		REST:       rest,
+		GraphQL:    githubv4.NewEnterpriseClient(server.URL+"/graphql", server.Client()),
+		httpClient: server.Client(),
+		graphqlURL: server.URL + "/graphql",
+	}
+}
+
+func TestGetBlockedBy_Paginates(t *testing.T) {
+	var cursors []interface{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		cursors = append(cursors, req.Variables["cursor"])
+		page := `{"nodes":[{"number":1},{"number":2}],"pageInfo":{"hasNextPage":true,"endCursor":"c1"}}`
+		if req.Variables["cursor"] == "c1" {
+			page = `{"nodes":[{"number":3}],"pageInfo":{"hasNextPage":false,"endCursor":"c2"}}`
+		}
+		fmt.Fprintf(w, `{"data":{"repository":{"issue":{"blockedBy":%s}}}}`, page)
+	}))
+
+	numbers, err := client.GetBlockedBy(context.Background(), "owner", "repo", 7)
+	if err != nil {
+		t.Fatalf("GetBlockedBy failed: %v", err)
+	}
+	if fmt.Sprint(numbers) != "[1 2 3]" {
+		t.Errorf("expected blockers from both pages, got %v", numbers)
+	}
+	if fmt.Sprint(cursors) != "[<nil> c1]" {
+		t.Errorf("unexpected cursors: %v", cursors)
+	}
+}
+
+func TestGetProjectV2StatusFieldOptions_StopsAtStatus(t *testing.T) {
+	requests := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page := `{"nodes":[{"id":"f1","name":"Priority","options":[]}],"pageInfo":{"hasNextPage":true,"endCursor":"c1"}}`
+		if requests == 2 {
+			page = `{"nodes":[{"id":"f2","name":"Status","options":[{"id":"o1","name":"Todo"}]}],"pageInfo":{"hasNextPage":true,"endCursor":"c2"}}`
+		}
+		fmt.Fprintf(w, `{"data":{"node":{"fields":%s}}}`, page)
+	}))
+
+	fieldID, options, err := client.GetProjectV2StatusFieldOptions(context.Background(), githubv4.ID("project"))
+	if err != nil {
+		t.Fatalf("GetProjectV2StatusFieldOptions failed: %v", err)
+	}
+	if fieldID != "f2" || options["Todo"] != "o1" {
+		t.Errorf("unexpected status field %v %v", fieldID, options)
+	}
+	if requests != 2 {
+		t.Errorf("expected to stop after the page with Status, made %d requests", requests)
+	}
+}
+
+func TestListMilestones_Paginates(t *testing.T) {
+	var states []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		states = append(states, r.URL.Query().Get("state"))
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"number":2,"title":"Closed","state":"closed"}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2>; rel="next"`, r.Host, r.URL.Path))
+		fmt.Fprint(w, `[{"number":1,"title":"Open","state":"open"}]`)
+	}))
+
+	milestones, err := client.ListMilestones(context.Background(), "owner", "repo")
+	if err != nil {
+		t.Fatalf("ListMilestones failed: %v", err)
+	}
+	if len(milestones) != 2 || milestones[1].GetTitle() != "Closed" {
+		t.Errorf("expected milestones from both pages, got %v", milestones)
+	}
+	if states[0] != "all" {
+		t.Errorf("expected milestones in every state, got state=%q", states[0])
+	}
+}
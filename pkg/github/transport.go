@@ -0,0 +1,268 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is the request budget GitHub last reported for one API resource,
+// such as "core" for REST or "graphql". The GraphQL budget is counted in points,
+// so it reflects the cost of each query rather than the number of requests.
+type RateLimit struct {
+	Resource  string
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+const (
+	defaultMaxRetries = 5
+	// backoffBase and backoffMax bound the jittered backoff for server errors.
+	backoffBase = time.Second
+	backoffMax  = time.Minute
+	// secondaryLimitWait is how long to wait after a secondary rate limit that
+	// names no Retry-After, as GitHub's documentation recommends.
+	secondaryLimitWait = time.Minute
+)
+
+// Transport is an http.RoundTripper that retries requests GitHub rejected for
+// rate limits or transient server errors, and records the rate limit budget
+// reported by every response.
+type Transport struct {
+	Base http.RoundTripper
+	// MaxRetries is the number of retries per request; zero means the default.
+	MaxRetries int
+	// OnWait, if set, is called before each wait with its length and reason.
+	OnWait func(wait time.Duration, reason string)
+
+	// sleep and now replace the clock in tests
+	sleep  func(ctx context.Context, d time.Duration) error
+	now    func() time.Time
+	mu     sync.Mutex
+	limits map[string]RateLimit
+}
+
+// NewTransport wraps base, or http.DefaultTransport when base is nil.
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base}
+}
+
+// RoundTrip sends the request, waiting and retrying as long as GitHub asks.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	maxRetries := t.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+	for attempt := 0; ; attempt++ {
+		r := req.Clone(req.Context())
+		if body != nil {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := t.Base.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		t.record(resp)
+
+		wait, reason, err := t.retryAfter(req, body, resp, attempt)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" || attempt >= maxRetries {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if t.OnWait != nil {
+			t.OnWait(wait, reason)
+		}
+		sleep := t.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryAfter decides whether resp should be retried, returning how long to wait
+// first and why. An empty reason means the response is final.
+func (t *Transport) retryAfter(req *http.Request, body []byte, resp *http.Response, attempt int) (time.Duration, string, error) {
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		// A server error may come after a write was applied, so only requests
+		// that are safe to repeat are retried. Failed writes are left to the
+		// journal and --resume, which find what was already created.
+		if !idempotent(req, body) {
+			break
+		}
+		return backoff(attempt), resp.Status, nil
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if wait, ok := t.retryAfterHeader(resp); ok {
+			return wait, "rate limited", nil
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return t.untilReset(resp), "rate limit exhausted", nil
+		}
+		body, err := peekBody(resp)
+		if err != nil {
+			return 0, "", err
+		}
+		if strings.Contains(body, "secondary rate limit") || strings.Contains(body, "abuse") {
+			return secondaryLimitWait + backoff(attempt), "secondary rate limit", nil
+		}
+	case http.StatusOK:
+		// GraphQL reports an exhausted budget as an error in a 200 response
+		if !strings.HasSuffix(req.URL.Path, "graphql") {
+			break
+		}
+		body, err := peekBody(resp)
+		if err != nil {
+			return 0, "", err
+		}
+		if strings.Contains(body, `"RATE_LIMITED"`) {
+			return t.untilReset(resp), "GraphQL rate limit exhausted", nil
+		}
+	}
+	return 0, "", nil
+}
+
+// idempotent reports whether req can be sent again without changing anything:
+// a GET or HEAD, or a GraphQL query rather than a mutation.
+func idempotent(req *http.Request, body []byte) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		if !strings.HasSuffix(req.URL.Path, "graphql") {
+			return false
+		}
+		var payload struct {
+			Query string `json:"query"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return false
+		}
+		return !strings.HasPrefix(strings.TrimSpace(payload.Query), "mutation")
+	}
+	return false
+}
+
+// retryAfterHeader reads a Retry-After header given in seconds.
+func (t *Transport) retryAfterHeader(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// untilReset is the time left until the X-RateLimit-Reset of resp, plus a second
+// of slack for clock skew.
+func (t *Transport) untilReset(resp *http.Response) time.Duration {
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return secondaryLimitWait
+	}
+	now := time.Now()
+	if t.now != nil {
+		now = t.now()
+	}
+	wait := time.Unix(reset, 0).Sub(now) + time.Second
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
+
+// record stores the rate limit headers of resp.
+func (t *Transport) record(resp *http.Response) {
+	limit, err1 := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	remaining, err2 := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, err3 := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return
+	}
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limits == nil {
+		t.limits = make(map[string]RateLimit)
+	}
+	t.limits[resource] = RateLimit{Resource: resource, Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0)}
+}
+
+// RateLimit returns the most depleted budget seen so far, as a fraction of its
+// limit. It is the zero RateLimit until a response has reported one.
+func (t *Transport) RateLimit() RateLimit {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var lowest RateLimit
+	for _, l := range t.limits {
+		if l.Limit == 0 {
+			continue
+		}
+		if lowest.Limit == 0 || l.Remaining*lowest.Limit < lowest.Remaining*l.Limit {
+			lowest = l
+		}
+	}
+	return lowest
+}
+
+// backoff is an exponential delay for the given attempt, jittered within its
+// upper half so parallel clients do not retry in step.
+func backoff(attempt int) time.Duration {
+	d := backoffBase << attempt
+	if d <= 0 || d > backoffMax {
+		d = backoffMax
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// peekBody reads the response body and replaces it so it can be read again.
+func peekBody(resp *http.Response) (string, error) {
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return string(data), nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,194 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestTransport returns a Transport that records its waits instead of sleeping.
+func newTestTransport(waits *[]time.Duration) *Transport {
+	t := NewTransport(nil)
+	t.now = func() time.Time { return time.Unix(1000, 0) }
+	t.sleep = func(_ context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	return t
+}
+
+func TestTransport_Retries(t *testing.T) {
+	tests := []struct {
+		name    string
+		first   func(w http.ResponseWriter)
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{
+			name: "retry after",
+			first: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "30")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantMin: 30 * time.Second,
+			wantMax: 30 * time.Second,
+		},
+		{
+			name: "primary limit reset",
+			first: func(w http.ResponseWriter) {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", "1120")
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantMin: 121 * time.Second,
+			wantMax: 121 * time.Second,
+		},
+		{
+			name: "secondary limit",
+			first: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit."}`)
+			},
+			wantMin: secondaryLimitWait,
+			wantMax: secondaryLimitWait + backoffBase,
+		},
+		{
+			name: "bad gateway",
+			first: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			wantMin: backoffBase / 2,
+			wantMax: backoffBase,
+		},
+		{
+			name: "graphql rate limited",
+			first: func(w http.ResponseWriter) {
+				w.Header().Set("X-RateLimit-Reset", "1010")
+				fmt.Fprint(w, `{"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded"}]}`)
+			},
+			wantMin: 11 * time.Second,
+			wantMax: 11 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				if len(bodies) == 1 {
+					tt.first(w)
+					return
+				}
+				fmt.Fprint(w, `{"data":{}}`)
+			}))
+			defer server.Close()
+
+			var waits []time.Duration
+			client := &http.Client{Transport: newTestTransport(&waits)}
+			resp, err := client.Post(server.URL+"/graphql", "application/json", strings.NewReader(`{"query":"q"}`))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK || string(body) != `{"data":{}}` {
+				t.Errorf("expected the retried response, got %d %s", resp.StatusCode, body)
+			}
+			if len(bodies) != 2 || bodies[1] != `{"query":"q"}` {
+				t.Errorf("expected the request body to be resent, got %q", bodies)
+			}
+			if len(waits) != 1 || waits[0] < tt.wantMin || waits[0] > tt.wantMax {
+				t.Errorf("expected one wait in [%s, %s], got %v", tt.wantMin, tt.wantMax, waits)
+			}
+		})
+	}
+}
+
+func TestTransport_GivesUp(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	transport := newTestTransport(&waits)
+	transport.MaxRetries = 2
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || requests != 3 || len(waits) != 2 {
+		t.Errorf("expected 3 attempts and the last error, got %d after %d requests", resp.StatusCode, requests)
+	}
+}
+
+func TestTransport_DoesNotRetryWrites(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{name: "graphql mutation", method: http.MethodPost, path: "/graphql", body: `{"query":"mutation($input:CreateIssueInput!){createIssue(input:$input){issue{id}}}"}`},
+		{name: "rest create", method: http.MethodPost, path: "/repos/o/r/labels", body: `{"name":"bug"}`},
+		{name: "rest edit", method: http.MethodPatch, path: "/repos/o/r/milestones/1", body: `{"state":"closed"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+			defer server.Close()
+
+			var waits []time.Duration
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			resp, err := (&http.Client{Transport: newTestTransport(&waits)}).Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadGateway || requests != 1 || len(waits) != 0 {
+				t.Errorf("expected the error without a retry, got %d after %d requests", resp.StatusCode, requests)
+			}
+		})
+	}
+}
+
+func TestTransport_RateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Resource", r.URL.Query().Get("resource"))
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", r.URL.Query().Get("remaining"))
+		w.Header().Set("X-RateLimit-Reset", "2000")
+	}))
+	defer server.Close()
+
+	transport := NewTransport(nil)
+	if limit := transport.RateLimit(); limit != (RateLimit{}) {
+		t.Errorf("expected no rate limit before any response, got %+v", limit)
+	}
+	client := &http.Client{Transport: transport}
+	for _, query := range []string{"resource=core&remaining=4000", "resource=graphql&remaining=300", "resource=core&remaining=3999"} {
+		resp, err := client.Get(server.URL + "?" + query)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	want := RateLimit{Resource: "graphql", Limit: 5000, Remaining: 300, Reset: time.Unix(2000, 0)}
+	if limit := transport.RateLimit(); limit != want {
+		t.Errorf("expected the most depleted budget %+v, got %+v", want, limit)
+	}
+}
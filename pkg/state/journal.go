@@ -0,0 +1,142 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Step is one mutation journaled by apply for a plan element.
+type Step struct {
+	// Key is the state key of the plan element, or "milestone:" and the key of
+	// a plan milestone.
+	Key     string    `json:"key"`
+	Name    string    `json:"step"`
+	Number  int       `json:"number,omitempty"`
+	NodeID  string    `json:"node_id,omitempty"`
+	ItemID  string    `json:"item_id,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Created bool      `json:"created,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Journal is an append-only log of the mutations made by apply, written and
+// synced to disk as each one succeeds so that an interrupted apply can be
+// resumed exactly where it stopped. It is safe for concurrent use.
+type Journal struct {
+	path  string
+	f     *os.File
+	mu    sync.Mutex
+	steps map[string]Step
+	count int
+}
+
+// JournalPath returns the journal path for the state file at path.
+func JournalPath(path string) string {
+	return path + ".journal"
+}
+
+// OpenJournal opens the journal at path for appending. With resume, the steps of
+// an earlier, unfinished apply are loaded from it; without, it refuses to
+// discard them.
+func OpenJournal(path string, resume bool) (*Journal, error) {
+	j := &Journal{path: path, steps: make(map[string]Step)}
+	// complete is the length of the journal up to its last complete line
+	var complete int64
+	f, err := os.Open(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	default:
+		reader := bufio.NewReader(f)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if errors.Is(err, io.EOF) {
+				// A line cut short by a crash is the last one; everything before it stands
+				break
+			}
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("failed to read journal: %w", err)
+			}
+			var step Step
+			if err := json.Unmarshal(line, &step); err != nil {
+				break
+			}
+			j.steps[stepKey(step.Key, step.Name)] = step
+			j.count++
+			complete += int64(len(line))
+		}
+		f.Close()
+	}
+	if j.count > 0 && !resume {
+		return nil, fmt.Errorf("%s records %d steps of an unfinished apply; rerun with --resume to continue it, or remove the file to start over", path, j.count)
+	}
+
+	j.f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	// Drop a torn last line, so that new steps start on a line of their own
+	if err := j.f.Truncate(complete); err != nil {
+		j.f.Close()
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	return j, nil
+}
+
+func stepKey(key, name string) string {
+	return key + "\x00" + name
+}
+
+// Step returns the journaled step name for key, if an earlier apply completed it.
+func (j *Journal) Step(key, name string) (Step, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	step, ok := j.steps[stepKey(key, name)]
+	return step, ok
+}
+
+// Len returns the number of steps loaded from an earlier apply.
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.count
+}
+
+// Record appends a completed step and syncs it to disk.
+func (j *Journal) Record(step Step) error {
+	step.At = time.Now().UTC()
+	data, err := json.Marshal(step)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal step: %w", err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	j.steps[stepKey(step.Key, step.Name)] = step
+	return nil
+}
+
+// Close closes the journal file, keeping it for a later resume.
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
+
+// Remove closes and deletes the journal once its apply has finished.
+func (j *Journal) Remove() error {
+	j.f.Close()
+	return os.Remove(j.path)
+}
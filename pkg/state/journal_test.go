@@ -0,0 +1,93 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJournal_Resume(t *testing.T) {
+	path := JournalPath(filepath.Join(t.TempDir(), DefaultPath))
+	j, err := OpenJournal(path, false)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	if err := j.Record(Step{Key: "title:Epic 1", Name: "resolve", Number: 7, NodeID: "node-7", Created: true}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := j.Record(Step{Key: "title:Epic 1", Name: "project", ItemID: "item-7"}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	j.Close()
+
+	// Simulate a crash part way through writing the next step
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	f.WriteString(`{"key":"title:Epic 1","st`)
+	f.Close()
+
+	if _, err := OpenJournal(path, false); err == nil || !strings.Contains(err.Error(), "--resume") {
+		t.Errorf("expected an unfinished journal to be refused without resume, got %v", err)
+	}
+
+	j, err = OpenJournal(path, true)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	if j.Len() != 2 {
+		t.Errorf("expected 2 journaled steps, got %d", j.Len())
+	}
+	step, ok := j.Step("title:Epic 1", "resolve")
+	if !ok || step.Number != 7 || step.NodeID != "node-7" || !step.Created {
+		t.Errorf("unexpected resolve step: %+v (found %v)", step, ok)
+	}
+	if _, ok := j.Step("title:Epic 1", "status"); ok {
+		t.Errorf("expected no status step")
+	}
+
+	if err := j.Remove(); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the journal to be removed, got %v", err)
+	}
+}
+
+func TestJournal_ResumeTwiceAfterTornWrite(t *testing.T) {
+	path := JournalPath(filepath.Join(t.TempDir(), DefaultPath))
+	j, err := OpenJournal(path, false)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	j.Record(Step{Key: "title:Epic 1", Name: "resolve", Number: 7})
+	j.Close()
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	f.WriteString(`{"key":"title:Epic 1","st`)
+	f.Close()
+
+	// The first resume journals more steps after the torn line
+	j, err = OpenJournal(path, true)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	if err := j.Record(Step{Key: "title:Epic 1", Name: "project", ItemID: "item-7"}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := j.Record(Step{Key: "title:Child", Name: "resolve", Number: 8}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	j.Close()
+
+	// The second resume still sees every step
+	j, err = OpenJournal(path, true)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	defer j.Close()
+	if j.Len() != 3 {
+		t.Errorf("expected 3 journaled steps, got %d", j.Len())
+	}
+	if step, ok := j.Step("title:Child", "resolve"); !ok || step.Number != 8 {
+		t.Errorf("expected the step journaled after the torn line, got %+v (found %v)", step, ok)
+	}
+}
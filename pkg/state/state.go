@@ -0,0 +1,264 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultPath is the state file used by apply when no other path is given.
+const DefaultPath = ".gh-project-helper.state.json"
+
+// ErrLocked is returned by AcquireLock when another process holds the state lock.
+var ErrLocked = errors.New("state is locked")
+
+// State records which GitHub objects each plan element resolved to or created,
+// so later runs can skip lookups. It is safe for concurrent use.
+type State struct {
+	Repository   string               `json:"repository"`
+	Project      string               `json:"project"`
+	RepositoryID string               `json:"repository_id,omitempty"`
+	ProjectID    string               `json:"project_id,omitempty"`
+	Milestones   map[string]Milestone `json:"milestones,omitempty"`
+	Issues       map[string]Issue     `json:"issues,omitempty"`
+	Labels       map[string]string    `json:"labels,omitempty"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+
+	mu sync.Mutex
+}
+
+// Milestone is the recorded GitHub milestone for a plan milestone.
+type Milestone struct {
+	Title  string `json:"title"`
+	Number int    `json:"number"`
+	NodeID string `json:"node_id"`
+}
+
+// Issue is the recorded GitHub issue for a plan epic or child issue.
+type Issue struct {
+	Kind          string   `json:"kind"`
+	Title         string   `json:"title"`
+	Number        int      `json:"number"`
+	NodeID        string   `json:"node_id"`
+	ProjectItemID string   `json:"project_item_id,omitempty"`
+	MilestoneID   string   `json:"milestone_id,omitempty"`
+	LabelIDs      []string `json:"label_ids,omitempty"`
+}
+
+// Key returns the state key for a plan element: its ID when set, otherwise its title.
+func Key(id, title string) string {
+	if id != "" {
+		return "id:" + id
+	}
+	return "title:" + title
+}
+
+// New returns an empty state for the given repository and project.
+func New(repository, project string) *State {
+	return &State{
+		Repository: repository,
+		Project:    project,
+		Milestones: make(map[string]Milestone),
+		Issues:     make(map[string]Issue),
+		Labels:     make(map[string]string),
+	}
+}
+
+// Load reads the state file at path. A missing file yields an empty state.
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return New("", ""), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	st := New("", "")
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if st.Milestones == nil {
+		st.Milestones = make(map[string]Milestone)
+	}
+	if st.Issues == nil {
+		st.Issues = make(map[string]Issue)
+	}
+	if st.Labels == nil {
+		st.Labels = make(map[string]string)
+	}
+	return st, nil
+}
+
+// Save writes the state to path, replacing the previous file atomically.
+func (s *State) Save(path string) error {
+	s.mu.Lock()
+	s.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Bind associates the state with a repository and project. It fails if the
+// state already belongs to a different one.
+func (s *State) Bind(repository, project string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Repository != "" && (s.Repository != repository || s.Project != project) {
+		return fmt.Errorf("state belongs to %s (project %q), not %s (project %q)", s.Repository, s.Project, repository, project)
+	}
+	s.Repository = repository
+	s.Project = project
+	return nil
+}
+
+// Issue returns the recorded issue for key.
+func (s *State) Issue(key string) (Issue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issue, ok := s.Issues[key]
+	return issue, ok
+}
+
+// SetIssue records the issue for key.
+func (s *State) SetIssue(key string, issue Issue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Issues[key] = issue
+}
+
+// DeleteIssue forgets the issue recorded for key.
+func (s *State) DeleteIssue(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Issues, key)
+}
+
+// Milestone returns the recorded milestone for key.
+func (s *State) Milestone(key string) (Milestone, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.Milestones[key]
+	return m, ok
+}
+
+// SetMilestone records the milestone for key.
+func (s *State) SetMilestone(key string, m Milestone) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Milestones[key] = m
+}
+
+// Label returns the recorded node ID for a label name.
+func (s *State) Label(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.Labels[name]
+	return id, ok
+}
+
+// SetLabel records the node ID for a label name.
+func (s *State) SetLabel(name, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Labels[name] = id
+}
+
+// MilestoneKeys returns the keys of all recorded milestones in sorted order.
+func (s *State) MilestoneKeys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.Milestones))
+	for k := range s.Milestones {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IssueKeys returns the keys of all recorded issues in sorted order.
+func (s *State) IssueKeys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.Issues))
+	for k := range s.Issues {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Lock is an exclusive lock on a state file, held by creating a sibling .lock file.
+type Lock struct {
+	path string
+}
+
+// LockInfo describes who holds a state lock.
+type LockInfo struct {
+	User     string    `json:"user"`
+	Host     string    `json:"host"`
+	PID      int       `json:"pid"`
+	Acquired time.Time `json:"acquired"`
+}
+
+// LockPath returns the lock file path for the state file at path.
+func LockPath(path string) string {
+	return path + ".lock"
+}
+
+// AcquireLock takes the lock for the state file at path. It returns an error
+// wrapping ErrLocked, describing the holder, if the lock is already taken.
+func AcquireLock(path string) (*Lock, error) {
+	lockPath := LockPath(path)
+	f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		var info LockInfo
+		if data, readErr := os.ReadFile(lockPath); readErr == nil {
+			_ = json.Unmarshal(data, &info)
+		}
+		return nil, fmt.Errorf("%w by %s@%s (pid %d) since %s; if that apply is no longer running, remove %s",
+			ErrLocked, info.User, info.Host, info.PID, info.Acquired.Format(time.RFC3339), lockPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock file: %w", err)
+	}
+	defer f.Close()
+
+	host, _ := os.Hostname()
+	user := os.Getenv("USER")
+	if user == "" {
+		user = os.Getenv("USERNAME")
+	}
+	info := LockInfo{User: user, Host: host, PID: os.Getpid(), Acquired: time.Now().UTC()}
+	if err := json.NewEncoder(f).Encode(info); err != nil {
+		os.Remove(lockPath)
+		return nil, fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return &Lock{path: lockPath}, nil
+}
+
+// Release removes the lock file.
+func (l *Lock) Release() error {
+	return os.Remove(l.path)
+}
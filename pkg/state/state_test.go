@@ -0,0 +1,94 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	st, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(st.Issues) != 0 || st.Repository != "" {
+		t.Errorf("expected empty state, got %+v", st)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultPath)
+	st := New("owner/repo", "Board")
+	st.RepositoryID = "repo-id"
+	st.SetIssue(Key("epic-1", "Epic 1"), Issue{Kind: "epic", Title: "Epic 1", Number: 7, NodeID: "node-7", LabelIDs: []string{"label-a"}})
+	st.SetMilestone(Key("", "Phase 1"), Milestone{Title: "Phase 1", Number: 1, NodeID: "ms-1"})
+	st.SetLabel("backend", "label-a")
+
+	if err := st.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	issue, ok := loaded.Issue("id:epic-1")
+	if !ok || issue.Number != 7 || issue.NodeID != "node-7" {
+		t.Errorf("unexpected issue after round-trip: %+v (found %v)", issue, ok)
+	}
+	if m, ok := loaded.Milestone("title:Phase 1"); !ok || m.NodeID != "ms-1" {
+		t.Errorf("unexpected milestone after round-trip: %+v", m)
+	}
+	if id, ok := loaded.Label("backend"); !ok || id != "label-a" {
+		t.Errorf("unexpected label after round-trip: %q", id)
+	}
+	if loaded.RepositoryID != "repo-id" {
+		t.Errorf("expected repository id to round-trip, got %q", loaded.RepositoryID)
+	}
+}
+
+func TestMilestoneKeys_Sorted(t *testing.T) {
+	st := New("owner/repo", "Board")
+	for _, title := range []string{"Phase 3", "Phase 1", "Phase 2"} {
+		st.SetMilestone(Key("", title), Milestone{Title: title})
+	}
+	if got := fmt.Sprint(st.MilestoneKeys()); got != "[title:Phase 1 title:Phase 2 title:Phase 3]" {
+		t.Errorf("expected sorted milestone keys, got %s", got)
+	}
+}
+
+func TestBind(t *testing.T) {
+	st := New("", "")
+	if err := st.Bind("owner/repo", "Board"); err != nil {
+		t.Fatalf("Bind on empty state failed: %v", err)
+	}
+	if err := st.Bind("owner/repo", "Board"); err != nil {
+		t.Errorf("Bind to the same plan failed: %v", err)
+	}
+	if err := st.Bind("owner/other", "Board"); err == nil {
+		t.Error("expected error binding state to a different repository")
+	}
+}
+
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultPath)
+
+	lock, err := AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+
+	if _, err := AcquireLock(path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked for second lock, got %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	lock, err = AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock after release failed: %v", err)
+	}
+	lock.Release()
+}
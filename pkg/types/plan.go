@@ -2,33 +2,125 @@ package types
 
 // Plan defines the structure of the YAML/JSON file
 type Plan struct {
-	Project    string       `yaml:"project" json:"project"`
-	Repository string       `yaml:"repository" json:"repository"`
-	Milestones []Milestone  `yaml:"milestones" json:"milestones"`
-	Epics      []Epic       `yaml:"epics" json:"epics"`
+	Project    string      `yaml:"project" json:"project"`
+	Repository string      `yaml:"repository" json:"repository"`
+	Milestones []Milestone `yaml:"milestones" json:"milestones"`
+	Epics      []Epic      `yaml:"epics" json:"epics"`
+	// Host is the GitHub host of the repository and project, such as a GitHub
+	// Enterprise Server. Empty means the configured host, github.com by default;
+	// any other value must match the configured host.
+	Host string `yaml:"host,omitempty" json:"host,omitempty"`
+	// Linking selects how child issues are linked to their epic: LinkTasklist
+	// (the default), LinkSubIssues or LinkBoth.
+	Linking string `yaml:"linking,omitempty" json:"linking,omitempty"`
+	// IterationField names the iteration field set by Iteration on epics and
+	// issues. It may be omitted when the project has a single iteration field.
+	IterationField string `yaml:"iteration_field,omitempty" json:"iteration_field,omitempty"`
+	// MaxDepth limits how deeply issues may be nested, counting epics as level 1.
+	// Zero means DefaultMaxDepth.
+	MaxDepth int `yaml:"max_depth,omitempty" json:"max_depth,omitempty"`
+	// Labels declares repository labels, which apply creates, updates and renames
+	// to match before creating issues.
+	Labels []Label `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// LabelPolicy selects which labels epics and issues may use. It defaults to
+	// LabelPolicyDeclared when Labels is set and LabelPolicyAny otherwise.
+	LabelPolicy string `yaml:"label_policy,omitempty" json:"label_policy,omitempty"`
 }
 
-// Milestone defines a milestone
+// DefaultMaxDepth is the nesting limit when a plan does not set max_depth. It
+// matches the number of levels GitHub allows for sub-issues.
+const DefaultMaxDepth = 8
+
+// Linking strategies for connecting child issues to their epic.
+const (
+	// LinkTasklist appends a "- [ ] #N" line per child to the epic body.
+	LinkTasklist = "tasklist"
+	// LinkSubIssues makes each child a native GitHub sub-issue of the epic.
+	LinkSubIssues = "sub_issues"
+	// LinkBoth uses both the tasklist and native sub-issues.
+	LinkBoth = "both"
+)
+
+// Label policies for labels used by epics and issues.
+const (
+	// LabelPolicyDeclared allows only labels declared in the labels section.
+	LabelPolicyDeclared = "declared"
+	// LabelPolicyAny also allows undeclared labels, which are created with
+	// GitHub's default color and no description.
+	LabelPolicyAny = "any"
+)
+
+// Label declares a repository label. Color is a six digit hex code, with or
+// without a leading '#'. An empty Color or Description leaves the existing
+// value unchanged. Aliases are former names: an existing label with one of them
+// is renamed to Name.
+type Label struct {
+	Name        string   `yaml:"name" json:"name"`
+	Color       string   `yaml:"color,omitempty" json:"color,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Aliases     []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+}
+
+// Milestone defines a milestone.
+// ID is an optional stable identifier embedded in the description so the
+// milestone can be found again after it is renamed. DueOn is a date such as
+// 2026-04-01 or an RFC 3339 time. State is MilestoneOpen or MilestoneClosed.
+// An empty DueOn, Description or State leaves the existing value unchanged.
 type Milestone struct {
+	ID          string `yaml:"id,omitempty" json:"id,omitempty"`
 	Title       string `yaml:"title" json:"title"`
 	DueOn       string `yaml:"due_on" json:"due_on"`
 	Description string `yaml:"description" json:"description"`
+	State       string `yaml:"state,omitempty" json:"state,omitempty"`
 }
 
-// Epic defines an epic
+// Milestone states.
+const (
+	MilestoneOpen   = "open"
+	MilestoneClosed = "closed"
+)
+
+// Epic defines an epic.
+// ID is an optional stable identifier embedded as a hidden marker in the
+// issue body; it takes precedence over the title when finding the issue.
+// Fields sets Project V2 custom fields by name; values are strings, numbers
+// or dates depending on the field type. Iteration is an iteration title or a
+// reference relative to today: "current", "next" or "+N". DependsOn and
+// Blocks name other epics or issues in the plan by ID or title.
 type Epic struct {
+	ID        string   `yaml:"id,omitempty" json:"id,omitempty"`
 	Title     string   `yaml:"title" json:"title"`
 	Body      string   `yaml:"body" json:"body"`
 	Milestone string   `yaml:"milestone" json:"milestone"`
 	Status    string   `yaml:"status" json:"status"`
 	Labels    []string `yaml:"labels" json:"labels"`
 	Assignees []string `yaml:"assignees" json:"assignees"`
+	Fields    Fields   `yaml:"fields,omitempty" json:"fields,omitempty"`
+	Iteration string   `yaml:"iteration,omitempty" json:"iteration,omitempty"`
+	DependsOn []string `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
+	Blocks    []string `yaml:"blocks,omitempty" json:"blocks,omitempty"`
 	Children  []Issue  `yaml:"children" json:"children"`
 }
 
-// Issue defines a child issue
+// Issue defines a child issue, which may have children of its own. ID, Fields,
+// Iteration, DependsOn and Blocks work as they do for Epic. Milestone, Status, Assignees and
+// Iteration are inherited from the parent when omitted, and Fields are merged
+// over the parent's. Assignees is a pointer so that an explicit empty list, which
+// leaves the child unassigned, survives saving and loading the plan.
 type Issue struct {
-	Title  string   `yaml:"title" json:"title"`
-	Body   string   `yaml:"body" json:"body"`
-	Labels []string `yaml:"labels" json:"labels"`
+	ID        string    `yaml:"id,omitempty" json:"id,omitempty"`
+	Title     string    `yaml:"title" json:"title"`
+	Body      string    `yaml:"body" json:"body"`
+	Milestone string    `yaml:"milestone,omitempty" json:"milestone,omitempty"`
+	Status    string    `yaml:"status,omitempty" json:"status,omitempty"`
+	Labels    []string  `yaml:"labels" json:"labels"`
+	Assignees *[]string `yaml:"assignees,omitempty" json:"assignees,omitempty"`
+	Fields    Fields    `yaml:"fields,omitempty" json:"fields,omitempty"`
+	Iteration string    `yaml:"iteration,omitempty" json:"iteration,omitempty"`
+	DependsOn []string  `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
+	Blocks    []string  `yaml:"blocks,omitempty" json:"blocks,omitempty"`
+	Children  []Issue   `yaml:"children,omitempty" json:"children,omitempty"`
 }
+
+// Fields maps Project V2 field names to the values to set.
+type Fields map[string]interface{}